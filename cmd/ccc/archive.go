@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/archive"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pathglob"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pins"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// archivingQuarantine adds session data to a bundle before it is removed.
+// Paths outside the directories a bundle may hold, such as settings files,
+// are only removed.
+type archivingQuarantine struct {
+	w *archive.Writer
+	q cleaner.Quarantine
+}
+
+// Remove archives path, then removes it through the wrapped quarantine. A
+// path that cannot be archived is not removed.
+func (aq *archivingQuarantine) Remove(path string) error {
+	if aq.w.Contains(path) {
+		if err := aq.w.Add(path); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", path, err)
+		}
+	}
+	return aq.q.Remove(path)
+}
+
+// Preserve keeps a copy of path in the wrapped quarantine.
+func (aq *archivingQuarantine) Preserve(path string) error {
+	return aq.q.Preserve(path)
+}
+
+// Created records path in the wrapped quarantine.
+func (aq *archivingQuarantine) Created(path string) error {
+	return aq.q.Created(path)
+}
+
+// cleanWithArchive runs the clean command, archiving what it removes to the
+// bundle given by --archive-to.
+func (a *app) cleanWithArchive(q cleaner.Quarantine, command string) int {
+	if a.args.ArchiveTo == "" {
+		return a.handleClean(q)
+	}
+
+	w, err := archive.Create(a.args.ArchiveTo, a.paths.Root, command)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	code := a.handleClean(&archivingQuarantine{w: w, q: q})
+	if err := w.Close(); err != nil {
+		fmt.Fprintln(a.stderr, "Error writing archive:", err)
+		return 1
+	}
+	a.reportArchive(w)
+	return code
+}
+
+// reportArchive reports a written bundle. Nothing is written for a bundle
+// that did not receive any files.
+func (a *app) reportArchive(w *archive.Writer) {
+	m := w.Manifest()
+	if len(m.Files) == 0 {
+		return
+	}
+	if a.machine() {
+		a.emit(output.NewArchive(w.Path(), m))
+	}
+	a.printf("Archived %d files (%s) to %s\n", len(m.Files), ui.FormatSize(m.TotalSize()), w.Path())
+}
+
+// handleArchive handles the "archive" command, which bundles the session
+// files and linked data of the given projects and sessions.
+func (a *app) handleArchive() int {
+	if len(a.args.Targets) == 0 || a.args.ArchiveTo == "" {
+		fmt.Fprintln(a.stderr, "Usage: cccc archive <project|session>... --to <file.tar.zst|file.tar.gz>")
+		return 1
+	}
+
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	changes, err := archiveTargets(a.paths.Projects, inv.Projects, cleaner.LinkedData(a.paths), a.args.Targets)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	w, err := archive.Create(a.args.ArchiveTo, a.paths.Root, "archive")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	preview := &ui.Preview{Title: "Archive to " + a.args.ArchiveTo, Changes: changes}
+	if a.args.DryRun {
+		var records []any
+		for _, c := range changes {
+			records = append(records, output.NewResult("archive", string(ui.ActionCreate), c.Path, c.Size, nil))
+		}
+		a.showDryRun(preview, "archive", records)
+		return 0
+	}
+
+	for _, c := range changes {
+		if err := w.Add(c.Path); err != nil {
+			w.Abort()
+			fmt.Fprintf(a.stderr, "Error archiving %s: %v\n", c.Path, err)
+			return 1
+		}
+	}
+	if err := w.Close(); err != nil {
+		fmt.Fprintln(a.stderr, "Error writing archive:", err)
+		return 1
+	}
+	a.reportArchive(w)
+	return 0
+}
+
+// archiveTargets resolves the targets of the archive command to the paths to
+// bundle. A target is a session ID, a unique prefix of one, or a project path
+// or glob pattern. Each target must match something.
+func archiveTargets(projectsDir string, projects []claude.Project, linked map[string][]string, targets []string) ([]ui.Change, error) {
+	var changes []ui.Change
+	seen := make(map[string]bool)
+	add := func(path, description string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		changes = append(changes, ui.Change{
+			Action:      ui.ActionCreate,
+			Path:        path,
+			Description: description,
+			Size:        cleaner.LinkedSize([]string{path}),
+		})
+	}
+
+	for _, target := range targets {
+		if p, s, err := findSession(projects, target); err == nil {
+			add(s.FilePath, "session "+s.ID)
+			for _, path := range slices.Concat(linked[s.ID], p.AgentData(s.ID)) {
+				add(path, "linked to session "+s.ID)
+			}
+			continue
+		}
+
+		pattern, err := pins.Normalize(target)
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, p := range projects {
+			if p.ActualPath == "" || !pathglob.MatchTree(pattern, p.ActualPath) {
+				continue
+			}
+			matched = true
+			add(filepath.Join(projectsDir, p.EncodedName), "project "+p.ActualPath)
+			for _, id := range p.SessionIDs {
+				for _, path := range linked[id] {
+					add(path, "linked to session "+id)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no project or session matches %s", target)
+		}
+	}
+	return changes, nil
+}
+
+// handleImport handles the "import" command, which restores a bundle into the
+// Claude home without overwriting existing files.
+func (a *app) handleImport() int {
+	if len(a.args.Targets) != 1 {
+		fmt.Fprintln(a.stderr, "Usage: cccc import <file.tar.zst|file.tar.gz> [--dry-run]")
+		return 1
+	}
+	bundle := a.args.Targets[0]
+
+	planned, err := archive.Import(bundle, a.paths.Root, true)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	preview := &ui.Preview{Title: "Import " + bundle}
+	for _, e := range planned.Restored {
+		preview.Changes = append(preview.Changes, ui.Change{Action: ui.ActionRestore, Path: a.importPath(e), Size: e.Size})
+	}
+	for _, e := range planned.Identical {
+		preview.Kept = append(preview.Kept, ui.Change{Path: a.importPath(e), Description: "already present"})
+	}
+	for _, e := range planned.Conflicts {
+		preview.Kept = append(preview.Kept, ui.Change{Path: a.importPath(e), Description: "exists with different content, not overwritten"})
+	}
+	conflicts := a.reportConflicts(planned)
+
+	if len(preview.Changes) == 0 {
+		a.printf("Nothing to import from %s.\n", bundle)
+		return conflicts
+	}
+
+	if a.args.DryRun {
+		var records []any
+		for _, c := range preview.Changes {
+			records = append(records, output.NewResult("import", string(ui.ActionRestore), c.Path, c.Size, nil))
+		}
+		a.showDryRun(preview, "import", records)
+		return conflicts
+	}
+
+	confirmed, err := a.confirm(preview, "import")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	result, err := archive.Import(bundle, a.paths.Root, false)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	summary := output.NewSummary("import")
+	var restoredSize int64
+	for _, e := range result.Restored {
+		path := a.importPath(e)
+		restoredSize += e.Size
+		if auditLogger != nil {
+			_ = auditLogger.LogWithDetails(ui.ActionRestore, path, fmt.Sprintf("from %s, size %d", bundle, e.Size))
+		}
+		if a.machine() {
+			a.emit(output.NewResult("import", string(ui.ActionRestore), path, e.Size, nil))
+			summary.Items++
+		}
+	}
+
+	// The imported sessions are picked up by the next scan
+	a.inv = nil
+
+	if a.machine() {
+		summary.Size = restoredSize
+		a.emit(summary)
+	}
+	a.printf("Imported %d files (%s) from %s\n", len(result.Restored), ui.FormatSize(restoredSize), bundle)
+	return conflicts
+}
+
+// importPath returns the path a bundle entry is restored to.
+func (a *app) importPath(e archive.Entry) string {
+	return filepath.Join(a.paths.Root, filepath.FromSlash(e.Path))
+}
+
+// reportConflicts warns about files of a bundle that differ from the files
+// already present, and returns the exit code to use for them.
+func (a *app) reportConflicts(r *archive.ImportResult) int {
+	if len(r.Conflicts) == 0 {
+		return 0
+	}
+	for _, e := range r.Conflicts {
+		fmt.Fprintf(a.stderr, "Warning: %s exists with different content, not overwritten\n", a.importPath(e))
+	}
+	return 1
+}
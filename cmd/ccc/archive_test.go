@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs_Archive(t *testing.T) {
+	args, err := parseArgs([]string{"archive", "full1", "~/Code/webapp", "--to", "out.tar.zst"})
+	require.NoError(t, err)
+	assert.Equal(t, "archive", args.Command)
+	assert.Equal(t, []string{"full1", "~/Code/webapp"}, args.Targets)
+	assert.Equal(t, "out.tar.zst", args.ArchiveTo)
+
+	args, err = parseArgs([]string{"clean", "projects", "--archive-to=out.tar.gz"})
+	require.NoError(t, err)
+	assert.Equal(t, "out.tar.gz", args.ArchiveTo)
+
+	args, err = parseArgs([]string{"import", "out.tar.gz"})
+	require.NoError(t, err)
+	assert.Equal(t, "import", args.Command)
+	assert.Equal(t, []string{"out.tar.gz"}, args.Targets)
+}
+
+func TestRunCLI_ArchiveAndImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	session := setupFullSession(t, tmpDir)
+	todo := filepath.Join(tmpDir, ".claude", "todos", "full1-agent-full1.json")
+	bundle := filepath.Join(tmpDir, "full1.tar.zst")
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"archive", "full1", "--to", bundle}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Archived 2 files")
+	assert.FileExists(t, bundle)
+
+	// Importing over the untouched data changes nothing
+	stdout.Reset()
+	code = runCLI([]string{"import", bundle, "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Nothing to import")
+
+	require.NoError(t, os.Remove(session))
+	require.NoError(t, os.Remove(todo))
+
+	stdout.Reset()
+	code = runCLI([]string{"import", bundle, "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "[RESTORE] "+session)
+	assert.NoFileExists(t, session)
+
+	stdout.Reset()
+	code = runCLI([]string{"import", bundle, "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Imported 2 files")
+	assert.FileExists(t, session)
+	assert.FileExists(t, todo)
+}
+
+func TestRunCLI_ImportConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	session := setupFullSession(t, tmpDir)
+	bundle := filepath.Join(tmpDir, "full1.tar.gz")
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"archive", "full1", "--to", bundle}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	require.NoError(t, os.WriteFile(session, []byte(`{"sessionId":"full1","cwd":"/changed"}`+"\n"), 0644))
+
+	stderr.Reset()
+	code = runCLI([]string{"import", bundle, "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), session+" exists with different content")
+
+	data, err := os.ReadFile(session)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/changed")
+}
+
+func TestRunCLI_ArchiveErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no target", []string{"archive", "--to", filepath.Join(tmpDir, "a.tar.zst")}, "Usage: cccc archive"},
+		{"no bundle", []string{"archive", "full1"}, "Usage: cccc archive"},
+		{"unknown target", []string{"archive", "nothing", "--to", filepath.Join(tmpDir, "a.tar.zst")}, "no project or session matches nothing"},
+		{"unsupported format", []string{"archive", "full1", "--to", filepath.Join(tmpDir, "a.zip")}, "Error:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCLI(tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, 1, code)
+			assert.Contains(t, stderr.String(), tt.want)
+		})
+	}
+	assert.NoFileExists(t, filepath.Join(tmpDir, "a.tar.zst"))
+}
+
+func TestRunCLI_CleanProjectsArchiveTo(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, ".claude", "projects", "-gone")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	gone := filepath.ToSlash(filepath.Join(tmpDir, "gone"))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "sess1.jsonl"),
+		[]byte(`{"sessionId":"sess1","cwd":"`+gone+`","timestamp":"2025-01-01T00:00:00Z"}`+"\n"), 0644))
+	bundle := filepath.Join(tmpDir, "stale.tar.gz")
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	// A dry run writes no bundle
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "projects", "--dry-run", "--archive-to", bundle}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoFileExists(t, bundle)
+	assert.DirExists(t, projectDir)
+
+	stdout.Reset()
+	code = runCLI([]string{"clean", "projects", "--yes", "--archive-to", bundle}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Archived 1 files")
+	assert.FileExists(t, bundle)
+	assert.NoDirExists(t, projectDir)
+
+	// The bundle brings the project back
+	stdout.Reset()
+	code = runCLI([]string{"import", bundle, "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.FileExists(t, filepath.Join(projectDir, "sess1.jsonl"))
+}
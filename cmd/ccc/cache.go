@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// loadIndex loads the scan index. An unreadable index is reported and
+// replaced by an empty one, so it never stops a run.
+func (a *app) loadIndex() *claude.Index {
+	path := claude.DefaultIndexPath(a.paths.Root)
+	index, err := claude.LoadIndex(path)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Warning: ignoring scan index:", err)
+		return claude.NewIndex(path)
+	}
+	return index
+}
+
+// handleCache handles the "cache" command and subcommands.
+func (a *app) handleCache() int {
+	switch a.args.Subcommand {
+	case "":
+		return a.showCache()
+	case "rebuild":
+		return a.rebuildCache()
+	case "clear":
+		return a.clearCache()
+	default:
+		fmt.Fprintf(a.stderr, "Unknown cache subcommand: %s\n", a.args.Subcommand)
+		return 1
+	}
+}
+
+// showCache prints the location, number of entries and size of the index.
+func (a *app) showCache() int {
+	index := a.loadIndex()
+
+	var size int64
+	if info, err := os.Stat(index.Path()); err == nil {
+		size = info.Size()
+	}
+
+	if a.machine() {
+		a.emit(struct {
+			Kind  string `json:"kind"`
+			Path  string `json:"path"`
+			Files int    `json:"files"`
+			Size  int64  `json:"size"`
+		}{"index", index.Path(), index.Len(), size})
+		return 0
+	}
+
+	if index.Len() == 0 {
+		fmt.Fprintf(a.stdout, "Scan index %s is empty.\n", index.Path())
+		return 0
+	}
+	fmt.Fprintf(a.stdout, "Scan index %s: %d session files, %s\n", index.Path(), index.Len(), ui.FormatSize(size))
+	return 0
+}
+
+// rebuildCache parses every session file into a new index.
+func (a *app) rebuildCache() int {
+	index := claude.NewIndex(claude.DefaultIndexPath(a.paths.Root))
+	if _, err := a.scan(index); err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	if err := index.Save(); err != nil {
+		fmt.Fprintln(a.stderr, "Error saving scan index:", err)
+		return 1
+	}
+
+	if a.machine() {
+		summary := output.NewSummary("cache")
+		summary.Items = index.Len()
+		a.emit(summary)
+	}
+	a.printf("Indexed %d session files in %s\n", index.Len(), index.Path())
+	return 0
+}
+
+// clearCache removes the index file.
+func (a *app) clearCache() int {
+	path := claude.DefaultIndexPath(a.paths.Root)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		a.printf("No scan index at %s.\n", path)
+		return 0
+	}
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error removing scan index:", err)
+		return 1
+	}
+
+	if a.machine() {
+		a.emit(output.NewResult("cache", string(ui.ActionDelete), path, info.Size(), nil))
+	}
+	a.printf("Removed scan index %s\n", path)
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs_Cache(t *testing.T) {
+	args, err := parseArgs([]string{"cache", "rebuild"})
+	require.NoError(t, err)
+	assert.Equal(t, "cache", args.Command)
+	assert.Equal(t, "rebuild", args.Subcommand)
+
+	args, err = parseArgs([]string{"list", "--no-cache"})
+	require.NoError(t, err)
+	assert.True(t, args.NoCache)
+}
+
+func TestRunCLI_ListIdenticalWithAndWithoutCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStaleProject(t, tmpDir)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+	indexPath := filepath.Join(tmpDir, ".claude", "cccc-index")
+
+	var uncached, stderr bytes.Buffer
+	code := runCLI([]string{"list", "projects", "-o", "json", "--no-cache"}, strings.NewReader(""), &uncached, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoFileExists(t, indexPath, "--no-cache neither reads nor writes the index")
+
+	// The first run builds the index, the second one uses it
+	for range 2 {
+		var cached bytes.Buffer
+		code = runCLI([]string{"list", "projects", "-o", "json"}, strings.NewReader(""), &cached, &stderr)
+		require.Equal(t, 0, code, stderr.String())
+		assert.FileExists(t, indexPath)
+		assert.JSONEq(t, uncached.String(), cached.String())
+	}
+}
+
+func TestRunCLI_CacheRebuildAndClear(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStaleProject(t, tmpDir)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+	indexPath := filepath.Join(tmpDir, ".claude", "cccc-index")
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"cache", "rebuild"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Indexed 1 session files")
+	assert.FileExists(t, indexPath)
+
+	stdout.Reset()
+	code = runCLI([]string{"cache"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "1 session files")
+
+	stdout.Reset()
+	code = runCLI([]string{"cache", "clear"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Removed scan index")
+	assert.NoFileExists(t, indexPath)
+
+	stdout.Reset()
+	code = runCLI([]string{"cache", "clear"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "No scan index")
+}
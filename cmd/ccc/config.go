@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/policy"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/trash"
+)
+
+// applyArgs merges the command-line flags into the policy, then fills the
+// flags that were not given from the policy, so that handlers only need to
+// look at args.
+func applyArgs(p *policy.Policy, args *Args) error {
+	// Flags override the policy file
+	if args.Output != "" {
+		p.Output = args.Output
+	}
+	if args.Confirm != "" {
+		p.Confirm = args.Confirm
+	}
+	if args.Yes {
+		p.Confirm = policy.ConfirmYes
+	}
+	if args.DryRun {
+		p.Confirm = policy.ConfirmDryRun
+	}
+
+	// --older-than means the trash age for "trash purge"; everywhere else it
+	// is a retention rule
+	if args.Command != "trash" {
+		if args.OlderThan != "" {
+			p.Retention.OlderThan = args.OlderThan
+		}
+		if args.KeepLast != "" {
+			n, err := strconv.Atoi(args.KeepLast)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid --keep-last value: %s", args.KeepLast)
+			}
+			p.Retention.KeepLast = n
+		}
+		if args.MaxSize != "" {
+			p.Retention.MaxProjectSize = args.MaxSize
+		}
+		if args.KeepHistory != "" {
+			n, err := strconv.Atoi(args.KeepHistory)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid --keep-history value: %s", args.KeepHistory)
+			}
+			p.Retention.KeepHistory = &n
+		}
+		if args.KeepVersions != "" {
+			n, err := strconv.Atoi(args.KeepVersions)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid --keep-versions value: %s", args.KeepVersions)
+			}
+			p.Retention.KeepFileVersions = n
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	// The policy supplies everything the command line left out
+	args.Output = p.Output
+	args.Yes = p.Confirm == policy.ConfirmYes
+	args.DryRun = p.Confirm == policy.ConfirmDryRun
+
+	switch args.Command {
+	case "clean":
+		args.OlderThan = p.Retention.OlderThan
+		args.MaxSize = p.Retention.MaxProjectSize
+		if p.Retention.KeepLast > 0 {
+			args.KeepLast = strconv.Itoa(p.Retention.KeepLast)
+		}
+		if p.Retention.KeepHistory != nil {
+			args.KeepHistory = strconv.Itoa(*p.Retention.KeepHistory)
+		}
+	case "list":
+		if args.Subcommand == "" {
+			args.Subcommand = p.List
+		}
+	}
+
+	return nil
+}
+
+// handleConfig handles the "config" command and subcommands.
+func (a *app) handleConfig() int {
+	switch a.args.Subcommand {
+	case "show", "":
+		return a.showConfig()
+	case "promote":
+		return a.withTrashRun(func(run *trash.Run, _ string) int {
+			return a.promoteConfig(run)
+		})
+	default:
+		fmt.Fprintf(a.stderr, "Unknown config subcommand: %s\n", a.args.Subcommand)
+		return 1
+	}
+}
+
+// showConfig prints the effective policy: the defaults, overridden by the
+// policy file, overridden by command-line flags.
+func (a *app) showConfig() int {
+	_, err := os.Stat(a.policyPath)
+	found := err == nil
+
+	if a.machine() {
+		a.emit(struct {
+			Kind   string         `json:"kind"` // "policy"
+			Source string         `json:"source"`
+			Found  bool           `json:"found"`
+			Policy *policy.Policy `json:"policy"`
+		}{"policy", a.policyPath, found, a.policy})
+		return 0
+	}
+
+	if found {
+		fmt.Fprintf(a.stdout, "# Effective policy (from %s and command-line flags)\n", a.policyPath)
+	} else {
+		fmt.Fprintf(a.stdout, "# Effective policy (no policy file at %s, using defaults)\n", a.policyPath)
+	}
+	if err := a.policy.Encode(a.stdout); err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePolicyFile writes ~/.config/cccc/config.toml below tmpDir.
+func writePolicyFile(t *testing.T, tmpDir, content string) string {
+	path := filepath.Join(tmpDir, ".config", "cccc", "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestParseArgs_ConfigShow(t *testing.T) {
+	args, err := parseArgs([]string{"config", "show"})
+	require.NoError(t, err)
+	assert.Equal(t, "config", args.Command)
+	assert.Equal(t, "show", args.Subcommand)
+
+	// "config" stays a subcommand of clean and list
+	args, err = parseArgs([]string{"clean", "config"})
+	require.NoError(t, err)
+	assert.Equal(t, "clean", args.Command)
+	assert.Equal(t, "config", args.Subcommand)
+}
+
+func TestRunCLI_ConfigShowDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "show"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "using defaults")
+	assert.Contains(t, stdout.String(), `clean = ["projects", "orphans", "config"]`)
+	assert.Contains(t, stdout.String(), `confirm = "prompt"`)
+}
+
+func TestRunCLI_ConfigShowMergesFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	writePolicyFile(t, tmpDir, "confirm = \"yes\"\n[retention]\nolder_than = \"90d\"\n")
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "show", "--older-than", "30d", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, true, doc.Records[0]["found"])
+	policy := doc.Records[0]["policy"].(map[string]any)
+	assert.Equal(t, "yes", policy["confirm"])
+	assert.Equal(t, "json", policy["output"])
+	assert.Equal(t, "30d", policy["retention"].(map[string]any)["olderThan"])
+}
+
+func TestRunCLI_InvalidPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	writePolicyFile(t, tmpDir, `confirm = "sometimes"`)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Error loading policy")
+}
+
+func TestRunCLI_PolicyExcludeKeepsProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+	writePolicyFile(t, tmpDir, `exclude = ["~/this-path-does-not-exist-*"]`)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "projects", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "No stale projects found")
+	assert.DirExists(t, projectDir)
+
+	stdout.Reset()
+	code = runCLI([]string{"list", "projects"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "[PROTECTED]")
+	assert.Contains(t, stdout.String(), "excluded by policy")
+}
+
+func TestRunCLI_PolicyConfirmYesAndOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+	writePolicyFile(t, tmpDir, `confirm = "yes"`)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	// --confirm prompt overrides the policy, so the empty answer aborts
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "projects", "--confirm", "prompt"}, strings.NewReader("n\n"), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.DirExists(t, projectDir)
+
+	// Without the flag the policy applies and no prompt is shown
+	code = runCLI([]string{"clean", "projects"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.NoDirExists(t, projectDir)
+}
+
+func TestRunCLI_PolicyDefaultCleanAndAuditLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+	auditPath := filepath.Join(tmpDir, "logs", "audit.log")
+	writePolicyFile(t, tmpDir, `
+clean = ["projects"]
+audit_log = "~/logs/audit.log"
+`)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoDirExists(t, projectDir)
+	assert.NotContains(t, stdout.String(), "orphan", "only the policy's subcommands run")
+	assert.FileExists(t, auditPath)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/usage"
+)
+
+// defaultTop is the number of projects and sessions du shows by default.
+const defaultTop = 10
+
+// histogramWidth is the length of the bar of the month with the most data.
+const histogramWidth = 40
+
+// handleDu handles the "du" command, which reports the disk space used below
+// ~/.claude by category, the largest projects and sessions, and by month.
+func (a *app) handleDu() int {
+	top := defaultTop
+	if a.args.Top != "" {
+		n, err := strconv.Atoi(a.args.Top)
+		if err != nil || n < 1 {
+			fmt.Fprintf(a.stderr, "Error: invalid --top value: %s\n", a.args.Top)
+			return 1
+		}
+		top = n
+	}
+
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	r, err := usage.Measure(a.paths, inv.Projects)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	projects := r.Projects[:min(top, len(r.Projects))]
+	sessions := r.Sessions[:min(top, len(r.Sessions))]
+
+	if a.machine() {
+		a.emit(output.NewUsage(output.UsageTotal, r.Total))
+		for _, group := range []struct {
+			name    string
+			entries []usage.Entry
+		}{
+			{output.UsageCategory, r.Categories},
+			{output.UsageProject, projects},
+			{output.UsageSession, sessions},
+			{output.UsageMonth, r.Months},
+		} {
+			for _, e := range group.entries {
+				a.emit(output.NewUsage(group.name, e))
+			}
+		}
+		return 0
+	}
+
+	if r.Total.Files == 0 {
+		fmt.Fprintf(a.stdout, "No files in %s.\n", r.Root)
+		return 0
+	}
+
+	fmt.Fprintf(a.stdout, "%s in %d files below %s\n", ui.FormatSize(r.Total.Size), r.Total.Files, r.Root)
+
+	fmt.Fprintln(a.stdout, "\nBy category:")
+	for _, e := range r.Categories {
+		fmt.Fprintf(a.stdout, "  %10s  %7d files  %s\n", ui.FormatSize(e.Size), e.Files, e.Name)
+	}
+
+	if len(projects) > 0 {
+		fmt.Fprintln(a.stdout, "\nLargest projects (with the todos, file-history and session-env of their sessions):")
+		for _, e := range projects {
+			fmt.Fprintf(a.stdout, "  %10s  %7d files  %s\n", ui.FormatSize(e.Size), e.Files, e.Name)
+		}
+	}
+
+	if len(sessions) > 0 {
+		fmt.Fprintln(a.stdout, "\nLargest sessions (with their todos, file-history and session-env):")
+		for _, e := range sessions {
+			fmt.Fprintf(a.stdout, "  %10s  %s  %s\n", ui.FormatSize(e.Size), e.Name, cmp.Or(e.Project, "(unknown path)"))
+		}
+	}
+
+	fmt.Fprintln(a.stdout, "\nBy month last modified:")
+	var largest int64
+	for _, e := range r.Months {
+		largest = max(largest, e.Size)
+	}
+	for _, e := range r.Months {
+		fmt.Fprintf(a.stdout, "  %s  %10s  %s\n", e.Name, ui.FormatSize(e.Size), bar(e.Size, largest))
+	}
+	return 0
+}
+
+// bar returns a histogram bar for size, scaled so that largest fills
+// histogramWidth. Non-zero sizes get at least one character.
+func bar(size, largest int64) string {
+	if size <= 0 || largest <= 0 {
+		return ""
+	}
+	n := max(1, int(size*histogramWidth/largest))
+	return strings.Repeat("#", n)
+}
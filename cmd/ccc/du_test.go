@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCLI_Du(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupSessions(t, tmpDir)
+	setupFullSession(t, tmpDir)
+	debug := filepath.Join(tmpDir, ".claude", "debug", "full1.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(debug), 0755))
+	require.NoError(t, os.WriteFile(debug, make([]byte, 64*1024), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"du", "--top", "1", "--no-cache"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "below "+filepath.Join(tmpDir, ".claude"))
+	assert.Contains(t, out, "64.0 KB        1 files  debug")
+	assert.Contains(t, out, "files  projects")
+	assert.Contains(t, out, "files  todos")
+	assert.Contains(t, out, "full1  "+filepath.Join(tmpDir, "webapp"), "the largest session")
+	assert.NotContains(t, out, "old  ", "only the largest session is shown")
+	assert.Contains(t, out, "By month last modified:")
+	assert.Contains(t, out, "#")
+}
+
+func TestRunCLI_DuJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"du", "--output", "json", "--no-cache"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc), stdout.String())
+	groups := make(map[string][]map[string]any)
+	for _, r := range doc.Records {
+		assert.Equal(t, "usage", r["kind"])
+		groups[r["group"].(string)] = append(groups[r["group"].(string)], r)
+	}
+	require.Len(t, groups["total"], 1)
+	require.Len(t, groups["project"], 1)
+	require.Len(t, groups["session"], 1)
+	assert.NotEmpty(t, groups["month"])
+
+	session := groups["session"][0]
+	assert.Equal(t, "full1", session["name"])
+	assert.Equal(t, filepath.Join(tmpDir, "webapp"), session["project"])
+	assert.Equal(t, float64(2), session["files"], "the session file and its todo")
+	assert.Equal(t, groups["project"][0]["size"], session["size"])
+	assert.Equal(t, groups["total"][0]["size"], session["size"])
+}
+
+func TestRunCLI_DuInvalidTop(t *testing.T) {
+	cleanup := setTestHome(t, t.TempDir())
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"du", "--top", "0"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "invalid --top value: 0")
+}
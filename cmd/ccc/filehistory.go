@@ -0,0 +1,157 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// findFileHistory analyzes the file-history of the sessions of all projects
+// that are not protected, keeping the newest versions given by
+// --keep-versions or the policy.
+func (a *app) findFileHistory() ([]cleaner.TrackedFile, []ui.Change, bool) {
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return nil, nil, false
+	}
+	projects, protected := cleaner.ProtectProjects(inv.Projects, a.protect)
+
+	rules := cleaner.FileHistoryRules{KeepVersions: a.policy.Retention.KeepFileVersions}
+	files, err := cleaner.AnalyzeFileHistory(a.paths, projects, mounts.System(), rules)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error reading file history:", err)
+		return nil, nil, false
+	}
+	return files, protected, true
+}
+
+// listFileHistory lists the files with file-history snapshots by project,
+// with the snapshots that clean file-history would remove.
+func (a *app) listFileHistory() int {
+	files, _, ok := a.findFileHistory()
+	if !ok {
+		return 1
+	}
+
+	if a.machine() {
+		for _, f := range files {
+			a.emit(output.NewTrackedFile(f))
+		}
+		return 0
+	}
+
+	if len(files) == 0 {
+		fmt.Fprintln(a.stdout, "No file history found.")
+		return 0
+	}
+
+	var snapshots, prunable int
+	var totalSize, prunableSize int64
+	project := ""
+	fmt.Fprintln(a.stdout, "File history:")
+	for _, f := range files {
+		if f.Project.EncodedName != project {
+			project = f.Project.EncodedName
+			fmt.Fprintf(a.stdout, "  %s\n", cmp.Or(f.Project.ActualPath, "(unknown path)"))
+		}
+
+		line := fmt.Sprintf("    %s  %d versions, %s", cmp.Or(f.Source, "(unknown file)"), len(f.Snapshots), ui.FormatSize(f.Size()))
+		if p := f.Prunable(); len(p) > 0 {
+			line += fmt.Sprintf(", %d prunable (%s)", len(p), p[0].Reason)
+			prunable += len(p)
+			for _, s := range p {
+				prunableSize += s.Size
+			}
+		}
+		fmt.Fprintln(a.stdout, line)
+		snapshots += len(f.Snapshots)
+		totalSize += f.Size()
+	}
+	fmt.Fprintf(a.stdout, "\nTotal: %d files, %d snapshots, %s; %d prunable, %s\n",
+		len(files), snapshots, ui.FormatSize(totalSize), prunable, ui.FormatSize(prunableSize))
+	return 0
+}
+
+// cleanFileHistory removes the file-history snapshots of deleted projects and
+// files, and the versions beyond the newest --keep-versions of each file.
+func (a *app) cleanFileHistory(q cleaner.Quarantine) int {
+	files, protected, ok := a.findFileHistory()
+	if !ok {
+		return 1
+	}
+
+	var prunable []cleaner.TrackedFile
+	for _, f := range files {
+		if len(f.Prunable()) > 0 {
+			prunable = append(prunable, f)
+		}
+	}
+	if len(prunable) == 0 {
+		a.printf("No prunable file history found.\n")
+		return 0
+	}
+
+	preview := cleaner.BuildFileHistoryPreview(prunable)
+	preview.Kept = append(preview.Kept, protected...)
+
+	if a.args.DryRun {
+		var records []any
+		for _, f := range prunable {
+			records = append(records, output.NewTrackedFile(f))
+		}
+		a.showDryRun(preview, "file-history", records)
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "file-history")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	summary := output.NewSummary("file-history")
+	var totalSaved int64
+	cleaned := 0
+	for _, f := range prunable {
+		for _, s := range f.Prunable() {
+			if err := cleaner.CleanSnapshot(s, q, false); err != nil {
+				fmt.Fprintf(a.stderr, "Error removing snapshot %s: %v\n", s.Path, err)
+				if a.machine() {
+					a.emit(output.NewResult("file-history", string(ui.ActionDelete), s.Path, 0, err))
+					summary.Errors++
+				}
+				continue
+			}
+			cleaned++
+			totalSaved += s.Size
+
+			if auditLogger != nil {
+				_ = auditLogger.LogWithDetails(ui.ActionDelete, s.Path, fmt.Sprintf("%s v%d, %s", cmp.Or(f.Source, "(unknown file)"), s.Version, s.Reason))
+			}
+			if a.machine() {
+				a.emit(output.NewResult("file-history", string(ui.ActionDelete), s.Path, s.Size, nil))
+				summary.Items++
+			}
+		}
+	}
+
+	if a.machine() {
+		summary.Size = totalSaved
+		a.emit(summary)
+	}
+	a.printf("Removed %d snapshots, freed %s\n", cleaned, ui.FormatSize(totalSaved))
+	return 0
+}
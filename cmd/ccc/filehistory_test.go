@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs_FileHistory(t *testing.T) {
+	args, err := parseArgs([]string{"clean", "file-history", "--keep-versions", "3"})
+	require.NoError(t, err)
+	assert.Equal(t, "clean", args.Command)
+	assert.Equal(t, "file-history", args.Subcommand)
+	assert.Equal(t, "3", args.KeepVersions)
+}
+
+// setupFileHistory adds three versions of a snapshot of main.go and one of
+// a deleted file to the full session.
+func setupFileHistory(t *testing.T, tmpDir string) (historyDir string) {
+	t.Helper()
+	session := setupFullSession(t, tmpDir)
+	project := filepath.Join(tmpDir, "webapp")
+	require.NoError(t, os.WriteFile(filepath.Join(project, "main.go"), []byte("package main"), 0644))
+
+	line := `{"type":"file-history-snapshot","snapshot":{"trackedFileBackups":{` +
+		`"main.go":{"backupFileName":"aaa@v3","version":3},` +
+		`"gone.go":{"backupFileName":"bbb@v1","version":1}}}}` + "\n"
+	f, err := os.OpenFile(session, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(line)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	historyDir = filepath.Join(tmpDir, ".claude", "file-history", "full1")
+	require.NoError(t, os.MkdirAll(historyDir, 0755))
+	for _, name := range []string{"aaa@v1", "aaa@v2", "aaa@v3", "bbb@v1"} {
+		require.NoError(t, os.WriteFile(filepath.Join(historyDir, name), []byte(name), 0644))
+	}
+	return historyDir
+}
+
+func TestRunCLI_ListFileHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFileHistory(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "file-history", "--keep-versions", "2"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, filepath.Join(tmpDir, "webapp", "main.go")+"  3 versions, 18 B, 1 prunable (older version)")
+	assert.Contains(t, out, filepath.Join(tmpDir, "webapp", "gone.go")+"  1 versions, 6 B, 1 prunable (file no longer exists)")
+	assert.Contains(t, out, "Total: 2 files, 4 snapshots")
+}
+
+func TestRunCLI_CleanFileHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	historyDir := setupFileHistory(t, tmpDir)
+	writePolicyFile(t, tmpDir, "[retention]\nkeep_file_versions = 2\n")
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "file-history", "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "older version")
+	assert.FileExists(t, filepath.Join(historyDir, "aaa@v1"))
+
+	stdout.Reset()
+	code = runCLI([]string{"clean", "file-history", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Removed 2 snapshots")
+	assert.NoFileExists(t, filepath.Join(historyDir, "aaa@v1"))
+	assert.NoFileExists(t, filepath.Join(historyDir, "bbb@v1"))
+	assert.FileExists(t, filepath.Join(historyDir, "aaa@v2"))
+	assert.FileExists(t, filepath.Join(historyDir, "aaa@v3"))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+)
+
+// searchRoles lists the values accepted by --role.
+var searchRoles = []string{claude.RoleUser, claude.RoleAssistant, claude.RoleTool}
+
+// handleGrep handles the "grep" command, which searches the transcripts of
+// all sessions that pass the filter flags. It exits with status 1 if nothing
+// matches, like grep.
+func (a *app) handleGrep() int {
+	if len(a.args.Targets) != 1 {
+		fmt.Fprintln(a.stderr, "Usage: cccc grep <pattern> [--role user|assistant|tool] [--project <path>] [--since <date>] [--until <date>]")
+		return 1
+	}
+	pattern, err := regexp.Compile(a.args.Targets[0])
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error: invalid pattern:", err)
+		return 1
+	}
+	roles, err := parseRoles(a.args.Role)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	filter, err := a.sessionFilter()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+
+	// Sizes are only needed, with their linked data, to filter by size
+	bySize := filter.minSize > 0 || filter.maxSize > 0
+	var linked map[string][]string
+	if bySize {
+		linked = cleaner.LinkedData(a.paths)
+	}
+	var paths []string
+	sessions := make(map[string]claude.SessionInfo)
+	projects := make(map[string]string)
+	for _, p := range inv.Projects {
+		matchedIDs := make(map[string]claude.SessionInfo)
+		for _, s := range p.Sessions {
+			size := s.Size
+			if bySize {
+				size += cleaner.LinkedSize(slices.Concat(linked[s.ID], p.AgentData(s.ID)))
+			}
+			if filter.matches(p, s, size) {
+				paths = append(paths, s.FilePath)
+				sessions[s.FilePath] = s
+				projects[s.FilePath] = p.ActualPath
+				matchedIDs[s.ID] = s
+			}
+		}
+		// Agent transcripts are searched as part of their session
+		for _, agent := range p.Agents {
+			if s, ok := matchedIDs[agent.ID]; ok && agent.ID != "" {
+				paths = append(paths, agent.FilePath)
+				sessions[agent.FilePath] = s
+				projects[agent.FilePath] = p.ActualPath
+			}
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	opts := claude.SearchOptions{Pattern: pattern, Roles: roles, Since: filter.since, Until: filter.until}
+	matched := make(map[string]bool)
+	count := 0
+	err = claude.SearchFiles(ctx, paths, opts, func(m claude.SearchMatch) {
+		m.SessionID = cmp.Or(m.SessionID, sessions[m.Path].ID)
+		count++
+		matched[sessions[m.Path].FilePath] = true
+
+		if a.machine() {
+			a.emit(output.NewMatch(projects[m.Path], m))
+			return
+		}
+		fmt.Fprintf(a.stdout, "%s  %s  %s  %s:%d\n", m.SessionID, formatTime(m.Timestamp), m.Role, cmp.Or(projects[m.Path], "(unknown path)"), m.Line)
+		fmt.Fprintf(a.stdout, "    %s\n", m.Snippet)
+	})
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error searching sessions:", err)
+		return 1
+	}
+
+	if count == 0 {
+		a.printf("No matches found.\n")
+		return 1
+	}
+	a.printf("\n%d matches in %d sessions\n", count, len(matched))
+	return 0
+}
+
+// parseRoles parses the comma-separated roles of --role.
+func parseRoles(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var roles []string
+	for _, role := range strings.Split(s, ",") {
+		role = strings.TrimSpace(role)
+		if !slices.Contains(searchRoles, role) {
+			return nil, fmt.Errorf("invalid role %q (use %s)", role, strings.Join(searchRoles, ", "))
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs_Grep(t *testing.T) {
+	args, err := parseArgs([]string{"grep", "sessions", "--role", "user,tool", "clean"})
+	require.NoError(t, err)
+	assert.Equal(t, "grep", args.Command)
+	assert.Empty(t, args.Subcommand, "words after grep are patterns")
+	assert.Equal(t, []string{"sessions", "clean"}, args.Targets)
+	assert.Equal(t, "user,tool", args.Role)
+
+	args, err = parseArgs([]string{"grep", "--project", "~/Code", "--", "--force"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--force"}, args.Targets)
+	assert.Equal(t, "~/Code", args.Project)
+}
+
+func TestRunCLI_Grep(t *testing.T) {
+	tmpDir := t.TempDir()
+	session := setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"grep", "(?i)upload"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "  user  "+filepath.Join(tmpDir, "webapp")+":3")
+	assert.Contains(t, out, "    The upload test fails randomly, can you look?")
+	assert.Contains(t, out, "  tool  ")
+	assert.Contains(t, out, "2 matches in 1 sessions")
+
+	stdout.Reset()
+	code = runCLI([]string{"grep", "(?i)upload", "--role", "tool", "--output", "ndjson"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	var matches []map[string]any
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		if record["kind"] == "match" {
+			matches = append(matches, record)
+		}
+	}
+	require.Len(t, matches, 1)
+	assert.Equal(t, "full1", matches[0]["session"])
+	assert.Equal(t, session, matches[0]["path"])
+	assert.Equal(t, "tool", matches[0]["role"])
+	assert.Equal(t, float64(5), matches[0]["line"])
+	assert.Contains(t, matches[0]["snippet"], "upload_test.go")
+}
+
+func TestRunCLI_GrepAgents(t *testing.T) {
+	tmpDir := t.TempDir()
+	session := setupFullSession(t, tmpDir)
+	agent := filepath.Join(filepath.Dir(session), "full1", "subagents", "agent-a1.jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(agent), 0755))
+	line := `{"type":"user","sessionId":"full1","isSidechain":true,"cwd":"/webapp","message":{"role":"user","content":"Find the flaky upload handler"}}` + "\n"
+	require.NoError(t, os.WriteFile(agent, []byte(line), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"grep", "flaky upload"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "full1  ")
+	assert.Contains(t, stdout.String(), "1 matches in 1 sessions")
+}
+
+func TestRunCLI_GrepFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"since", []string{"grep", "temp dir", "--since", "2025-12-02"}, 0, "1 matches in 1 sessions"},
+		{"until excludes later messages", []string{"grep", "temp dir", "--until", "2025-12-01T12:00:00Z"}, 1, "No matches found."},
+		{"other project", []string{"grep", "upload", "--project", filepath.Join(tmpDir, "other")}, 1, "No matches found."},
+		{"project", []string{"grep", "upload", "--project", filepath.Join(tmpDir, "webapp")}, 0, "2 matches in 1 sessions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCLI(tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, tt.code, code, stderr.String())
+			assert.Contains(t, stdout.String(), tt.want)
+		})
+	}
+}
+
+func TestRunCLI_GrepErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no pattern", []string{"grep"}, "Usage: cccc grep"},
+		{"invalid pattern", []string{"grep", "("}, "invalid pattern"},
+		{"invalid role", []string{"grep", "x", "--role", "system"}, `invalid role "system"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runCLI(tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, 1, code)
+			assert.Contains(t, stderr.String(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+)
+
+// handleLint handles the "lint" command and subcommands.
+func (a *app) handleLint() int {
+	switch a.args.Subcommand {
+	case "config":
+		return a.lintConfig()
+	default:
+		fmt.Fprintln(a.stderr, "Usage: cccc lint config")
+		return 1
+	}
+}
+
+// lintConfig analyzes the permission rules of the managed and user settings
+// together with those of every known project, and reports conflicts, dead
+// rules and duplicates. It exits non-zero if there are any, so that it can
+// guard settings in CI.
+func (a *app) lintConfig() int {
+	global, err := a.globalSettingsLayers()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+
+	// The global layers on their own, then with each project's
+	sets := [][]claude.SettingsLayer{global}
+	failed := false
+	for _, projectPath := range inv.ProjectPaths() {
+		dir := filepath.Join(projectPath, ".claude")
+		if filepath.Clean(dir) == filepath.Clean(a.paths.Root) {
+			// The home directory's settings are the user settings
+			continue
+		}
+
+		layers := slices.Clone(global)
+		for _, l := range []claude.SettingsLayer{
+			{Name: claude.LayerProject, Path: filepath.Join(dir, "settings.json")},
+			{Name: claude.LayerLocal, Path: filepath.Join(dir, "settings.local.json")},
+		} {
+			if _, err := os.Stat(l.Path); err != nil {
+				continue
+			}
+			settings, err := claude.LoadSettings(l.Path)
+			if err != nil {
+				fmt.Fprintf(a.stderr, "Error loading %s: %v\n", l.Path, err)
+				failed = true
+				continue
+			}
+			l.Settings = settings
+			layers = append(layers, l)
+		}
+		if len(layers) > len(global) {
+			sets = append(sets, layers)
+		}
+	}
+
+	// A finding of the global layers is found again with every project
+	var findings []cleaner.LintFinding
+	files := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, layers := range sets {
+		for _, l := range layers {
+			if _, err := os.Stat(l.Path); err == nil {
+				files[l.Path] = true
+			}
+		}
+		for _, f := range cleaner.LintSettings(layers) {
+			key := fmt.Sprint(f.Problem, f.Path, f.List, f.Rule, f.ByPath, f.ByList, f.By)
+			if !seen[key] {
+				seen[key] = true
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	if a.machine() {
+		for _, f := range findings {
+			a.emit(output.NewLint(f))
+		}
+	} else {
+		a.printLint(findings, len(files))
+	}
+
+	if failed || len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printLint prints the findings grouped by settings file.
+func (a *app) printLint(findings []cleaner.LintFinding, files int) {
+	if len(findings) == 0 {
+		fmt.Fprintf(a.stdout, "No problems found in %d settings files.\n", files)
+		return
+	}
+
+	var paths []string
+	byPath := make(map[string][]cleaner.LintFinding)
+	for _, f := range findings {
+		if _, ok := byPath[f.Path]; !ok {
+			paths = append(paths, f.Path)
+		}
+		byPath[f.Path] = append(byPath[f.Path], f)
+	}
+
+	fmt.Fprintln(a.stdout, "Config lint:")
+	for _, path := range paths {
+		fmt.Fprintf(a.stdout, "  %s (%s settings)\n", path, byPath[path][0].Layer)
+		for _, f := range byPath[path] {
+			fmt.Fprintf(a.stdout, "    %-10s %s\n", f.Problem, f.Description())
+		}
+	}
+	fmt.Fprintf(a.stdout, "\nFound %d problems in %d files\n", len(findings), len(paths))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLintProject creates user settings and a registered project with local
+// settings, returning the local settings path.
+func setupLintProject(t *testing.T, tmpDir, user, local string) string {
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	encodedProjectDir := filepath.Join(claudeDir, "projects", "-myproject")
+	require.NoError(t, os.MkdirAll(encodedProjectDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(user), 0644))
+
+	projectDir := filepath.Join(tmpDir, "myproject")
+	sessionData := `{"sessionId":"sess1","cwd":"` + filepath.ToSlash(projectDir) + `","timestamp":"2025-01-01T00:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(encodedProjectDir, "sess1.jsonl"), []byte(sessionData), 0644))
+
+	localPath := filepath.Join(projectDir, ".claude", "settings.local.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(localPath), 0755))
+	require.NoError(t, os.WriteFile(localPath, []byte(local), 0644))
+	return localPath
+}
+
+func TestParseArgs_LintConfig(t *testing.T) {
+	args, err := parseArgs([]string{"lint", "config"})
+	require.NoError(t, err)
+	assert.Equal(t, "lint", args.Command)
+	assert.Equal(t, "config", args.Subcommand)
+}
+
+func TestRunCLI_LintConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	localPath := setupLintProject(t, tmpDir,
+		`{"permissions":{"deny":["Bash(git push:*)"]}}`,
+		`{"permissions":{"allow":["Bash(git push origin:*)","Bash(make:*)","Bash(make test)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"lint", "config"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	out := stdout.String()
+	assert.Contains(t, out, localPath+" (local settings)")
+	assert.Contains(t, out, "dead       allow Bash(git push origin:*) is shadowed by deny Bash(git push:*) of user settings")
+	assert.Contains(t, out, "duplicate  allow Bash(make test) is covered by Bash(make:*)")
+	assert.Contains(t, out, "Found 2 problems in 1 files")
+}
+
+func TestRunCLI_LintConfigClean(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupLintProject(t, tmpDir,
+		`{"permissions":{"allow":["Bash(git:*)"],"deny":["Bash(git push:*)"]}}`,
+		`{"permissions":{"allow":["Bash(make:*)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"lint", "config"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "No problems found in 2 settings files.")
+}
+
+func TestRunCLI_LintConfigJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	localPath := setupLintProject(t, tmpDir,
+		`{"permissions":{"deny":["Read(~/.ssh/**)"]}}`,
+		`{"permissions":{"ask":["Read(~/.ssh/**)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"lint", "config", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+
+	var doc struct {
+		Records []map[string]any `json:"records"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, "lint", doc.Records[0]["kind"])
+	assert.Equal(t, "conflict", doc.Records[0]["problem"])
+	assert.Equal(t, localPath, doc.Records[0]["path"])
+	assert.Equal(t, "ask", doc.Records[0]["list"])
+	assert.Equal(t, "deny", doc.Records[0]["byList"])
+	assert.Equal(t, "user", doc.Records[0]["byLayer"])
+}
+
+func TestRunCLI_LintUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	code := runCLI([]string{"lint"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Usage: cccc lint config")
+}
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
 	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pins"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/policy"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/trash"
 	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
 )
 
@@ -17,14 +26,34 @@ var Version = "dev"
 
 // Args represents parsed command-line arguments.
 type Args struct {
-	Command    string // "clean", "list", ""
-	Subcommand string // "projects", "orphans", "config", ""
-	DryRun     bool
-	Yes        bool
-	StaleOnly  bool
-	Verbose    bool
-	Help       bool
-	Version    bool
+	Command      string   // "clean", "list", "restore", "trash", "config", "pin", "unpin", "pins", "cache", "repair", "show", "archive", "import", "grep", "scan", "redact", "du", "lint", ""
+	Subcommand   string   // "projects", "orphans", "config", "state", "sessions", "file-history", "session", "purge", "show", "rebuild", "clear", "secrets", "promote", ""
+	Targets      []string // Positional arguments, e.g. the run ID for restore, the paths to pin or a session ID
+	DryRun       bool
+	Yes          bool
+	StaleOnly    bool
+	Verbose      bool
+	Help         bool
+	Version      bool
+	NoCache      bool
+	OlderThan    string
+	Output       string // "text", "json" or "ndjson"
+	KeepHistory  string
+	KeepVersions string // --keep-versions of clean file-history
+	KeepLast     string
+	MaxSize      string // --max-project-size
+	Confirm      string // "prompt", "yes" or "dry-run"
+	Config       string // Path of the policy file
+	Project      string // --project, a path or glob
+	Since        string
+	Until        string
+	Branch       string
+	LargerThan   string
+	SmallerThan  string
+	ArchiveTo    string // --to for archive, --archive-to for clean
+	Role         string // --role of grep, comma-separated
+	Top          string // --top of du
+	MinProjects  string // --min-projects of config promote
 }
 
 func main() {
@@ -57,17 +86,122 @@ func runCLI(osArgs []string, stdin io.Reader, stdout, stderr io.Writer) int {
 		return 1
 	}
 
-	switch args.Command {
+	// Load the policy file; flags given on the command line override it
+	policyPath := args.Config
+	if policyPath == "" {
+		policyPath, err = policy.DefaultPath()
+		if err != nil {
+			fmt.Fprintln(stderr, "Error locating policy file:", err)
+			return 1
+		}
+	}
+	pol, err := policy.Load(policyPath)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error loading policy:", err)
+		return 1
+	}
+	if err := applyArgs(pol, args); err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+
+	// Pinned projects are protected in addition to those excluded by the policy
+	pinList, err := pins.Load(pins.DefaultPath(paths.Root))
+	if err != nil {
+		fmt.Fprintln(stderr, "Error loading pins:", err)
+		return 1
+	}
+
+	a := &app{
+		args:       args,
+		paths:      paths,
+		policy:     pol,
+		policyPath: policyPath,
+		pins:       pinList,
+		protect:    cleaner.Protectors{pinList, pol},
+		stdin:      stdin,
+		stdout:     stdout,
+		stderr:     stderr,
+	}
+	if format := output.Format(args.Output); format != "" && format != output.FormatText {
+		a.out = output.NewEncoder(stdout, format, strings.TrimSpace(args.Command+" "+args.Subcommand))
+	}
+
+	code := a.run()
+
+	if a.machine() {
+		if err := a.out.Close(); err != nil {
+			fmt.Fprintln(stderr, "Error writing output:", err)
+			return 1
+		}
+	}
+	return code
+}
+
+// run dispatches to the handler of the parsed command.
+func (a *app) run() int {
+	switch a.args.Command {
 	case "clean":
-		return handleClean(args, paths, stdin, stdout, stderr)
+		return a.withTrashRun(func(run *trash.Run, command string) int {
+			return a.cleanWithArchive(run, command)
+		})
+	case "repair":
+		return a.withTrashRun(func(run *trash.Run, _ string) int {
+			return a.handleRepair(run)
+		})
 	case "list":
-		return handleList(args, paths, stdout, stderr)
+		return a.handleList()
+	case "restore":
+		return a.handleRestore()
+	case "trash":
+		return a.handleTrash()
+	case "config":
+		return a.handleConfig()
+	case "pin":
+		return a.handlePin()
+	case "unpin":
+		return a.handleUnpin()
+	case "pins":
+		return a.listPins()
+	case "cache":
+		return a.handleCache()
+	case "show":
+		return a.handleShow()
+	case "archive":
+		return a.handleArchive()
+	case "import":
+		return a.handleImport()
+	case "grep":
+		return a.handleGrep()
+	case "scan":
+		return a.handleScan()
+	case "redact":
+		return a.handleRedact()
+	case "du":
+		return a.handleDu()
+	case "lint":
+		return a.handleLint()
 	default:
-		printHelp(stdout)
+		printHelp(a.stdout)
 		return 0
 	}
 }
 
+// withTrashRun runs fn with a new trash run for the command, and reports the
+// run if fn moved anything into it.
+func (a *app) withTrashRun(fn func(run *trash.Run, command string) int) int {
+	command := strings.TrimSpace(a.args.Command + " " + a.args.Subcommand)
+	run := trash.NewStore(trash.DefaultDir(a.paths.Root)).Begin(command)
+	code := fn(run, command)
+	if run.ID() != "" {
+		if a.machine() {
+			a.emit(output.NewTrashRun(run.Manifest()))
+		}
+		a.printf("Moved %d items to trash run %s (undo with: cccc restore %s)\n", len(run.Items()), run.ID(), run.ID())
+	}
+	return code
+}
+
 // parseArgs parses command-line arguments into Args struct.
 func parseArgs(osArgs []string) (*Args, error) {
 	args := &Args{}
@@ -81,6 +215,26 @@ func parseArgs(osArgs []string) (*Args, error) {
 	for i < len(osArgs) {
 		arg := osArgs[i]
 
+		// The pattern of grep may be any word, and "--" ends the flags
+		if acceptsTargets[args.Command] && (arg == "--" || (args.Command == "grep" && !strings.HasPrefix(arg, "-"))) {
+			if arg == "--" {
+				args.Targets = append(args.Targets, osArgs[i+1:]...)
+				break
+			}
+			args.Targets = append(args.Targets, arg)
+			i++
+			continue
+		}
+
+		// Split "--flag=value" into its name and inline value
+		inline, hasInline := "", false
+		if strings.HasPrefix(arg, "--") {
+			arg, inline, hasInline = strings.Cut(arg, "=")
+		}
+		if hasInline && !valueFlags[arg] {
+			return nil, fmt.Errorf("flag %s does not take a value", arg)
+		}
+
 		switch arg {
 		case "-h", "--help", "help":
 			args.Help = true
@@ -96,19 +250,143 @@ func parseArgs(osArgs []string) (*Args, error) {
 			args.StaleOnly = true
 		case "-v", "--verbose":
 			args.Verbose = true
-		case "clean", "list":
+		case "--no-cache":
+			args.NoCache = true
+		case "--older-than":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.OlderThan = value
+		case "--keep-history":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.KeepHistory = value
+		case "--keep-versions":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.KeepVersions = value
+		case "--keep-last":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.KeepLast = value
+		case "--max-project-size":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.MaxSize = value
+		case "--confirm":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Confirm = value
+		case "--config":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Config = value
+		case "--project":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Project = value
+		case "--since":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Since = value
+		case "--until":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Until = value
+		case "--branch":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Branch = value
+		case "--role":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Role = value
+		case "--top":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.Top = value
+		case "--min-projects":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.MinProjects = value
+		case "--larger-than":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.LargerThan = value
+		case "--smaller-than":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.SmallerThan = value
+		case "--to", "--archive-to":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			args.ArchiveTo = value
+		case "--output", "-o":
+			value, err := flagValue(osArgs, &i, arg, inline, hasInline)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := output.ParseFormat(value); err != nil {
+				return nil, err
+			}
+			args.Output = value
+		case "clean", "list", "restore", "trash", "pin", "unpin", "pins", "cache", "repair", "archive", "import", "grep", "scan", "redact", "du", "lint":
+			if args.Command == "" {
+				args.Command = arg
+			} else {
+				args.Subcommand = arg
+			}
+		case "config", "show":
+			// A command of its own, or the subcommand of clean, list and config
 			if args.Command == "" {
 				args.Command = arg
 			} else {
 				args.Subcommand = arg
 			}
-		case "projects", "orphans", "config":
+		case "projects", "orphans", "state", "sessions", "file-history", "session", "purge", "rebuild", "clear", "secrets", "promote":
 			args.Subcommand = arg
 		default:
-			if strings.HasPrefix(arg, "-") {
+			switch {
+			case strings.HasPrefix(arg, "-"):
 				return nil, fmt.Errorf("unknown flag: %s", arg)
+			case acceptsTargets[args.Command]:
+				args.Targets = append(args.Targets, arg)
+			default:
+				return nil, fmt.Errorf("unknown command: %s", arg)
 			}
-			return nil, fmt.Errorf("unknown command: %s", arg)
 		}
 		i++
 	}
@@ -116,6 +394,53 @@ func parseArgs(osArgs []string) (*Args, error) {
 	return args, nil
 }
 
+// acceptsTargets lists the commands that take positional arguments.
+var acceptsTargets = map[string]bool{
+	"restore": true,
+	"pin":     true,
+	"unpin":   true,
+	"show":    true,
+	"archive": true,
+	"import":  true,
+	"grep":    true,
+}
+
+// valueFlags lists the flags that take a value.
+var valueFlags = map[string]bool{
+	"--older-than":       true,
+	"--output":           true,
+	"--keep-history":     true,
+	"--keep-last":        true,
+	"--keep-versions":    true,
+	"--max-project-size": true,
+	"--confirm":          true,
+	"--config":           true,
+	"--project":          true,
+	"--since":            true,
+	"--until":            true,
+	"--branch":           true,
+	"--role":             true,
+	"--top":              true,
+	"--min-projects":     true,
+	"--larger-than":      true,
+	"--smaller-than":     true,
+	"--to":               true,
+	"--archive-to":       true,
+}
+
+// flagValue returns the value of a flag given as "--flag value" or "--flag=value".
+// For the separate form it advances i past the value.
+func flagValue(osArgs []string, i *int, name, inline string, hasInline bool) (string, error) {
+	if hasInline {
+		return inline, nil
+	}
+	if *i+1 >= len(osArgs) {
+		return "", fmt.Errorf("flag %s requires a value", name)
+	}
+	*i++
+	return osArgs[*i], nil
+}
+
 // printHelp prints the usage information.
 func printHelp(w io.Writer) {
 	fmt.Fprintf(w, "cccc version %s\n", Version)
@@ -123,417 +448,720 @@ func printHelp(w io.Writer) {
 	fmt.Fprintln(w, "A CLI utility to clean up Claude Code configuration.")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Usage:")
-	fmt.Fprintln(w, "  cccc clean                          Clean all (default: projects + orphans + config)")
+	fmt.Fprintln(w, "  cccc clean                          Clean all (default: projects + orphans + config, set by the policy file)")
 	fmt.Fprintln(w, "  cccc clean projects [--dry-run]     Remove stale project session data")
 	fmt.Fprintln(w, "  cccc clean orphans [--dry-run]      Remove orphaned data")
 	fmt.Fprintln(w, "  cccc clean config [--dry-run]       Deduplicate local configs against global settings")
+	fmt.Fprintln(w, "  cccc clean state [--keep-history N] Prune ~/.claude.json entries of missing projects")
+	fmt.Fprintln(w, "  cccc clean sessions --older-than 90d [--keep-last 20] [--max-project-size 200MB]")
+	fmt.Fprintln(w, "                                      Remove old sessions of existing projects")
+	fmt.Fprintln(w, "  cccc clean file-history [--keep-versions N]")
+	fmt.Fprintln(w, "                                      Remove file-history snapshots of deleted projects and files, and old versions")
+	fmt.Fprintln(w, "  cccc clean ... --archive-to <file>  Archive removed session data to a .tar.zst or .tar.gz bundle first")
+	fmt.Fprintln(w, "  cccc repair sessions [--dry-run]    Truncate session files after their last valid line")
+	fmt.Fprintln(w, "  cccc grep <pattern> [--role user|assistant|tool] [filters]")
+	fmt.Fprintln(w, "                                      Search all session transcripts with a regular expression")
+	fmt.Fprintln(w, "  cccc scan secrets [filters]         Report API keys, tokens and private keys in transcripts and file-history")
+	fmt.Fprintln(w, "  cccc redact [filters] [--dry-run]   Replace those secrets in place")
+	fmt.Fprintln(w, "  cccc archive <project|session>... --to <file>")
+	fmt.Fprintln(w, "                                      Bundle sessions with their todos, file-history and session-env")
+	fmt.Fprintln(w, "  cccc import <file> [--dry-run]      Restore a bundle into ~/.claude without overwriting files")
+	fmt.Fprintln(w, "  cccc du [--top N]                   Show the disk space used by category, project, session and month")
+	fmt.Fprintln(w, "  cccc lint config                    Report conflicting, shadowed and duplicate permission rules; exits 1 if any")
 	fmt.Fprintln(w, "  cccc list                           List projects (default)")
 	fmt.Fprintln(w, "  cccc list projects [--stale-only]   List all projects with their status")
 	fmt.Fprintln(w, "  cccc list orphans                   List orphaned data without removing")
 	fmt.Fprintln(w, "  cccc list config [--verbose]        List duplicate config entries without removing")
+	fmt.Fprintln(w, "  cccc list state                     List the project entries of ~/.claude.json")
+	fmt.Fprintln(w, "  cccc list sessions [filters]        List sessions with their messages, models and branch")
+	fmt.Fprintln(w, "  cccc list file-history              List the files with file-history snapshots and what can be pruned")
+	fmt.Fprintln(w, "  cccc show session <id>              Show a session's transcript, todos, file-history and session-env")
+	fmt.Fprintln(w, "  cccc restore <run-id> [item...]     Restore data moved to the trash by a clean run")
+	fmt.Fprintln(w, "  cccc trash list [--verbose]         List trash runs")
+	fmt.Fprintln(w, "  cccc trash purge --older-than 30d   Permanently delete old trash runs")
+	fmt.Fprintln(w, "  cccc config show                    Print the effective policy")
+	fmt.Fprintln(w, "  cccc config promote [--min-projects N]")
+	fmt.Fprintln(w, "                                      Move rules found in N or more local configs (default 3) into ~/.claude/settings.json")
+	fmt.Fprintln(w, "  cccc pin <path-or-glob>...          Never treat matching projects as stale or clean them")
+	fmt.Fprintln(w, "  cccc unpin <path-or-glob>...        Remove pins")
+	fmt.Fprintln(w, "  cccc pins [--verbose]               List pins and the projects they match")
+	fmt.Fprintln(w, "  cccc cache                          Show the scan index")
+	fmt.Fprintln(w, "  cccc cache rebuild|clear            Rebuild or remove the scan index")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Flags:")
-	fmt.Fprintln(w, "  --dry-run      Show what would be cleaned without making changes")
-	fmt.Fprintln(w, "  --yes, -y      Skip confirmation prompts")
-	fmt.Fprintln(w, "  --verbose, -v  Show detailed output (e.g., list duplicate entries)")
-	fmt.Fprintln(w, "  --stale-only   Show only stale projects (with list projects)")
-	fmt.Fprintln(w, "  --help, -h     Show this help message")
-	fmt.Fprintln(w, "  --version      Show version information")
+	fmt.Fprintln(w, "  --dry-run           Show what would be cleaned without making changes")
+	fmt.Fprintln(w, "  --yes, -y           Skip confirmation prompts")
+	fmt.Fprintln(w, "  --verbose, -v       Show detailed output (e.g., list duplicate entries)")
+	fmt.Fprintln(w, "  --stale-only        Show only stale projects (with list projects)")
+	fmt.Fprintln(w, "  --older-than        Age threshold such as 30d or 12h (with trash purge, clean sessions)")
+	fmt.Fprintln(w, "  --keep-last         Sessions to always keep per project (with clean sessions)")
+	fmt.Fprintln(w, "  --max-project-size  Size limit per project such as 200MB (with clean sessions)")
+	fmt.Fprintln(w, "  --keep-history      Prompt history entries to keep per project (with clean state)")
+	fmt.Fprintln(w, "  --keep-versions     File-history versions to keep per file (with list and clean file-history)")
+	fmt.Fprintln(w, "  --project           Only sessions of projects at or below a path or glob (with list sessions, grep, scan, redact)")
+	fmt.Fprintln(w, "  --since, --until    Only sessions active in a date range, e.g. 2025-12-02 or 7d (with list sessions, grep, scan, redact)")
+	fmt.Fprintln(w, "  --branch            Only sessions on a git branch, globs allowed (with list sessions, grep, scan, redact)")
+	fmt.Fprintln(w, "  --role              Only text of these roles: user, assistant, tool, comma-separated (with grep)")
+	fmt.Fprintln(w, "  --larger-than       Only sessions larger than a size such as 10MB (with list sessions)")
+	fmt.Fprintln(w, "  --smaller-than      Only sessions smaller than a size (with list sessions)")
+	fmt.Fprintln(w, "  --top               Number of largest projects and sessions to show, default 10 (with du)")
+	fmt.Fprintln(w, "  --min-projects      Local configs a rule must be in to be promoted, default 3 (with config promote)")
+	fmt.Fprintln(w, "  --to, --archive-to  Bundle to write, ending in .tar.zst or .tar.gz (with archive, clean)")
+	fmt.Fprintln(w, "  --output, -o        Output format: text (default), json or ndjson")
+	fmt.Fprintln(w, "  --confirm           Confirmation mode: prompt (default), yes or dry-run")
+	fmt.Fprintln(w, "  --config            Policy file (default: ~/.config/cccc/config.toml)")
+	fmt.Fprintln(w, "  --no-cache          Parse every session file instead of using the scan index")
+	fmt.Fprintln(w, "  --help, -h          Show this help message")
+	fmt.Fprintln(w, "  --version           Show version information")
+}
+
+// app holds the state shared by the handlers of a single invocation.
+type app struct {
+	args   *Args
+	paths  *claude.Paths
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// policy is the effective policy, with command-line flags applied.
+	policy     *policy.Policy
+	policyPath string
+
+	// pins holds the pinned paths; protect combines them with the policy's
+	// excludes and decides what the cleaners must keep.
+	pins    *pins.List
+	protect cleaner.Protector
+
+	// out receives machine-readable records; nil for text output.
+	out *output.Encoder
+
+	// inv is the inventory of the projects directory, scanned on first use
+	// and shared by all cleaners of the run.
+	inv *claude.Inventory
+}
+
+// inventory returns the shared inventory, scanning the projects directory on
+// the first call. Unless --no-cache is given, only session files that changed
+// since the last run are parsed. An interrupt cancels the scan.
+func (a *app) inventory() (*claude.Inventory, error) {
+	if a.inv != nil {
+		return a.inv, nil
+	}
+
+	var index *claude.Index
+	if !a.args.NoCache {
+		index = a.loadIndex()
+	}
+
+	inv, err := a.scan(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if index != nil {
+		if err := index.Save(); err != nil {
+			fmt.Fprintln(a.stderr, "Warning: could not save scan index:", err)
+		}
+	}
+	a.inv = inv
+	return inv, nil
+}
+
+// scan scans the projects directory using index, which may be nil. An
+// interrupt cancels the scan.
+func (a *app) scan(index *claude.Index) (*claude.Inventory, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return claude.ScanInventory(ctx, a.paths.Projects, 0, index)
+}
+
+// machine reports whether output is machine-readable.
+func (a *app) machine() bool {
+	return a.out != nil
+}
+
+// emit adds a record to the machine-readable output.
+func (a *app) emit(record any) {
+	if err := a.out.Add(record); err != nil {
+		fmt.Fprintln(a.stderr, "Error writing output:", err)
+	}
+}
+
+// printf writes human-readable output. It is suppressed for machine-readable output.
+func (a *app) printf(format string, v ...any) {
+	if !a.machine() {
+		fmt.Fprintf(a.stdout, format, v...)
+	}
+}
+
+// showDryRun displays the preview of a dry run. For machine-readable output
+// the candidate records are emitted instead, followed by a summary.
+func (a *app) showDryRun(preview *ui.Preview, category string, records []any) {
+	if !a.machine() {
+		fmt.Fprintln(a.stdout, "[DRY RUN]")
+		_ = preview.Display(a.stdout)
+		return
+	}
+
+	for _, r := range records {
+		a.emit(r)
+	}
+	summary := output.NewSummary(category)
+	summary.DryRun = true
+	summary.Items = len(preview.Changes)
+	summary.Size = preview.TotalSize()
+	a.emit(summary)
+}
+
+// confirm displays the preview and asks for confirmation. For machine-readable
+// output the preview and prompt go to stderr so that stdout stays parseable.
+func (a *app) confirm(preview *ui.Preview, category string) (bool, error) {
+	w := a.stdout
+	if a.machine() {
+		w = a.stderr
+	}
+
+	confirmed, err := ui.ConfirmChanges(preview, a.stdin, w, a.args.Yes)
+	if err == nil && !confirmed && a.machine() {
+		summary := output.NewSummary(category)
+		summary.Aborted = true
+		a.emit(summary)
+	}
+	return confirmed, err
+}
+
+// openAuditLog opens the audit log, or returns nil with a warning if it cannot be created.
+func (a *app) openAuditLog() *ui.AuditLogger {
+	path := a.policy.AuditLog
+	if path == "" {
+		path = ui.DefaultAuditLogPath(a.paths.Root)
+	}
+
+	auditLogger, err := ui.NewAuditLogger(path)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Warning: could not create audit log:", err)
+		return nil
+	}
+	return auditLogger
 }
 
 // handleClean handles the "clean" command and subcommands.
-func handleClean(args *Args, paths *claude.Paths, stdin io.Reader, stdout, stderr io.Writer) int {
-	switch args.Subcommand {
+// Removed and rewritten data is moved into q so that it can be restored.
+func (a *app) handleClean(q cleaner.Quarantine) int {
+	switch a.args.Subcommand {
 	case "projects":
-		return cleanProjects(args, paths, stdin, stdout, stderr)
+		return a.cleanProjects(q)
 	case "orphans":
-		return cleanOrphans(args, paths, stdin, stdout, stderr)
+		return a.cleanOrphans(q)
 	case "config":
-		return cleanConfig(args, paths, stdin, stdout, stderr)
+		return a.cleanConfig(q)
+	case "state":
+		return a.cleanState(q)
+	case "sessions":
+		return a.cleanSessions(q)
+	case "file-history":
+		return a.cleanFileHistory(q)
 	case "":
-		// Clean all
-		code := cleanProjects(args, paths, stdin, stdout, stderr)
-		if code != 0 {
-			return code
-		}
-		code = cleanOrphans(args, paths, stdin, stdout, stderr)
-		if code != 0 {
-			return code
+		// Clean all subcommands selected by the policy, stopping at the first failure
+		for _, sub := range a.policy.Clean {
+			a.args.Subcommand = sub
+			code := a.handleClean(q)
+			if code != 0 {
+				return code
+			}
 		}
-		return cleanConfig(args, paths, stdin, stdout, stderr)
+		a.args.Subcommand = ""
+		return 0
 	default:
-		fmt.Fprintf(stderr, "Unknown clean subcommand: %s\n", args.Subcommand)
+		fmt.Fprintf(a.stderr, "Unknown clean subcommand: %s\n", a.args.Subcommand)
 		return 1
 	}
 }
 
 // handleList handles the "list" command and subcommands.
-func handleList(args *Args, paths *claude.Paths, stdout, stderr io.Writer) int {
-	switch args.Subcommand {
-	case "projects", "":
-		return listProjects(args, paths, stdout, stderr)
+func (a *app) handleList() int {
+	switch a.args.Subcommand {
+	case "projects":
+		return a.listProjects()
 	case "orphans":
-		return listOrphans(paths, stdout, stderr)
+		return a.listOrphans()
 	case "config":
-		return listConfig(args, paths, stdout, stderr)
+		return a.listConfig()
+	case "state":
+		return a.listState()
+	case "sessions":
+		return a.listSessions()
+	case "file-history":
+		return a.listFileHistory()
 	default:
-		fmt.Fprintf(stderr, "Unknown list subcommand: %s\n", args.Subcommand)
+		fmt.Fprintf(a.stderr, "Unknown list subcommand: %s\n", a.args.Subcommand)
 		return 1
 	}
 }
 
 // cleanProjects finds and removes stale project session data.
-func cleanProjects(args *Args, paths *claude.Paths, stdin io.Reader, stdout, stderr io.Writer) int {
-	projects, err := claude.ScanProjects(paths.Projects)
+func (a *app) cleanProjects(q cleaner.Quarantine) int {
+	inv, err := a.inventory()
 	if err != nil {
-		fmt.Fprintln(stderr, "Error scanning projects:", err)
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
 		return 1
 	}
+	projects, protected := cleaner.ProtectProjects(inv.Projects, a.protect)
 
-	stale := cleaner.FindStaleProjects(projects)
+	// Projects on volumes that are not mounted are missing, but not stale
+	stale, unavailable := cleaner.ClassifyProjects(projects, mounts.System())
 	if len(stale) == 0 {
-		fmt.Fprintln(stdout, "No stale projects found.")
+		a.printf("No stale projects found.\n")
 		return 0
 	}
 
-	// Build kept list (non-stale)
+	// Build kept list (present projects)
 	var kept []claude.Project
-	staleSet := make(map[string]bool)
+	missing := make(map[string]bool)
 	for _, p := range stale {
-		staleSet[p.EncodedName] = true
+		missing[p.EncodedName] = true
+	}
+	for _, u := range unavailable {
+		missing[u.Project.EncodedName] = true
 	}
 	for _, p := range projects {
-		if !staleSet[p.EncodedName] {
+		if !missing[p.EncodedName] {
 			kept = append(kept, p)
 		}
 	}
 
 	preview := cleaner.BuildStalePreview(stale, kept)
+	preview.Kept = append(preview.Kept, cleaner.KeepUnavailable(unavailable)...)
+	preview.Kept = append(preview.Kept, protected...)
 
-	if args.DryRun {
-		fmt.Fprintln(stdout, "[DRY RUN]")
-		_ = preview.Display(stdout)
+	if a.args.DryRun {
+		var records []any
+		for _, p := range stale {
+			records = append(records, output.NewProject(p, output.StatusStale))
+		}
+		a.showDryRun(preview, "projects", records)
 		return 0
 	}
 
-	confirmed, err := ui.ConfirmChanges(preview, stdin, stdout, args.Yes)
+	confirmed, err := a.confirm(preview, "projects")
 	if err != nil {
-		fmt.Fprintln(stderr, "Error:", err)
+		fmt.Fprintln(a.stderr, "Error:", err)
 		return 1
 	}
 	if !confirmed {
 		return 0
 	}
 
-	// Create audit logger
-	auditLogger, err := ui.NewAuditLogger(ui.DefaultAuditLogPath(paths.Root))
-	if err != nil {
-		fmt.Fprintln(stderr, "Warning: could not create audit log:", err)
-	} else {
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
 		defer auditLogger.Close()
 	}
 
 	// Perform cleanup
+	summary := output.NewSummary("projects")
 	var totalSaved int64
 	for _, p := range stale {
-		result, err := cleaner.CleanStaleProject(paths.Projects, p, false)
+		result, err := cleaner.CleanStaleProject(a.paths.Projects, p, q, false)
 		if err != nil {
-			fmt.Fprintf(stderr, "Error cleaning project %s: %v\n", p.ActualPath, err)
+			fmt.Fprintf(a.stderr, "Error cleaning project %s: %v\n", p.ActualPath, err)
+			if a.machine() {
+				a.emit(output.NewResult("projects", string(ui.ActionDelete), p.ActualPath, 0, err))
+				summary.Errors++
+			}
 			continue
 		}
+		inv.RemoveProject(p.EncodedName)
 		totalSaved += result.SizeSaved
 
 		if auditLogger != nil {
 			_ = auditLogger.Log(ui.ActionDelete, p.ActualPath, result.SizeSaved)
 		}
+		if a.machine() {
+			a.emit(output.NewResult("projects", string(ui.ActionDelete), p.ActualPath, result.SizeSaved, nil))
+			summary.Items++
+		}
 	}
 
-	fmt.Fprintf(stdout, "Cleaned %d stale projects, freed %s\n", len(stale), ui.FormatSize(totalSaved))
+	if a.machine() {
+		summary.Size = totalSaved
+		a.emit(summary)
+	}
+	a.printf("Cleaned %d stale projects, freed %s\n", len(stale), ui.FormatSize(totalSaved))
 	return 0
 }
 
 // cleanOrphans finds and removes orphaned data.
-func cleanOrphans(args *Args, paths *claude.Paths, stdin io.Reader, stdout, stderr io.Writer) int {
-	// Get valid session IDs from projects
-	projects, err := claude.ScanProjects(paths.Projects)
-	if err != nil {
-		fmt.Fprintln(stderr, "Error scanning projects:", err)
-		return 1
-	}
-
-	var validSessionIDs []string
-	for _, p := range projects {
-		validSessionIDs = append(validSessionIDs, p.SessionIDs...)
-	}
-
-	orphans, err := cleaner.FindOrphans(paths, validSessionIDs)
-	if err != nil {
-		fmt.Fprintln(stderr, "Error finding orphans:", err)
+func (a *app) cleanOrphans(q cleaner.Quarantine) int {
+	orphans, protected, ok := a.findOrphans()
+	if !ok {
 		return 1
 	}
 
 	if len(orphans) == 0 {
-		fmt.Fprintln(stdout, "No orphaned data found.")
+		a.printf("No orphaned data found.\n")
 		return 0
 	}
 
 	preview := cleaner.BuildOrphanPreview(orphans)
+	preview.Kept = append(preview.Kept, protected...)
 
-	if args.DryRun {
-		fmt.Fprintln(stdout, "[DRY RUN]")
-		_ = preview.Display(stdout)
+	if a.args.DryRun {
+		var records []any
+		for _, o := range orphans {
+			records = append(records, output.NewOrphan(o))
+		}
+		a.showDryRun(preview, "orphans", records)
 		return 0
 	}
 
-	confirmed, err := ui.ConfirmChanges(preview, stdin, stdout, args.Yes)
+	confirmed, err := a.confirm(preview, "orphans")
 	if err != nil {
-		fmt.Fprintln(stderr, "Error:", err)
+		fmt.Fprintln(a.stderr, "Error:", err)
 		return 1
 	}
 	if !confirmed {
 		return 0
 	}
 
-	// Create audit logger
-	auditLogger, err := ui.NewAuditLogger(ui.DefaultAuditLogPath(paths.Root))
-	if err != nil {
-		fmt.Fprintln(stderr, "Warning: could not create audit log:", err)
-	} else {
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
 		defer auditLogger.Close()
 	}
 
-	// Perform cleanup
-	results, err := cleaner.CleanOrphans(orphans, false)
-	if err != nil {
-		fmt.Fprintln(stderr, "Error cleaning orphans:", err)
-		return 1
-	}
-
+	// Perform cleanup one item at a time so that every outcome can be reported
+	summary := output.NewSummary("orphans")
 	var totalSaved int64
-	for _, r := range results {
+	var cleanErr error
+	for _, o := range orphans {
+		if cleanErr != nil {
+			if a.machine() {
+				result := output.NewResult("orphans", string(ui.ActionDelete), o.Path, 0, nil)
+				result.Type = string(o.Type)
+				result.Status = output.ResultSkipped
+				a.emit(result)
+			}
+			continue
+		}
+
+		results, err := cleaner.CleanOrphans([]cleaner.OrphanResult{o}, q, false)
+		if err != nil {
+			cleanErr = err
+			if a.machine() {
+				result := output.NewResult("orphans", string(ui.ActionDelete), o.Path, 0, err)
+				result.Type = string(o.Type)
+				a.emit(result)
+				summary.Errors++
+			}
+			continue
+		}
+
+		r := results[0]
+		switch r.Type {
+		case cleaner.OrphanTypeEmptySession:
+			a.inv.RemoveEmptySession(r.Path)
+		case cleaner.OrphanTypeAgent:
+			a.inv.RemoveAgentData(r.Path)
+		case cleaner.OrphanTypeTrivialSession:
+			a.inv.RemoveSession(r.SessionID)
+		}
 		totalSaved += r.SizeSaved
 		if auditLogger != nil {
 			_ = auditLogger.Log(ui.ActionDelete, r.Path, r.SizeSaved)
+			for _, linked := range r.Linked {
+				_ = auditLogger.LogWithDetails(ui.ActionDelete, linked, "linked to session "+r.SessionID)
+			}
+		}
+		if a.machine() {
+			result := output.NewResult("orphans", string(ui.ActionDelete), r.Path, r.SizeSaved, nil)
+			result.Type = string(r.Type)
+			a.emit(result)
+			summary.Items++
 		}
 	}
 
-	fmt.Fprintf(stdout, "Cleaned %d orphaned items, freed %s\n", len(results), ui.FormatSize(totalSaved))
-	return 0
-}
-
-// cleanConfig deduplicates local configs against global settings.
-func cleanConfig(args *Args, paths *claude.Paths, stdin io.Reader, stdout, stderr io.Writer) int {
-	// Load global settings
-	global, err := claude.LoadSettings(paths.Settings)
-	if err != nil {
-		fmt.Fprintln(stderr, "Error loading global settings:", err)
-		return 1
+	if a.machine() {
+		summary.Size = totalSaved
+		a.emit(summary)
 	}
-
-	// Get project paths from scanned projects for fast config lookup
-	projects, err := claude.ScanProjects(paths.Projects)
-	if err != nil {
-		fmt.Fprintln(stderr, "Error scanning projects:", err)
+	if cleanErr != nil {
+		fmt.Fprintln(a.stderr, "Error cleaning orphans:", cleanErr)
 		return 1
 	}
 
-	// Extract unique project paths
-	var projectPaths []string
-	for _, p := range projects {
-		if p.ActualPath != "" {
-			projectPaths = append(projectPaths, p.ActualPath)
-		}
-	}
-
-	// Find local configs only in known project directories (fast)
-	// Exclude ~/.claude/settings.local.json (if home dir is a project, it shouldn't be treated as a local config)
-	homeLocalSettings := filepath.Join(paths.Root, "settings.local.json")
-	localConfigs := cleaner.FindLocalConfigsFromProjects(projectPaths, homeLocalSettings)
+	a.printf("Cleaned %d orphaned items, freed %s\n", len(orphans), ui.FormatSize(totalSaved))
+	return 0
+}
 
-	if len(localConfigs) == 0 {
-		fmt.Fprintln(stdout, "No local configs found.")
-		return 0
+// cleanConfig deduplicates local configs against global settings.
+func (a *app) cleanConfig(q cleaner.Quarantine) int {
+	results, protected, code := a.findDuplicateConfigs()
+	if results == nil {
+		return code
 	}
 
-	// Analyze each local config
-	var results []cleaner.DedupResult
-	for _, configPath := range localConfigs {
-		local, err := claude.LoadSettings(configPath)
-		if err != nil {
-			fmt.Fprintf(stderr, "Warning: could not load %s: %v\n", configPath, err)
-			continue
-		}
+	preview := a.dedupPreview(results)
+	preview.Kept = append(preview.Kept, protected...)
 
-		result := cleaner.DeduplicateConfig(configPath, global, local)
-		if result.HasDuplicates() || result.SuggestDelete {
-			results = append(results, *result)
+	if a.args.DryRun {
+		var records []any
+		for _, r := range results {
+			records = append(records, output.NewConfig(r))
 		}
-	}
-
-	if len(results) == 0 {
-		fmt.Fprintln(stdout, "No duplicate configs found.")
-		return 0
-	}
-
-	// Use verbose preview if requested
-	var preview *ui.Preview
-	if args.Verbose {
-		preview = cleaner.BuildDedupPreviewVerbose(results, paths.Settings)
-	} else {
-		preview = cleaner.BuildDedupPreview(results)
-	}
-
-	if args.DryRun {
-		fmt.Fprintln(stdout, "[DRY RUN]")
-		_ = preview.Display(stdout)
+		a.showDryRun(preview, "config", records)
 		return 0
 	}
 
-	confirmed, err := ui.ConfirmChanges(preview, stdin, stdout, args.Yes)
+	confirmed, err := a.confirm(preview, "config")
 	if err != nil {
-		fmt.Fprintln(stderr, "Error:", err)
+		fmt.Fprintln(a.stderr, "Error:", err)
 		return 1
 	}
 	if !confirmed {
 		return 0
 	}
 
-	// Create audit logger
-	auditLogger, err := ui.NewAuditLogger(ui.DefaultAuditLogPath(paths.Root))
-	if err != nil {
-		fmt.Fprintln(stderr, "Warning: could not create audit log:", err)
-	} else {
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
 		defer auditLogger.Close()
 	}
 
 	// Apply deduplication
+	summary := output.NewSummary("config")
 	for _, r := range results {
-		if err := cleaner.ApplyDedup(&r, false); err != nil {
-			fmt.Fprintf(stderr, "Error deduplicating %s: %v\n", r.LocalPath, err)
+		action := ui.ActionModify
+		if r.SuggestDelete {
+			action = ui.ActionDelete
+		}
+
+		if err := cleaner.ApplyDedup(&r, q, false); err != nil {
+			fmt.Fprintf(a.stderr, "Error deduplicating %s: %v\n", r.LocalPath, err)
+			if a.machine() {
+				a.emit(output.NewResult("config", string(action), r.LocalPath, 0, err))
+				summary.Errors++
+			}
 			continue
 		}
 		if auditLogger != nil {
-			action := ui.ActionModify
-			if r.SuggestDelete {
-				action = ui.ActionDelete
-			}
 			_ = auditLogger.LogWithDetails(action, r.LocalPath, r.FormatAuditDetails())
 		}
+		if a.machine() {
+			a.emit(output.NewResult("config", string(action), r.LocalPath, 0, nil))
+			summary.Items++
+		}
 	}
 
-	fmt.Fprintf(stdout, "Deduplicated %d config files\n", len(results))
+	if a.machine() {
+		a.emit(summary)
+	}
+	a.printf("Deduplicated %d config files\n", len(results))
 	return 0
 }
 
 // listProjects lists all projects and their status.
-func listProjects(args *Args, paths *claude.Paths, stdout, stderr io.Writer) int {
-	projects, err := claude.ScanProjects(paths.Projects)
+func (a *app) listProjects() int {
+	inv, err := a.inventory()
 	if err != nil {
-		fmt.Fprintln(stderr, "Error scanning projects:", err)
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
 		return 1
 	}
+	projects := inv.Projects
 
 	if len(projects) == 0 {
-		fmt.Fprintln(stdout, "No projects found.")
+		a.printf("No projects found.\n")
 		return 0
 	}
 
-	stale := cleaner.FindStaleProjects(projects)
+	allowed, _ := cleaner.ProtectProjects(projects, a.protect)
+	stale, unavailable := cleaner.ClassifyProjects(allowed, mounts.System())
 	staleSet := make(map[string]bool)
 	for _, p := range stale {
 		staleSet[p.EncodedName] = true
 	}
+	unavailableSet := make(map[string]cleaner.UnavailableProject)
+	for _, u := range unavailable {
+		unavailableSet[u.Project.EncodedName] = u
+	}
 
-	fmt.Fprintln(stdout, "Projects:")
+	a.printf("Projects:\n")
 	for _, p := range projects {
 		isStale := staleSet[p.EncodedName]
+		protectedBy := a.protect.Protects(p.ActualPath)
+		pinned := a.pins.Protects(p.ActualPath) != ""
+		u, isUnavailable := unavailableSet[p.EncodedName]
+		reason := protectedBy
+		if isUnavailable {
+			reason = u.Description()
+		}
+		corrupt := p.CorruptSessions()
 
 		// Skip non-stale if --stale-only
-		if args.StaleOnly && !isStale {
+		if a.args.StaleOnly && !isStale {
 			continue
 		}
 
-		status := "OK"
-		if isStale {
-			status = "STALE"
+		// A project kept because its path is unknown shows as corrupt
+		status := output.StatusOK
+		switch {
+		case pinned:
+			status = output.StatusPinned
+		case isUnavailable && !u.UnknownPath:
+			status = unavailableStatus(u.Unavailability)
+		case protectedBy != "":
+			status = output.StatusProtected
+		case isStale:
+			status = output.StatusStale
+		case len(corrupt) > 0:
+			status = output.StatusCorrupt
 		}
 
+		if a.machine() {
+			record := output.NewProject(p, status)
+			record.Reason = reason
+			a.emit(record)
+			continue
+		}
+		status = strings.ToUpper(status)
+
 		path := p.ActualPath
 		if path == "" {
 			path = "(unknown path)"
 		}
 
-		fmt.Fprintf(stdout, "  [%s] %s\n", status, path)
-		fmt.Fprintf(stdout, "        %d files, %s, last used: %s\n",
+		fmt.Fprintf(a.stdout, "  [%s] %s\n", status, path)
+		fmt.Fprintf(a.stdout, "        %d files, %s, last used: %s\n",
 			p.FileCount, ui.FormatSize(p.TotalSize), p.LastUsed.Format("2006-01-02"))
+		if activity := p.Activity(); activity.UserMessages+activity.AssistantMessages > 0 {
+			fmt.Fprintf(a.stdout, "        %s\n", formatActivity(activity.UserMessages, activity.AssistantMessages, activity.Usage, activity.Models, activity.Branches))
+		}
+		if reason != "" {
+			fmt.Fprintf(a.stdout, "        %s\n", reason)
+		}
+		if len(corrupt) > 0 {
+			badLines, hint := 0, ""
+			for _, s := range corrupt {
+				badLines += s.BadLines
+				if s.IsTruncated() && s.ValidSize > 0 {
+					hint = ", repair with: cccc repair sessions"
+				}
+			}
+			fmt.Fprintf(a.stdout, "        %d corrupt sessions (%d invalid lines)%s\n", len(corrupt), badLines, hint)
+		}
 	}
 
-	fmt.Fprintf(stdout, "\nTotal: %d projects (%d stale)\n", len(projects), len(stale))
+	if len(unavailable) > 0 {
+		a.printf("\nTotal: %d projects (%d stale, %d unavailable)\n", len(projects), len(stale), len(unavailable))
+	} else {
+		a.printf("\nTotal: %d projects (%d stale)\n", len(projects), len(stale))
+	}
 	return 0
 }
 
 // listOrphans lists orphaned data without removing it.
-func listOrphans(paths *claude.Paths, stdout, stderr io.Writer) int {
-	// Get valid session IDs from projects
-	projects, err := claude.ScanProjects(paths.Projects)
-	if err != nil {
-		fmt.Fprintln(stderr, "Error scanning projects:", err)
+func (a *app) listOrphans() int {
+	orphans, protected, ok := a.findOrphans()
+	if !ok {
 		return 1
 	}
 
-	var validSessionIDs []string
-	for _, p := range projects {
-		validSessionIDs = append(validSessionIDs, p.SessionIDs...)
-	}
-
-	orphans, err := cleaner.FindOrphans(paths, validSessionIDs)
-	if err != nil {
-		fmt.Fprintln(stderr, "Error finding orphans:", err)
-		return 1
+	if a.machine() {
+		for _, o := range orphans {
+			a.emit(output.NewOrphan(o))
+		}
+		return 0
 	}
 
 	if len(orphans) == 0 {
-		fmt.Fprintln(stdout, "No orphaned data found.")
+		fmt.Fprintln(a.stdout, "No orphaned data found.")
 		return 0
 	}
 
 	preview := cleaner.BuildOrphanPreview(orphans)
-	_ = preview.Display(stdout)
+	preview.Kept = append(preview.Kept, protected...)
+	_ = preview.Display(a.stdout)
 
 	return 0
 }
 
 // listConfig lists duplicate config entries without removing them.
-func listConfig(args *Args, paths *claude.Paths, stdout, stderr io.Writer) int {
-	// Load global settings
-	global, err := claude.LoadSettings(paths.Settings)
+func (a *app) listConfig() int {
+	results, protected, code := a.findDuplicateConfigs()
+	if results == nil {
+		return code
+	}
+
+	if a.machine() {
+		for _, r := range results {
+			a.emit(output.NewConfig(r))
+		}
+		return 0
+	}
+
+	preview := a.dedupPreview(results)
+	preview.Kept = append(preview.Kept, protected...)
+	_ = preview.Display(a.stdout)
+
+	return 0
+}
+
+// findOrphans takes the valid session IDs from the inventory and returns the orphaned
+// data, along with the kept entries of orphans protected by the policy or a pin.
+// Errors are reported on stderr.
+func (a *app) findOrphans() ([]cleaner.OrphanResult, []ui.Change, bool) {
+	// Get valid session IDs and empty session files from the inventory
+	inv, err := a.inventory()
 	if err != nil {
-		fmt.Fprintln(stderr, "Error loading global settings:", err)
-		return 1
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return nil, nil, false
 	}
 
-	// Get project paths from scanned projects for fast config lookup
-	projects, err := claude.ScanProjects(paths.Projects)
+	rules := cleaner.OrphanRules{MaxAge: cleaner.DefaultLogRetention}
+	if a.policy.Retention.LogsOlderThan != "" {
+		// Validated when the policy was loaded
+		rules.MaxAge, _ = ui.ParseAge(a.policy.Retention.LogsOlderThan)
+	}
+	if t := a.policy.Trivial; t.MaxMessages != nil {
+		rules.Trivial = &cleaner.TrivialRules{MaxMessages: *t.MaxMessages, NoToolUse: t.NoToolUse}
+		rules.Trivial.ShorterThan, _ = ui.ParseAge(t.ShorterThan)
+	}
+
+	orphans, err := cleaner.FindInventoryOrphans(a.paths, inv, rules)
 	if err != nil {
-		fmt.Fprintln(stderr, "Error scanning projects:", err)
-		return 1
+		fmt.Fprintln(a.stderr, "Error finding orphans:", err)
+		return nil, nil, false
 	}
 
-	// Extract unique project paths
-	var projectPaths []string
-	for _, p := range projects {
-		if p.ActualPath != "" {
-			projectPaths = append(projectPaths, p.ActualPath)
-		}
+	orphans, protected := cleaner.ProtectOrphans(orphans, inv.Projects, a.protect)
+	return orphans, protected, true
+}
+
+// findDuplicateConfigs analyzes the local configs of all known projects against
+// the managed, user and project settings, skipping projects protected by the policy or a pin. If
+// there is nothing to deduplicate it reports why and returns nil results
+// together with the exit code.
+func (a *app) findDuplicateConfigs() ([]cleaner.DedupResult, []ui.Change, int) {
+	layers, err := a.globalSettingsLayers()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return nil, nil, 1
+	}
+
+	// Get project paths from the inventory for fast config lookup
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return nil, nil, 1
 	}
+	projectPaths, protected := cleaner.ProtectPaths(inv.ProjectPaths(), a.protect)
 
 	// Find local configs only in known project directories (fast)
-	homeLocalSettings := filepath.Join(paths.Root, "settings.local.json")
+	// Exclude ~/.claude/settings.local.json (if home dir is a project, it shouldn't be treated as a local config)
+	homeLocalSettings := filepath.Join(a.paths.Root, "settings.local.json")
 	localConfigs := cleaner.FindLocalConfigsFromProjects(projectPaths, homeLocalSettings)
 
 	if len(localConfigs) == 0 {
-		fmt.Fprintln(stdout, "No local configs found.")
-		return 0
+		a.printf("No local configs found.\n")
+		return nil, nil, 0
 	}
 
 	// Analyze each local config
@@ -541,30 +1169,56 @@ func listConfig(args *Args, paths *claude.Paths, stdout, stderr io.Writer) int {
 	for _, configPath := range localConfigs {
 		local, err := claude.LoadSettings(configPath)
 		if err != nil {
-			fmt.Fprintf(stderr, "Warning: could not load %s: %v\n", configPath, err)
+			fmt.Fprintf(a.stderr, "Warning: could not load %s: %v\n", configPath, err)
+			continue
+		}
+
+		// The shared project settings apply on top of the global layers
+		projectPath := cleaner.ProjectSettingsPath(configPath)
+		project, err := claude.LoadSettings(projectPath)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "Warning: could not load %s: %v\n", projectPath, err)
 			continue
 		}
 
-		result := cleaner.DeduplicateConfig(configPath, global, local)
+		result := cleaner.DeduplicateLayers(configPath, slices.Concat(layers, []claude.SettingsLayer{
+			{Name: claude.LayerProject, Path: projectPath, Settings: project},
+		}), local)
 		if result.HasDuplicates() || result.SuggestDelete {
 			results = append(results, *result)
 		}
 	}
 
 	if len(results) == 0 {
-		fmt.Fprintln(stdout, "No duplicate configs found.")
-		return 0
+		a.printf("No duplicate configs found.\n")
+		return nil, nil, 0
 	}
 
-	// Use verbose preview if requested
-	var preview *ui.Preview
-	if args.Verbose {
-		preview = cleaner.BuildDedupPreviewVerbose(results, paths.Settings)
-	} else {
-		preview = cleaner.BuildDedupPreview(results)
-	}
+	return results, protected, 0
+}
 
-	_ = preview.Display(stdout)
+// globalSettingsLayers loads the settings layers that apply to every
+// project, highest precedence first.
+func (a *app) globalSettingsLayers() ([]claude.SettingsLayer, error) {
+	var layers []claude.SettingsLayer
+	for _, l := range []claude.SettingsLayer{
+		{Name: claude.LayerManaged, Path: cmp.Or(a.policy.ManagedSettings, a.paths.ManagedSettings)},
+		{Name: claude.LayerUser, Path: a.paths.Settings},
+	} {
+		settings, err := claude.LoadSettings(l.Path)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s settings: %w", l.Name, err)
+		}
+		l.Settings = settings
+		layers = append(layers, l)
+	}
+	return layers, nil
+}
 
-	return 0
+// dedupPreview builds the deduplication preview, verbose if requested.
+func (a *app) dedupPreview(results []cleaner.DedupResult) *ui.Preview {
+	if a.args.Verbose {
+		return cleaner.BuildDedupPreviewVerbose(results)
+	}
+	return cleaner.BuildDedupPreview(results)
 }
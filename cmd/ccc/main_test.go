@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -405,6 +406,101 @@ func TestRunCLI_CleanOrphansDryRun(t *testing.T) {
 	assert.FileExists(t, orphanTodo)
 }
 
+func TestRunCLI_CleanOrphansLogsAndPlans(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+	claudeDir := filepath.Join(tmpDir, ".claude")
+
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	write := func(path string, modTime time.Time) string {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		return path
+	}
+	oldSnapshot := write(filepath.Join(claudeDir, "shell-snapshots", "snapshot-bash-1.sh"), old)
+	newLog := write(filepath.Join(claudeDir, "debug", "full1.txt"), time.Now())
+	plan := write(filepath.Join(claudeDir, "plans", "fuzzy-wandering-otter.md"), old)
+	unusedPlan := write(filepath.Join(claudeDir, "plans", "lost-humming-bird.md"), time.Now())
+	writePolicyFile(t, tmpDir, "[retention]\nlogs_older_than = \"7d\"\n")
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "orphans"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Old shell snapshot")
+	assert.Contains(t, stdout.String(), "Plan of no existing session")
+
+	stdout.Reset()
+	code = runCLI([]string{"clean", "orphans", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoFileExists(t, oldSnapshot)
+	assert.NoFileExists(t, unusedPlan)
+	assert.FileExists(t, newLog)
+	assert.FileExists(t, plan, "the plan of an existing session is kept")
+}
+
+func TestRunCLI_CleanOrphanAgents(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	projectDir := filepath.Join(claudeDir, "projects", "-webapp")
+
+	writeAgent := func(path, sessionID string) string {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		line := `{"sessionId":"` + sessionID + `","cwd":"/webapp","isSidechain":true}` + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+		return path
+	}
+	agent := writeAgent(filepath.Join(projectDir, "full1", "subagents", "agent-a1.jsonl"), "full1")
+	writeAgent(filepath.Join(projectDir, "deleted", "subagents", "agent-a2.jsonl"), "deleted")
+	agentTodo := filepath.Join(claudeDir, "todos", "full1-agent-a9.json")
+	require.NoError(t, os.WriteFile(agentTodo, []byte("[]"), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "orphans"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Agent transcript of a deleted session")
+	assert.Contains(t, stdout.String(), "Todo of an agent whose transcript is gone")
+
+	stdout.Reset()
+	code = runCLI([]string{"clean", "orphans", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoDirExists(t, filepath.Join(projectDir, "deleted"))
+	assert.NoFileExists(t, agentTodo)
+	assert.FileExists(t, agent, "the agents of an existing session are kept")
+	assert.FileExists(t, filepath.Join(claudeDir, "todos", "full1-agent-full1.json"))
+}
+
+func TestRunCLI_CleanTrivialSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	session := setupFullSession(t, tmpDir)
+	trivial := filepath.Join(filepath.Dir(session), "trivial.jsonl")
+	line := `{"type":"user","sessionId":"trivial","cwd":"/webapp","timestamp":"2025-12-01T09:00:00Z","message":{"role":"user","content":"hi"}}` + "\n"
+	require.NoError(t, os.WriteFile(trivial, []byte(line), 0644))
+	writePolicyFile(t, tmpDir, "[trivial_sessions]\nmax_messages = 1\nno_tool_use = true\n")
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "orphans"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Trivial session: 1 prompt without response, no tool use")
+	assert.NotContains(t, stdout.String(), session)
+
+	stdout.Reset()
+	code = runCLI([]string{"clean", "orphans", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoFileExists(t, trivial)
+	assert.FileExists(t, session)
+}
+
 func TestParseArgs_ListConfig(t *testing.T) {
 	args, err := parseArgs([]string{"list", "config"})
 	require.NoError(t, err)
@@ -526,6 +622,43 @@ func TestRunCLI_ListConfigVerbose(t *testing.T) {
 	assert.Contains(t, output, "Bash(rm -rf:*)")
 }
 
+func TestRunCLI_ListConfigVerboseLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	projectsDir := filepath.Join(claudeDir, "projects")
+	require.NoError(t, os.MkdirAll(projectsDir, 0755))
+
+	// Managed settings deny what the local config denies
+	managedPath := filepath.Join(tmpDir, "managed-settings.json")
+	require.NoError(t, os.WriteFile(managedPath, []byte(`{"permissions":{"deny":["Bash(rm -rf:*)"]}}`), 0644))
+	writePolicyFile(t, tmpDir, `managed_settings = "`+filepath.ToSlash(managedPath)+`"`)
+
+	// The shared project settings allow what the local config allows
+	projectDir := filepath.Join(tmpDir, "myproject")
+	projectClaudeDir := filepath.Join(projectDir, ".claude")
+	require.NoError(t, os.MkdirAll(projectClaudeDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectClaudeDir, "settings.json"), []byte(`{"permissions":{"allow":["Bash(make:*)"]}}`), 0644))
+	localSettings := `{"permissions":{"allow":["Bash(make:*)"],"deny":["Bash(rm -rf:*)"]}}`
+	require.NoError(t, os.WriteFile(filepath.Join(projectClaudeDir, "settings.local.json"), []byte(localSettings), 0644))
+
+	encodedProjectDir := filepath.Join(projectsDir, "-myproject")
+	require.NoError(t, os.MkdirAll(encodedProjectDir, 0755))
+	sessionData := `{"sessionId":"sess1","cwd":"` + filepath.ToSlash(projectDir) + `","timestamp":"2025-01-01T00:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(encodedProjectDir, "session.jsonl"), []byte(sessionData), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "config", "--verbose"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 0, code, stderr.String())
+	output := stdout.String()
+	assert.Contains(t, output, "Duplicates of managed settings ("+managedPath+")")
+	assert.Contains(t, output, "Duplicates of project settings ("+filepath.Join(projectClaudeDir, "settings.json")+")")
+	assert.Contains(t, output, "File will be deleted")
+}
+
 func TestParseArgs_VerboseFlag(t *testing.T) {
 	args, err := parseArgs([]string{"clean", "config", "--verbose"})
 	require.NoError(t, err)
@@ -581,3 +714,66 @@ func TestRunCLI_CleanConfigVerboseDryRun(t *testing.T) {
 	// Should show the global config path
 	assert.Contains(t, output, "settings.json")
 }
+
+func TestRunCLI_CleanConfigSubsumed(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	projectsDir := filepath.Join(claudeDir, "projects")
+	require.NoError(t, os.MkdirAll(projectsDir, 0755))
+
+	globalSettings := `{"permissions":{"allow":["Bash(git:*)"]}}`
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(globalSettings), 0644))
+
+	// Narrower rules covered by the global settings and by the file itself
+	projectDir := filepath.Join(tmpDir, "myproject")
+	projectClaudeDir := filepath.Join(projectDir, ".claude")
+	require.NoError(t, os.MkdirAll(projectClaudeDir, 0755))
+	localPath := filepath.Join(projectClaudeDir, "settings.local.json")
+	localSettings := `{"permissions":{"allow":["Bash(git status:*)","Bash(npm:*)","Bash(npm test)"]}}`
+	require.NoError(t, os.WriteFile(localPath, []byte(localSettings), 0644))
+
+	encodedProjectDir := filepath.Join(projectsDir, "-myproject")
+	require.NoError(t, os.MkdirAll(encodedProjectDir, 0755))
+	sessionData := `{"sessionId":"sess1","cwd":"` + filepath.ToSlash(projectDir) + `","timestamp":"2025-01-01T00:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(encodedProjectDir, "session.jsonl"), []byte(sessionData), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "config", "--dry-run", "--verbose"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "allow: Bash(git status:*) by Bash(git:*) (user settings)")
+	assert.Contains(t, stdout.String(), "allow: Bash(npm test) by Bash(npm:*) (local settings)")
+
+	stdout.Reset()
+	code = runCLI([]string{"clean", "config", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	data, err := os.ReadFile(localPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"permissions":{"allow":["Bash(npm:*)"]}}`, string(data))
+}
+
+func TestRunCLI_CleanAllSharesInventory(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+
+	// A todo of the stale project's session only becomes an orphan once the
+	// project has been removed earlier in the same run
+	todosDir := filepath.Join(tmpDir, ".claude", "todos")
+	require.NoError(t, os.MkdirAll(todosDir, 0755))
+	todo := filepath.Join(todosDir, "sess1-agent-sess1.json")
+	require.NoError(t, os.WriteFile(todo, []byte("[]"), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.NoDirExists(t, projectDir)
+	assert.NoFileExists(t, todo)
+	assert.Contains(t, stdout.String(), "Cleaned 1 orphaned items")
+}
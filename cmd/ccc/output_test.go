@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonOutput is the top-level document written by --output json.
+type jsonOutput struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Command       string           `json:"command"`
+	Records       []map[string]any `json:"records"`
+}
+
+func TestParseArgs_Output(t *testing.T) {
+	for _, argv := range [][]string{
+		{"list", "--output", "json"},
+		{"list", "--output=json"},
+		{"list", "-o", "json"},
+	} {
+		args, err := parseArgs(argv)
+		require.NoError(t, err)
+		assert.Equal(t, "json", args.Output)
+	}
+
+	_, err := parseArgs([]string{"list", "--output", "xml"})
+	assert.Error(t, err)
+}
+
+func TestRunCLI_ListProjectsJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStaleProject(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "projects", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc), stdout.String())
+	assert.Equal(t, 1, doc.SchemaVersion)
+	assert.Equal(t, "list projects", doc.Command)
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, "project", doc.Records[0]["kind"])
+	assert.Equal(t, "stale", doc.Records[0]["status"])
+	assert.Equal(t, []any{"sess1"}, doc.Records[0]["sessionIds"])
+}
+
+func TestRunCLI_ListProjectsNDJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".claude", "projects"), 0755))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "projects", "--output", "ndjson"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code)
+	// Only the header line, no human-readable text
+	assert.Equal(t, `{"kind":"header","schemaVersion":1,"command":"list projects"}`+"\n", stdout.String())
+}
+
+func TestRunCLI_CleanProjectsJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "projects", "--yes", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoDirExists(t, projectDir)
+
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc), stdout.String())
+
+	var kinds []string
+	for _, r := range doc.Records {
+		kinds = append(kinds, r["kind"].(string))
+	}
+	assert.Equal(t, []string{"result", "summary", "trashRun"}, kinds)
+	assert.Equal(t, "done", doc.Records[0]["status"])
+	assert.Equal(t, "DELETE", doc.Records[0]["action"])
+	assert.Equal(t, float64(1), doc.Records[1]["items"])
+	assert.Equal(t, trashRunIDs(t, tmpDir)[0], doc.Records[2]["runId"])
+
+	// The preview goes to stderr so that stdout stays parseable
+	assert.Contains(t, stderr.String(), "Stale Project Cleanup")
+}
+
+func TestRunCLI_CleanProjectsDryRunNDJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "projects", "--dry-run", "--output", "ndjson"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code)
+	assert.DirExists(t, projectDir)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[1], `"kind":"project"`)
+	assert.Contains(t, lines[2], `"dryRun":true`)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pathglob"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pins"
+)
+
+// handlePin handles the "pin" command.
+func (a *app) handlePin() int {
+	if len(a.args.Targets) == 0 {
+		fmt.Fprintln(a.stderr, "Usage: cccc pin <path-or-glob>...")
+		return 1
+	}
+
+	patterns, err := pinPatterns(a.args.Targets)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	now := time.Now()
+	var results []output.Result
+	for _, pattern := range patterns {
+		result := output.NewResult("pins", "pin", pattern, 0, nil)
+		if a.pins.Add(pattern, now) {
+			a.printf("Pinned %s\n", pattern)
+		} else {
+			result.Status = output.ResultSkipped
+			a.printf("%s is already pinned\n", pattern)
+		}
+		results = append(results, result)
+	}
+
+	if err := a.pins.Save(); err != nil {
+		fmt.Fprintln(a.stderr, "Error saving pins:", err)
+		return 1
+	}
+	if a.machine() {
+		for _, r := range results {
+			a.emit(r)
+		}
+	}
+	return 0
+}
+
+// handleUnpin handles the "unpin" command. Nothing is changed unless every
+// target is pinned.
+func (a *app) handleUnpin() int {
+	if len(a.args.Targets) == 0 {
+		fmt.Fprintln(a.stderr, "Usage: cccc unpin <path-or-glob>...")
+		return 1
+	}
+
+	patterns, err := pinPatterns(a.args.Targets)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	for _, pattern := range patterns {
+		if !a.pins.Has(pattern) {
+			fmt.Fprintf(a.stderr, "Error: %s is not pinned\n", pattern)
+			return 1
+		}
+	}
+
+	for _, pattern := range patterns {
+		a.pins.Remove(pattern)
+	}
+	if err := a.pins.Save(); err != nil {
+		fmt.Fprintln(a.stderr, "Error saving pins:", err)
+		return 1
+	}
+
+	for _, pattern := range patterns {
+		if a.machine() {
+			a.emit(output.NewResult("pins", "unpin", pattern, 0, nil))
+		}
+		a.printf("Unpinned %s\n", pattern)
+	}
+	return 0
+}
+
+// listPins lists the pins along with the known projects each one matches.
+func (a *app) listPins() int {
+	// A missing projects directory just means no project matches
+	var projects []claude.Project
+	if inv, err := a.inventory(); err == nil {
+		projects = inv.Projects
+	}
+
+	if a.machine() {
+		for _, pin := range a.pins.Pins {
+			a.emit(output.NewPin(pin, matchingProjects(pin.Pattern, projects)))
+		}
+		return 0
+	}
+
+	if len(a.pins.Pins) == 0 {
+		fmt.Fprintln(a.stdout, "No pins.")
+		return 0
+	}
+
+	fmt.Fprintln(a.stdout, "Pins:")
+	for _, pin := range a.pins.Pins {
+		matches := matchingProjects(pin.Pattern, projects)
+		fmt.Fprintf(a.stdout, "  %s\n", pin.Pattern)
+		fmt.Fprintf(a.stdout, "        pinned: %s, matches %d projects\n",
+			pin.Created.Local().Format("2006-01-02 15:04"), len(matches))
+		if a.args.Verbose {
+			for _, path := range matches {
+				fmt.Fprintf(a.stdout, "        %s\n", path)
+			}
+		}
+	}
+	return 0
+}
+
+// pinPatterns normalizes the pin targets given on the command line.
+func pinPatterns(targets []string) ([]string, error) {
+	var patterns []string
+	for _, target := range targets {
+		pattern, err := pins.Normalize(target)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// matchingProjects returns the paths of the projects matched by pattern.
+func matchingProjects(pattern string, projects []claude.Project) []string {
+	var matches []string
+	for _, p := range projects {
+		if pathglob.MatchTree(pattern, p.ActualPath) {
+			matches = append(matches, p.ActualPath)
+		}
+	}
+	return matches
+}
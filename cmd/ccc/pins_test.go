@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs_Pin(t *testing.T) {
+	args, err := parseArgs([]string{"pin", "/mnt/usb/**", "~/Code/worktree"})
+	require.NoError(t, err)
+	assert.Equal(t, "pin", args.Command)
+	assert.Equal(t, []string{"/mnt/usb/**", "~/Code/worktree"}, args.Targets)
+
+	args, err = parseArgs([]string{"pins", "--verbose"})
+	require.NoError(t, err)
+	assert.Equal(t, "pins", args.Command)
+	assert.True(t, args.Verbose)
+
+	_, err = parseArgs([]string{"pins", "/mnt/usb"})
+	assert.Error(t, err)
+}
+
+func TestRunCLI_PinUnpinAndList(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStaleProject(t, tmpDir)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"pin", "~/this-path-does-not-exist-*"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	pattern := filepath.Join(tmpDir, "this-path-does-not-exist-*")
+	assert.Contains(t, stdout.String(), "Pinned "+pattern)
+	assert.FileExists(t, filepath.Join(tmpDir, ".claude", "cccc-pins.json"))
+
+	// Pinning again is a no-op
+	stdout.Reset()
+	code = runCLI([]string{"pin", pattern}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "already pinned")
+
+	stdout.Reset()
+	code = runCLI([]string{"pins", "--verbose"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), pattern)
+	assert.Contains(t, stdout.String(), "matches 1 projects")
+	assert.Contains(t, stdout.String(), filepath.Join(tmpDir, "this-path-does-not-exist-anywhere"))
+
+	// Unpinning something that is not pinned changes nothing
+	stderr.Reset()
+	code = runCLI([]string{"unpin", pattern, "/not/pinned"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "/not/pinned is not pinned")
+
+	stdout.Reset()
+	code = runCLI([]string{"unpin", pattern}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Unpinned "+pattern)
+
+	stdout.Reset()
+	code = runCLI([]string{"pins"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "No pins.")
+}
+
+func TestRunCLI_PinKeepsStaleProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	pinnedDir := setupStaleProject(t, tmpDir)
+
+	// A second stale project that is not pinned
+	otherDir := filepath.Join(tmpDir, ".claude", "projects", "-other-missing")
+	require.NoError(t, os.MkdirAll(otherDir, 0755))
+	sessionData := `{"sessionId":"sess2","cwd":"` + filepath.ToSlash(filepath.Join(tmpDir, "other-missing")) + `","timestamp":"2025-01-01T00:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, "session.jsonl"), []byte(sessionData), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	pinnedPath := filepath.Join(tmpDir, "this-path-does-not-exist-anywhere")
+	code := runCLI([]string{"pin", pinnedPath}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	stdout.Reset()
+	code = runCLI([]string{"list", "projects"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "[PINNED]")
+	assert.Contains(t, stdout.String(), "pinned ("+pinnedPath+")")
+
+	stdout.Reset()
+	code = runCLI([]string{"clean", "projects", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "pinned ("+pinnedPath+")", "pinned project is listed as kept")
+	assert.DirExists(t, pinnedDir)
+	assert.NoDirExists(t, otherDir)
+}
+
+func TestRunCLI_PinsJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStaleProject(t, tmpDir)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	pattern := filepath.Join(tmpDir, "**")
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"pin", pattern, "-o", "json"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, "result", doc.Records[0]["kind"])
+	assert.Equal(t, "pin", doc.Records[0]["action"])
+	assert.Equal(t, pattern, doc.Records[0]["path"])
+
+	stdout.Reset()
+	code = runCLI([]string{"pins", "-o", "json"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	doc = jsonOutput{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, "pin", doc.Records[0]["kind"])
+	assert.Equal(t, []any{filepath.Join(tmpDir, "this-path-does-not-exist-anywhere")}, doc.Records[0]["projects"])
+}
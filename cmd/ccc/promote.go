@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// defaultMinProjects is the number of local configs that must have a rule for
+// config promote to suggest it.
+const defaultMinProjects = 3
+
+// promoteConfig moves the permission rules that at least --min-projects local
+// configs have in common into the user settings and strips them from the
+// local configs, as one previewed and audited change. The user settings are
+// written first, so that a failure never leaves a rule in neither place. The
+// originals of all files are kept in the trash run q, and user settings that
+// did not exist are recorded in it, so that restoring the run undoes it all.
+func (a *app) promoteConfig(q cleaner.Quarantine) int {
+	minProjects := defaultMinProjects
+	if a.args.MinProjects != "" {
+		n, err := strconv.Atoi(a.args.MinProjects)
+		if err != nil || n < 1 {
+			fmt.Fprintf(a.stderr, "Error: invalid --min-projects value: %s\n", a.args.MinProjects)
+			return 1
+		}
+		minProjects = n
+	}
+
+	layers, err := a.globalSettingsLayers()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	projectPaths, protected := cleaner.ProtectPaths(inv.ProjectPaths(), a.protect)
+
+	// The home directory's local settings belong to the user settings
+	homeLocalSettings := filepath.Join(a.paths.Root, "settings.local.json")
+	locals := make(map[string]*claude.Settings)
+	for _, path := range cleaner.FindLocalConfigsFromProjects(projectPaths, homeLocalSettings) {
+		local, err := claude.LoadSettings(path)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "Warning: could not load %s: %v\n", path, err)
+			continue
+		}
+		locals[path] = local
+	}
+
+	promotions, held := cleaner.FindPromotions(locals, layers, minProjects)
+	if len(promotions) == 0 {
+		for _, f := range held {
+			a.printf("Not promoted, %s\n", f.Description())
+		}
+		a.printf("No rules to promote found in %d or more of %d local configs.\n", minProjects, len(locals))
+		return 0
+	}
+	results := cleaner.PlanPromotion(promotions, locals)
+
+	preview := cleaner.BuildPromotionPreview(a.paths.Settings, promotions, results, held)
+	preview.Kept = append(preview.Kept, protected...)
+
+	if a.args.DryRun {
+		var records []any
+		for _, p := range promotions {
+			records = append(records, output.NewPromotion(p))
+		}
+		for _, f := range held {
+			records = append(records, output.NewLint(f))
+		}
+		a.showDryRun(preview, "promote", records)
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "promote")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+	summary := output.NewSummary("promote")
+
+	// Add the rules to the user settings before removing them anywhere
+	if err := cleaner.ApplyPromotion(a.paths.Settings, promotions, q); err != nil {
+		fmt.Fprintf(a.stderr, "Error updating %s: %v\n", a.paths.Settings, err)
+		if a.machine() {
+			a.emit(output.NewResult("promote", string(ui.ActionModify), a.paths.Settings, 0, err))
+			summary.Errors++
+			a.emit(summary)
+		}
+		return 1
+	}
+	if auditLogger != nil {
+		_ = auditLogger.LogWithDetails(ui.ActionModify, a.paths.Settings, "added "+describePromotions(promotions))
+	}
+	if a.machine() {
+		a.emit(output.NewResult("promote", string(ui.ActionModify), a.paths.Settings, 0, nil))
+		summary.Items++
+	}
+
+	updated := 0
+	for _, r := range results {
+		action := ui.ActionModify
+		if r.SuggestDelete {
+			action = ui.ActionDelete
+		}
+
+		if err := cleaner.ApplyDedup(&r, q, false); err != nil {
+			fmt.Fprintf(a.stderr, "Error updating %s: %v\n", r.LocalPath, err)
+			if a.machine() {
+				a.emit(output.NewResult("promote", string(action), r.LocalPath, 0, err))
+				summary.Errors++
+			}
+			continue
+		}
+		updated++
+		if auditLogger != nil {
+			_ = auditLogger.LogWithDetails(action, r.LocalPath, fmt.Sprintf("%s, promoted to %s", r.FormatAuditDetails(), a.paths.Settings))
+		}
+		if a.machine() {
+			a.emit(output.NewResult("promote", string(action), r.LocalPath, 0, nil))
+			summary.Items++
+		}
+	}
+
+	if a.machine() {
+		a.emit(summary)
+	}
+	a.printf("Promoted %d rules to %s and updated %d local configs\n", len(promotions), a.paths.Settings, updated)
+	return 0
+}
+
+// describePromotions lists the promoted rules by permission list.
+func describePromotions(promotions []cleaner.Promotion) string {
+	var parts []string
+	for _, list := range []string{"allow", "deny", "ask"} {
+		var rules []string
+		for _, p := range promotions {
+			if p.List == list {
+				rules = append(rules, p.Rule)
+			}
+		}
+		if len(rules) > 0 {
+			parts = append(parts, list+": "+strings.Join(rules, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
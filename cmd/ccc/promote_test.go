@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPromoteProjects creates user settings and registered projects with the
+// given local settings, returning the local settings paths.
+func setupPromoteProjects(t *testing.T, tmpDir, user string, locals ...string) []string {
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	require.NoError(t, os.MkdirAll(claudeDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.json"), []byte(user), 0644))
+
+	var paths []string
+	for i, local := range locals {
+		name := "project" + string(rune('a'+i))
+		encodedProjectDir := filepath.Join(claudeDir, "projects", "-"+name)
+		require.NoError(t, os.MkdirAll(encodedProjectDir, 0755))
+		projectDir := filepath.Join(tmpDir, name)
+		sessionData := `{"sessionId":"sess1","cwd":"` + filepath.ToSlash(projectDir) + `","timestamp":"2025-01-01T00:00:00Z"}`
+		require.NoError(t, os.WriteFile(filepath.Join(encodedProjectDir, "sess1.jsonl"), []byte(sessionData), 0644))
+
+		localPath := filepath.Join(projectDir, ".claude", "settings.local.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(localPath), 0755))
+		require.NoError(t, os.WriteFile(localPath, []byte(local), 0644))
+		paths = append(paths, localPath)
+	}
+	return paths
+}
+
+func TestParseArgs_ConfigPromote(t *testing.T) {
+	args, err := parseArgs([]string{"config", "promote", "--min-projects", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, "config", args.Command)
+	assert.Equal(t, "promote", args.Subcommand)
+	assert.Equal(t, "2", args.MinProjects)
+}
+
+func TestRunCLI_ConfigPromoteDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	localPaths := setupPromoteProjects(t, tmpDir, `{}`,
+		`{"permissions":{"allow":["Bash(make:*)","Bash(go test:*)"]}}`,
+		`{"permissions":{"allow":["Bash(make:*)"]}}`,
+		`{"permissions":{"allow":["Bash(go test:*)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "promote", "--min-projects", "2", "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Config Promotion")
+	assert.Contains(t, out, "allow: Bash(go test:*) (in 2 local configs)")
+	assert.Contains(t, out, "allow: Bash(make:*) (in 2 local configs)")
+
+	// Nothing is changed
+	data, err := os.ReadFile(localPaths[1])
+	require.NoError(t, err)
+	assert.Equal(t, `{"permissions":{"allow":["Bash(make:*)"]}}`, string(data))
+}
+
+func TestRunCLI_ConfigPromote(t *testing.T) {
+	tmpDir := t.TempDir()
+	user := "{\n  \"permissions\": {\n    \"allow\": [\n      \"Bash(ls)\"\n    ]\n  }\n}\n"
+	localPaths := setupPromoteProjects(t, tmpDir, user,
+		`{"permissions":{"allow":["Bash(make:*)","Bash(go test:*)"]}}`,
+		`{"permissions":{"allow":["Bash(make:*)"]}}`,
+		`{"permissions":{"allow":["Bash(make:*)","Bash(npm test)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "promote", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Promoted 1 rules to")
+	assert.Contains(t, stdout.String(), "updated 3 local configs")
+
+	userPath := filepath.Join(tmpDir, ".claude", "settings.json")
+	data, err := os.ReadFile(userPath)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"permissions\": {\n    \"allow\": [\n      \"Bash(ls)\",\n      \"Bash(make:*)\"\n    ]\n  }\n}\n", string(data))
+
+	data, err = os.ReadFile(localPaths[0])
+	require.NoError(t, err)
+	assert.Equal(t, `{"permissions":{"allow":["Bash(go test:*)"]}}`, string(data))
+	assert.NoFileExists(t, localPaths[1])
+
+	// The whole promotion is undone from its trash run
+	ids := trashRunIDs(t, tmpDir)
+	require.Len(t, ids, 1)
+	stdout.Reset()
+	code = runCLI([]string{"restore", ids[0], "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	data, err = os.ReadFile(userPath)
+	require.NoError(t, err)
+	assert.Equal(t, user, string(data))
+	data, err = os.ReadFile(localPaths[1])
+	require.NoError(t, err)
+	assert.Equal(t, `{"permissions":{"allow":["Bash(make:*)"]}}`, string(data))
+}
+
+func TestRunCLI_ConfigPromoteCreatesUserSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	localPaths := setupPromoteProjects(t, tmpDir, `{}`,
+		`{"permissions":{"allow":["Bash(make:*)"]}}`,
+		`{"permissions":{"allow":["Bash(make:*)","Bash(ls)"]}}`)
+	userPath := filepath.Join(tmpDir, ".claude", "settings.json")
+	require.NoError(t, os.Remove(userPath))
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "promote", "--min-projects", "2", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	data, err := os.ReadFile(userPath)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"permissions\": {\n    \"allow\": [\n      \"Bash(make:*)\"\n    ]\n  }\n}\n", string(data))
+	assert.NoFileExists(t, localPaths[0])
+
+	// Restoring the run removes the created file again
+	ids := trashRunIDs(t, tmpDir)
+	require.Len(t, ids, 1)
+	code = runCLI([]string{"restore", ids[0], "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	assert.NoFileExists(t, userPath)
+	data, err = os.ReadFile(localPaths[0])
+	require.NoError(t, err)
+	assert.Equal(t, `{"permissions":{"allow":["Bash(make:*)"]}}`, string(data))
+}
+
+func TestRunCLI_ConfigPromoteHoldsBackShadowedRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupPromoteProjects(t, tmpDir, `{"permissions":{"deny":["Bash(git push:*)"]}}`,
+		`{"permissions":{"allow":["Bash(git push origin:*)"]}}`,
+		`{"permissions":{"allow":["Bash(git push origin:*)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "promote", "--min-projects", "2"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Not promoted, allow Bash(git push origin:*) is shadowed by deny Bash(git push:*) of user settings")
+	assert.Contains(t, stdout.String(), "No rules to promote found")
+}
+
+func TestRunCLI_ConfigPromoteJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	localPaths := setupPromoteProjects(t, tmpDir, `{}`,
+		`{"permissions":{"deny":["Read(~/.ssh/**)"]}}`,
+		`{"permissions":{"deny":["Read(~/.ssh/**)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "promote", "--min-projects", "2", "--dry-run", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var doc struct {
+		Records []map[string]any `json:"records"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Records, 2)
+	assert.Equal(t, "promotion", doc.Records[0]["kind"])
+	assert.Equal(t, "deny", doc.Records[0]["list"])
+	assert.Equal(t, "Read(~/.ssh/**)", doc.Records[0]["rule"])
+	assert.Equal(t, []any{localPaths[0], localPaths[1]}, doc.Records[0]["configs"])
+	assert.Equal(t, "summary", doc.Records[1]["kind"])
+}
+
+func TestRunCLI_ConfigPromoteNothing(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupPromoteProjects(t, tmpDir, `{"permissions":{"allow":["Bash(make:*)"]}}`,
+		`{"permissions":{"allow":["Bash(make:*)"]}}`,
+		`{"permissions":{"allow":["Bash(make test)"]}}`,
+		`{"permissions":{"allow":["Bash(make:*)","Read(./src/**)"]}}`)
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "promote", "--min-projects", "1"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "No rules to promote found in 1 or more of 3 local configs.")
+}
+
+func TestRunCLI_ConfigPromoteInvalidMinProjects(t *testing.T) {
+	cleanup := setTestHome(t, t.TempDir())
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"config", "promote", "--min-projects", "zero"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "invalid --min-projects value: zero")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// handleRepair handles the "repair" command and subcommands.
+// Repaired files are preserved in q first so that they can be restored.
+func (a *app) handleRepair(q cleaner.Quarantine) int {
+	switch a.args.Subcommand {
+	case "sessions":
+		return a.repairSessions(q)
+	case "":
+		fmt.Fprintln(a.stderr, "Usage: cccc repair sessions [--dry-run]")
+		return 1
+	default:
+		fmt.Fprintf(a.stderr, "Unknown repair subcommand: %s\n", a.args.Subcommand)
+		return 1
+	}
+}
+
+// repairSessions truncates session files that end in invalid data, as left
+// behind by a crash, after their last valid line.
+func (a *app) repairSessions(q cleaner.Quarantine) int {
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	projects, protected := cleaner.ProtectProjects(inv.Projects, a.protect)
+
+	results := cleaner.FindRepairableSessions(projects)
+	if len(results) == 0 {
+		a.printf("No session files to repair.\n")
+		return 0
+	}
+
+	preview := cleaner.BuildRepairPreview(results)
+	preview.Kept = append(preview.Kept, protected...)
+
+	if a.args.DryRun {
+		var records []any
+		for _, r := range results {
+			records = append(records, output.NewResult("repair", string(ui.ActionModify), r.Session.FilePath, r.Truncated(), nil))
+		}
+		a.showDryRun(preview, "repair", records)
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "repair")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	summary := output.NewSummary("repair")
+	var totalTruncated int64
+	repaired := 0
+	for _, r := range results {
+		if err := cleaner.RepairSession(r, q, false); err != nil {
+			fmt.Fprintf(a.stderr, "Error repairing session %s: %v\n", r.Session.FilePath, err)
+			if a.machine() {
+				a.emit(output.NewResult("repair", string(ui.ActionModify), r.Session.FilePath, 0, err))
+				summary.Errors++
+			}
+			continue
+		}
+		repaired++
+		totalTruncated += r.Truncated()
+
+		if auditLogger != nil {
+			_ = auditLogger.LogWithDetails(ui.ActionModify, r.Session.FilePath, r.FormatAuditDetails())
+		}
+		if a.machine() {
+			a.emit(output.NewResult("repair", string(ui.ActionModify), r.Session.FilePath, r.Truncated(), nil))
+			summary.Items++
+		}
+	}
+
+	// The repaired files are parsed again by the next scan
+	a.inv = nil
+
+	if a.machine() {
+		summary.Size = totalTruncated
+		a.emit(summary)
+	}
+	a.printf("Repaired %d sessions, removed %s of invalid data\n", repaired, ui.FormatSize(totalTruncated))
+	return 0
+}
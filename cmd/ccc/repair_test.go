@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCrashedSession creates an existing project whose only session file
+// ends in a half-written line. It returns the session file and its valid part.
+func setupCrashedSession(t *testing.T, tmpDir string) (session, valid string) {
+	existing := filepath.Join(tmpDir, "existing-project")
+	require.NoError(t, os.MkdirAll(existing, 0755))
+
+	projectDir := filepath.Join(tmpDir, ".claude", "projects", "-existing-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	valid = `{"sessionId":"crashed","cwd":"` + filepath.ToSlash(existing) + `","timestamp":"2025-12-06T10:00:00Z"}` + "\n"
+	session = filepath.Join(projectDir, "crashed.jsonl")
+	require.NoError(t, os.WriteFile(session, []byte(valid+`{"type":"assistant","mess`), 0644))
+	return session, valid
+}
+
+func TestParseArgs_RepairSessions(t *testing.T) {
+	args, err := parseArgs([]string{"repair", "sessions", "--dry-run"})
+	require.NoError(t, err)
+	assert.Equal(t, "repair", args.Command)
+	assert.Equal(t, "sessions", args.Subcommand)
+	assert.True(t, args.DryRun)
+}
+
+func TestRunCLI_ListProjectsShowsCorruptSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupCrashedSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "projects"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "[CORRUPT]")
+	assert.Contains(t, stdout.String(), "1 corrupt sessions (1 invalid lines), repair with: cccc repair sessions")
+
+	stdout.Reset()
+	code = runCLI([]string{"list", "projects", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc), stdout.String())
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, "corrupt", doc.Records[0]["status"])
+	assert.Len(t, doc.Records[0]["corruptSessions"], 1)
+}
+
+func TestRunCLI_ListProjectsUnknownPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, ".claude", "projects", "-lost-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "broken.jsonl"), []byte(`{"type":"assistant","mess`), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "projects"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "[CORRUPT] (unknown path)")
+	assert.Contains(t, stdout.String(), "corrupt sessions (path unknown, no valid line has a cwd)")
+
+	stdout.Reset()
+	code = runCLI([]string{"list", "projects", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc), stdout.String())
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, "corrupt", doc.Records[0]["status"])
+}
+
+func TestRunCLI_RepairSessionsDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	session, valid := setupCrashedSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"repair", "sessions", "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), session)
+	stat, err := os.Stat(session)
+	require.NoError(t, err)
+	assert.Greater(t, stat.Size(), int64(len(valid)))
+}
+
+func TestRunCLI_RepairSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	session, valid := setupCrashedSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"repair", "sessions", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Repaired 1 sessions")
+
+	data, err := os.ReadFile(session)
+	require.NoError(t, err)
+	assert.Equal(t, valid, string(data))
+
+	audit, err := os.ReadFile(filepath.Join(tmpDir, ".claude", "cccc-audit.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(audit), session)
+
+	// The original is kept in the trash and can be restored
+	runs := trashRunIDs(t, tmpDir)
+	require.Len(t, runs, 1)
+	stdout.Reset()
+	code = runCLI([]string{"restore", runs[0], "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	data, err = os.ReadFile(session)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), `{"type":"assistant","mess`))
+
+	// The scan index notices that the restored file is corrupt again
+	stdout.Reset()
+	code = runCLI([]string{"repair", "sessions", "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), session)
+}
+
+func TestRunCLI_RepairUnknownSubcommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"repair"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Usage: cccc repair sessions")
+}
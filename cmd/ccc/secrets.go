@@ -0,0 +1,276 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/secrets"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// secretFile is a file searched for secrets.
+type secretFile struct {
+	path    string
+	session string // ID of the session the file belongs to
+	project string // Path of the project, if known
+	jsonl   bool   // A session transcript rather than a file-history snapshot
+}
+
+// secretHits are the secrets found in a file.
+type secretHits struct {
+	file secretFile
+	hits []secrets.Hit
+}
+
+// handleScan handles the "scan" command and subcommands.
+func (a *app) handleScan() int {
+	switch a.args.Subcommand {
+	case "secrets":
+		return a.scanSecrets()
+	default:
+		fmt.Fprintln(a.stderr, "Usage: cccc scan secrets [filters]")
+		return 1
+	}
+}
+
+// secretFiles returns the transcripts of the sessions of projects that pass
+// the filter flags, and their file-history snapshots. Without filters, the
+// snapshots of sessions that no longer exist are included too. known lists
+// the IDs of all sessions, including those of projects left out.
+func (a *app) secretFiles(projects []claude.Project, known []string) ([]secretFile, error) {
+	filter, err := a.sessionFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []secretFile
+	selected := make(map[string]string) // Session ID to project path
+	for _, p := range projects {
+		for _, s := range p.Sessions {
+			if filter.matches(p, s, s.Size) {
+				files = append(files, secretFile{path: s.FilePath, session: s.ID, project: p.ActualPath, jsonl: true})
+				selected[s.ID] = p.ActualPath
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(a.paths.FileHistory)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		id := entry.Name()
+		project, ok := selected[id]
+		if !entry.IsDir() || (!ok && (filter != sessionFilter{} || slices.Contains(known, id))) {
+			continue
+		}
+		dir := filepath.Join(a.paths.FileHistory, id)
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				files = append(files, secretFile{path: path, session: id, project: project})
+			}
+			return nil
+		})
+	}
+	return files, nil
+}
+
+// findSecrets scans files with the rules of the policy, warning about files
+// that cannot be read. Only files with secrets are returned.
+func (a *app) findSecrets(files []secretFile) ([]secretHits, error) {
+	rules, err := a.policy.Secrets.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []secretHits
+	for _, f := range files {
+		hits, err := secrets.ScanFile(f.path, rules, f.jsonl)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "Warning: could not scan %s: %v\n", f.path, err)
+			continue
+		}
+		if len(hits) > 0 {
+			found = append(found, secretHits{f, hits})
+		}
+	}
+	return found, nil
+}
+
+// scanSecrets reports the secrets in session transcripts and file-history
+// snapshots by session and line, without showing them in full.
+func (a *app) scanSecrets() int {
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	files, err := a.secretFiles(inv.Projects, inv.SessionIDs())
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	found, err := a.findSecrets(files)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	if len(found) == 0 {
+		a.printf("No secrets found in %d files.\n", len(files))
+		return 0
+	}
+
+	total := 0
+	a.printf("Secrets:\n")
+	for _, f := range found {
+		total += len(f.hits)
+		if a.machine() {
+			for _, h := range f.hits {
+				a.emit(output.NewSecret(f.file.path, f.file.session, f.file.project, h))
+			}
+			continue
+		}
+
+		fmt.Fprintf(a.stdout, "  %s\n", f.file.path)
+		fmt.Fprintf(a.stdout, "    session %s, %s\n", f.file.session, cmp.Or(f.file.project, "(unknown project)"))
+		for _, h := range f.hits {
+			fmt.Fprintf(a.stdout, "    line %-6d %-18s %s\n", h.Line, h.Rule, h.Masked)
+		}
+	}
+
+	a.printf("\nFound %d secrets in %d files (redact with: cccc redact)\n", total, len(found))
+	return 0
+}
+
+// handleRedact handles the "redact" command, which replaces the secrets in
+// session transcripts and file-history snapshots. The originals are not kept
+// in the trash, as that would keep the secrets too.
+func (a *app) handleRedact() int {
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	projects, protected := cleaner.ProtectProjects(inv.Projects, a.protect)
+	files, err := a.secretFiles(projects, inv.SessionIDs())
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	found, err := a.findSecrets(files)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	if len(found) == 0 {
+		a.printf("No secrets to redact.\n")
+		return 0
+	}
+
+	preview := &ui.Preview{Title: "Redact Secrets", Kept: protected}
+	for _, f := range found {
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      ui.ActionModify,
+			Path:        f.file.path,
+			Description: describeHits(f.hits),
+			Size:        fileSize(f.file.path),
+		})
+	}
+
+	if a.args.DryRun {
+		var records []any
+		for _, c := range preview.Changes {
+			records = append(records, output.NewResult("redact", string(ui.ActionModify), c.Path, c.Size, nil))
+		}
+		a.showDryRun(preview, "redact", records)
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "redact")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	rules, err := a.policy.Secrets.Compile()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	summary := output.NewSummary("redact")
+	redacted, changed := 0, 0
+	for _, f := range found {
+		hits, err := secrets.RedactFile(f.file.path, rules, f.file.jsonl)
+		if err != nil {
+			fmt.Fprintf(a.stderr, "Error redacting %s: %v\n", f.file.path, err)
+			if a.machine() {
+				a.emit(output.NewResult("redact", string(ui.ActionModify), f.file.path, 0, err))
+				summary.Errors++
+			}
+			continue
+		}
+		if len(hits) == 0 {
+			continue
+		}
+		redacted += len(hits)
+		changed++
+
+		if auditLogger != nil {
+			_ = auditLogger.LogWithDetails(ui.ActionModify, f.file.path, "redacted "+describeHits(hits))
+		}
+		if a.machine() {
+			a.emit(output.NewResult("redact", string(ui.ActionModify), f.file.path, fileSize(f.file.path), nil))
+			summary.Items++
+		}
+	}
+
+	// The rewritten transcripts are parsed again by the next scan
+	a.inv = nil
+
+	if a.machine() {
+		a.emit(summary)
+	}
+	a.printf("Redacted %d secrets in %d files\n", redacted, changed)
+	return 0
+}
+
+// describeHits summarizes secrets by rule, e.g. "3 secrets (aws-access-key,
+// github-token)", without showing them.
+func describeHits(hits []secrets.Hit) string {
+	var rules []string
+	for _, h := range hits {
+		if !slices.Contains(rules, h.Rule) {
+			rules = append(rules, h.Rule)
+		}
+	}
+	slices.Sort(rules)
+	return fmt.Sprintf("%d secrets (%s)", len(hits), strings.Join(rules, ", "))
+}
+
+// fileSize returns the size of the file at path, or 0 if it cannot be read.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
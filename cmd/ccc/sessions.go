@@ -0,0 +1,320 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pathglob"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pins"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// retentionPolicy builds the retention policy from the command-line flags,
+// which default to the retention rules of the policy file.
+func (a *app) retentionPolicy() (cleaner.RetentionPolicy, error) {
+	var retention cleaner.RetentionPolicy
+
+	if a.args.OlderThan != "" {
+		age, err := ui.ParseAge(a.args.OlderThan)
+		if err != nil {
+			return retention, err
+		}
+		retention.OlderThan = age
+	}
+
+	if a.args.KeepLast != "" {
+		n, err := strconv.Atoi(a.args.KeepLast)
+		if err != nil || n < 0 {
+			return retention, fmt.Errorf("invalid --keep-last value: %s", a.args.KeepLast)
+		}
+		retention.KeepLast = n
+	}
+
+	if a.args.MaxSize != "" {
+		size, err := ui.ParseSize(a.args.MaxSize)
+		if err != nil {
+			return retention, err
+		}
+		retention.MaxProjectSize = size
+	}
+
+	if retention.IsZero() {
+		return retention, fmt.Errorf("clean sessions requires --older-than or --max-project-size (e.g. --older-than 90d)")
+	}
+
+	return retention, nil
+}
+
+// cleanSessions removes sessions of existing projects selected by the retention policy.
+func (a *app) cleanSessions(q cleaner.Quarantine) int {
+	retention, err := a.retentionPolicy()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	projects, protected := cleaner.ProtectProjects(inv.Projects, a.protect)
+
+	expired := cleaner.FindExpiredSessions(a.paths, projects, retention, time.Now())
+	if len(expired) == 0 {
+		a.printf("No sessions selected by the retention policy.\n")
+		return 0
+	}
+
+	preview := cleaner.BuildSessionPreview(expired)
+	preview.Kept = append(preview.Kept, protected...)
+
+	if a.args.DryRun {
+		var records []any
+		for _, r := range expired {
+			records = append(records, output.NewSession(r))
+		}
+		a.showDryRun(preview, "sessions", records)
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "sessions")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	// Perform cleanup
+	summary := output.NewSummary("sessions")
+	var totalSaved int64
+	cleaned := 0
+	for _, r := range expired {
+		if err := cleaner.CleanSession(r, q, false); err != nil {
+			fmt.Fprintf(a.stderr, "Error cleaning session %s: %v\n", r.Session.FilePath, err)
+			if a.machine() {
+				a.emit(output.NewResult("sessions", string(ui.ActionDelete), r.Session.FilePath, 0, err))
+				summary.Errors++
+			}
+			continue
+		}
+		inv.RemoveSession(r.Session.ID)
+		cleaned++
+		totalSaved += r.SizeSaved
+
+		if auditLogger != nil {
+			_ = auditLogger.Log(ui.ActionDelete, r.Session.FilePath, r.Session.Size)
+			for _, linked := range r.Linked {
+				_ = auditLogger.LogWithDetails(ui.ActionDelete, linked, "linked to session "+r.Session.ID)
+			}
+		}
+		if a.machine() {
+			a.emit(output.NewResult("sessions", string(ui.ActionDelete), r.Session.FilePath, r.SizeSaved, nil))
+			summary.Items++
+		}
+	}
+
+	if a.machine() {
+		summary.Size = totalSaved
+		a.emit(summary)
+	}
+	a.printf("Cleaned %d sessions, freed %s\n", cleaned, ui.FormatSize(totalSaved))
+	return 0
+}
+
+// sessionFilter selects the sessions shown by list sessions. Zero values
+// disable the corresponding condition.
+type sessionFilter struct {
+	project      string // Absolute path or glob; matches projects at or below it
+	since, until time.Time
+	branch       string // Branch name or glob
+	minSize      int64  // Exclusive
+	maxSize      int64  // Exclusive
+}
+
+// sessionFilter builds the session filter from the command-line flags.
+func (a *app) sessionFilter() (sessionFilter, error) {
+	var f sessionFilter
+	var err error
+	now := time.Now()
+
+	if a.args.Project != "" {
+		if f.project, err = pins.Normalize(a.args.Project); err != nil {
+			return f, err
+		}
+	}
+	if a.args.Since != "" {
+		if f.since, err = ui.ParseTime(a.args.Since, now, false); err != nil {
+			return f, err
+		}
+	}
+	if a.args.Until != "" {
+		if f.until, err = ui.ParseTime(a.args.Until, now, true); err != nil {
+			return f, err
+		}
+	}
+	if a.args.Branch != "" {
+		if _, err := path.Match(a.args.Branch, ""); err != nil {
+			return f, fmt.Errorf("invalid --branch pattern: %s", a.args.Branch)
+		}
+		f.branch = a.args.Branch
+	}
+	if a.args.LargerThan != "" {
+		if f.minSize, err = ui.ParseSize(a.args.LargerThan); err != nil {
+			return f, err
+		}
+	}
+	if a.args.SmallerThan != "" {
+		if f.maxSize, err = ui.ParseSize(a.args.SmallerThan); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// matches reports whether a session of project p, whose size including its
+// linked data is size, passes the filter. A session matches a date range if
+// it was active at any time within it.
+func (f sessionFilter) matches(p claude.Project, s claude.SessionInfo, size int64) bool {
+	if f.project != "" && (p.ActualPath == "" || !pathglob.MatchTree(f.project, p.ActualPath)) {
+		return false
+	}
+	if !f.since.IsZero() || !f.until.IsZero() {
+		if s.Timestamp.IsZero() {
+			return false
+		}
+		if !f.since.IsZero() && s.LastActive().Before(f.since) {
+			return false
+		}
+		if !f.until.IsZero() && s.Timestamp.After(f.until) {
+			return false
+		}
+	}
+	if f.branch != "" {
+		if ok, _ := path.Match(f.branch, s.GitBranch); !ok {
+			return false
+		}
+	}
+	if f.minSize > 0 && size <= f.minSize {
+		return false
+	}
+	if f.maxSize > 0 && size >= f.maxSize {
+		return false
+	}
+	return true
+}
+
+// listSessions lists the sessions of all projects that pass the filter
+// flags, most recently active first.
+func (a *app) listSessions() int {
+	filter, err := a.sessionFilter()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+
+	type entry struct {
+		project claude.Project
+		session claude.SessionInfo
+		linked  []string
+		size    int64
+	}
+	linked := cleaner.LinkedData(a.paths)
+	var sessions []entry
+	for _, p := range inv.Projects {
+		for _, s := range p.Sessions {
+			sessionLinked := slices.Concat(linked[s.ID], p.AgentData(s.ID))
+			size := s.Size + cleaner.LinkedSize(sessionLinked)
+			if filter.matches(p, s, size) {
+				sessions = append(sessions, entry{p, s, sessionLinked, size})
+			}
+		}
+	}
+	slices.SortStableFunc(sessions, func(x, y entry) int {
+		return y.session.LastActive().Compare(x.session.LastActive())
+	})
+
+	if len(sessions) == 0 {
+		a.printf("No sessions found.\n")
+		return 0
+	}
+
+	var totalSize int64
+	a.printf("Sessions:\n")
+	for _, e := range sessions {
+		s, size := e.session, e.size
+		totalSize += size
+
+		if a.machine() {
+			a.emit(output.NewSessionInfo(e.project, s, e.linked, size))
+			continue
+		}
+
+		path := cmp.Or(e.project.ActualPath, "(unknown path)")
+		fmt.Fprintf(a.stdout, "  %s  %s\n", s.ID, path)
+		fmt.Fprintf(a.stdout, "        %s to %s, %s\n", formatTime(s.Timestamp), formatTime(s.LastActive()), ui.FormatSize(size))
+		fmt.Fprintf(a.stdout, "        %s\n", formatActivity(s.UserMessages, s.AssistantMessages, s.Usage, s.Models, branches(s.GitBranch)))
+		if s.Summary != "" {
+			fmt.Fprintf(a.stdout, "        %q\n", s.Summary)
+		}
+		if s.IsCorrupt() {
+			fmt.Fprintf(a.stdout, "        %d invalid lines\n", s.BadLines)
+		}
+	}
+
+	a.printf("\nTotal: %d sessions, %s\n", len(sessions), ui.FormatSize(totalSize))
+	return 0
+}
+
+// formatActivity describes the messages, token usage, models and branches
+// of a session or project on a single line.
+func formatActivity(user, assistant int, usage claude.TokenUsage, models, branches []string) string {
+	parts := []string{
+		fmt.Sprintf("%d messages (%d user, %d assistant)", user+assistant, user, assistant),
+		ui.FormatCount(usage.Total()) + " tokens",
+	}
+	if len(models) > 0 {
+		parts = append(parts, "models: "+strings.Join(models, ", "))
+	}
+	if len(branches) > 0 {
+		parts = append(parts, "branch: "+strings.Join(branches, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// branches returns the branch as a list, which is empty if it is unknown.
+func branches(branch string) []string {
+	if branch == "" {
+		return nil
+	}
+	return []string{branch}
+}
+
+// formatTime formats a session timestamp in local time, or "unknown".
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSessions creates an existing project with an old and a recent session.
+// The old session has a todo file. It returns the two session files and the todo.
+func setupSessions(t *testing.T, tmpDir string) (oldSession, newSession, todo string) {
+	existing := filepath.Join(tmpDir, "existing-project")
+	require.NoError(t, os.MkdirAll(existing, 0755))
+
+	projectDir := filepath.Join(tmpDir, ".claude", "projects", "-existing-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	write := func(id string, ts time.Time) string {
+		path := filepath.Join(projectDir, id+".jsonl")
+		data := `{"sessionId":"` + id + `","cwd":"` + filepath.ToSlash(existing) + `","timestamp":"` + ts.UTC().Format(time.RFC3339) + `"}`
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		return path
+	}
+	oldSession = write("old", time.Now().AddDate(0, 0, -200))
+	newSession = write("new", time.Now().AddDate(0, 0, -1))
+
+	todo = filepath.Join(tmpDir, ".claude", "todos", "old-agent-old.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(todo), 0755))
+	require.NoError(t, os.WriteFile(todo, []byte("[]"), 0644))
+
+	return oldSession, newSession, todo
+}
+
+func TestParseArgs_CleanSessions(t *testing.T) {
+	args, err := parseArgs([]string{"clean", "sessions", "--older-than", "90d", "--keep-last=20", "--max-project-size", "200MB"})
+	require.NoError(t, err)
+	assert.Equal(t, "sessions", args.Subcommand)
+	assert.Equal(t, "90d", args.OlderThan)
+	assert.Equal(t, "20", args.KeepLast)
+	assert.Equal(t, "200MB", args.MaxSize)
+}
+
+func TestRunCLI_CleanSessionsRequiresRule(t *testing.T) {
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "sessions", "--keep-last", "5"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "requires --older-than or --max-project-size")
+}
+
+func TestRunCLI_CleanSessionsDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldSession, _, todo := setupSessions(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "sessions", "--older-than", "90d", "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), oldSession)
+	assert.Contains(t, stdout.String(), "with 1 todo")
+	assert.FileExists(t, oldSession)
+	assert.FileExists(t, todo)
+}
+
+func TestRunCLI_CleanSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldSession, newSession, todo := setupSessions(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "sessions", "--older-than", "90d", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Cleaned 1 sessions")
+	assert.NoFileExists(t, oldSession)
+	assert.NoFileExists(t, todo)
+	assert.FileExists(t, newSession)
+
+	audit, err := os.ReadFile(filepath.Join(tmpDir, ".claude", "cccc-audit.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(audit), oldSession)
+	assert.Contains(t, string(audit), "linked to session old")
+
+	// Both the session and its todo can be restored
+	assert.Len(t, trashRunIDs(t, tmpDir), 1)
+}
+
+func TestRunCLI_CleanSessionsKeepLast(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldSession, _, _ := setupSessions(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "sessions", "--older-than", "90d", "--keep-last", "2", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "No sessions selected")
+	assert.FileExists(t, oldSession)
+}
+
+// setupFullSession creates an existing project with a session that has
+// messages of two models and a todo.
+func setupFullSession(t *testing.T, tmpDir string) string {
+	existing := filepath.Join(tmpDir, "webapp")
+	require.NoError(t, os.MkdirAll(existing, 0755))
+
+	projectDir := filepath.Join(tmpDir, ".claude", "projects", "-webapp")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sessions", "full.jsonl"))
+	require.NoError(t, err)
+	data = bytes.ReplaceAll(data, []byte("/Users/testuser/Code/webapp"), []byte(filepath.ToSlash(existing)))
+	session := filepath.Join(projectDir, "full1.jsonl")
+	require.NoError(t, os.WriteFile(session, data, 0644))
+
+	todo := filepath.Join(tmpDir, ".claude", "todos", "full1-agent-full1.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(todo), 0755))
+	require.NoError(t, os.WriteFile(todo, []byte("[]"), 0644))
+
+	return session
+}
+
+func TestRunCLI_ListSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupSessions(t, tmpDir)
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "sessions"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "full1  "+filepath.Join(tmpDir, "webapp"))
+	assert.Contains(t, out, "5 messages (2 user, 3 assistant), 2.1k tokens, models: claude-sonnet-4-5-20250929, claude-opus-4-1-20250805, branch: fix-upload")
+	assert.Contains(t, out, `"Fix flaky upload test"`)
+	assert.Contains(t, out, "Total: 3 sessions")
+
+	// Most recently active first
+	assert.Less(t, strings.Index(out, "new  "), strings.Index(out, "old  "))
+	assert.Less(t, strings.Index(out, "old  "), strings.Index(out, "full1  "))
+}
+
+func TestRunCLI_ListSessionsJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	session := setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "sessions", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc), stdout.String())
+	require.Len(t, doc.Records, 1)
+	r := doc.Records[0]
+	assert.Equal(t, "session", r["kind"])
+	assert.Equal(t, session, r["path"])
+	assert.Equal(t, "2025-12-01T09:00:00Z", r["started"])
+	assert.Equal(t, "2025-12-02T14:02:00Z", r["lastActive"])
+	assert.Equal(t, float64(2), r["userMessages"])
+	assert.Equal(t, float64(3), r["assistantMessages"])
+	assert.Equal(t, "2.0.62", r["version"])
+	assert.Equal(t, "fix-upload", r["gitBranch"])
+	assert.Equal(t, map[string]any{"input": float64(30), "output": float64(80), "cacheCreation": float64(1000), "cacheRead": float64(1000)}, r["tokens"])
+	assert.Len(t, r["linked"], 1)
+}
+
+func TestRunCLI_ListProjectsShowsActivity(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "projects"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "last used: 2025-12-02")
+	assert.Contains(t, stdout.String(), "5 messages (2 user, 3 assistant)")
+}
+
+func TestParseArgs_ListSessionsFilters(t *testing.T) {
+	args, err := parseArgs([]string{"list", "sessions", "--project", "~/Code/**", "--since=2025-12-01", "--until", "2025-12-02",
+		"--branch", "feature/*", "--larger-than", "1MB", "--smaller-than", "1GB"})
+	require.NoError(t, err)
+	assert.Equal(t, "sessions", args.Subcommand)
+	assert.Equal(t, "~/Code/**", args.Project)
+	assert.Equal(t, "2025-12-01", args.Since)
+	assert.Equal(t, "2025-12-02", args.Until)
+	assert.Equal(t, "feature/*", args.Branch)
+	assert.Equal(t, "1MB", args.LargerThan)
+	assert.Equal(t, "1GB", args.SmallerThan)
+}
+
+func TestRunCLI_ListSessionsFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupSessions(t, tmpDir)
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	list := func(flags ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		code := runCLI(append([]string{"list", "sessions"}, flags...), strings.NewReader(""), &stdout, &stderr)
+		require.Equal(t, 0, code, stderr.String())
+		return stdout.String()
+	}
+
+	out := list("--project", filepath.Join(tmpDir, "webapp"))
+	assert.Contains(t, out, "full1  ")
+	assert.Contains(t, out, "Total: 1 sessions")
+
+	// Active on 2025-12-02, although it started the day before
+	out = list("--since", "2025-12-02", "--until", "2025-12-02")
+	assert.Contains(t, out, "full1  ")
+	assert.Contains(t, out, "Total: 1 sessions")
+	assert.Contains(t, list("--until", "2025-11-30"), "No sessions found.")
+
+	assert.Contains(t, list("--branch", "fix-*"), "Total: 1 sessions")
+	assert.Contains(t, list("--branch", "main"), "No sessions found.")
+
+	out = list("--since", "30d")
+	assert.Contains(t, out, "new  ")
+	assert.Contains(t, out, "Total: 1 sessions")
+
+	out = list("--larger-than", "1KB")
+	assert.Contains(t, out, "full1  ")
+	assert.Contains(t, out, "Total: 1 sessions")
+	assert.Contains(t, list("--smaller-than", "1KB"), "Total: 2 sessions")
+}
+
+func TestRunCLI_ListSessionsInvalidFilter(t *testing.T) {
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "sessions", "--since", "last tuesday"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "invalid time")
+}
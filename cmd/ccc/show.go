@@ -0,0 +1,218 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// transcriptWidth is the number of characters of a message shown by show
+// session without --verbose.
+const transcriptWidth = 100
+
+// handleShow handles the "show" command and subcommands.
+func (a *app) handleShow() int {
+	switch a.args.Subcommand {
+	case "session":
+		return a.showSession()
+	default:
+		fmt.Fprintln(a.stderr, "Usage: cccc show session <id>")
+		return 1
+	}
+}
+
+// findSession returns the session whose ID is id or, failing that, the only
+// session whose ID starts with id.
+func findSession(projects []claude.Project, id string) (claude.Project, claude.SessionInfo, error) {
+	var project claude.Project
+	var session claude.SessionInfo
+	matches := 0
+	for _, p := range projects {
+		for _, s := range p.Sessions {
+			if s.ID == id {
+				return p, s, nil
+			}
+			if strings.HasPrefix(s.ID, id) {
+				project, session = p, s
+				matches++
+			}
+		}
+	}
+
+	switch matches {
+	case 0:
+		return project, session, fmt.Errorf("no session %s", id)
+	case 1:
+		return project, session, nil
+	default:
+		return project, session, fmt.Errorf("session ID %s is ambiguous, %d sessions start with it", id, matches)
+	}
+}
+
+// showSession shows the metadata, transcript and linked data of a session.
+func (a *app) showSession() int {
+	if len(a.args.Targets) != 1 {
+		fmt.Fprintln(a.stderr, "Usage: cccc show session <id>")
+		return 1
+	}
+
+	inv, err := a.inventory()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error scanning projects:", err)
+		return 1
+	}
+	p, s, err := findSession(inv.Projects, a.args.Targets[0])
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	messages, err := claude.ReadTranscript(s.FilePath)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error reading transcript:", err)
+		return 1
+	}
+
+	linked := slices.Concat(cleaner.LinkedData(a.paths)[s.ID], p.AgentData(s.ID))
+	size := s.Size + cleaner.LinkedSize(linked)
+
+	if a.machine() {
+		a.emit(output.NewSessionInfo(p, s, linked, size))
+		for _, m := range messages {
+			a.emit(output.NewMessage(m))
+		}
+		for _, path := range linked {
+			if filepath.Dir(path) != a.paths.Todos {
+				continue
+			}
+			todos, err := claude.LoadTodos(path)
+			if err != nil {
+				fmt.Fprintln(a.stderr, "Warning:", err)
+				continue
+			}
+			for _, t := range todos {
+				a.emit(output.NewTodo(path, t))
+			}
+		}
+		return 0
+	}
+
+	w := a.stdout
+	fmt.Fprintf(w, "Session %s\n", s.ID)
+	fmt.Fprintf(w, "  Project:     %s\n", cmp.Or(p.ActualPath, "(unknown path)"))
+	fmt.Fprintf(w, "  File:        %s (%s)\n", s.FilePath, ui.FormatSize(s.Size))
+	fmt.Fprintf(w, "  Started:     %s\n", formatTime(s.Timestamp))
+	fmt.Fprintf(w, "  Last active: %s\n", formatTime(s.LastActive()))
+	fmt.Fprintf(w, "  Activity:    %s\n", formatActivity(s.UserMessages, s.AssistantMessages, s.Usage, s.Models, branches(s.GitBranch)))
+	if s.Version != "" {
+		fmt.Fprintf(w, "  Version:     %s\n", s.Version)
+	}
+	if s.Summary != "" {
+		fmt.Fprintf(w, "  Summary:     %s\n", s.Summary)
+	}
+	if s.IsCorrupt() {
+		fmt.Fprintf(w, "  Invalid:     %d lines\n", s.BadLines)
+	}
+
+	fmt.Fprintln(w, "\nTranscript:")
+	if len(messages) == 0 {
+		fmt.Fprintln(w, "  (no messages)")
+	}
+	for _, m := range messages {
+		text := m.Text
+		if !a.args.Verbose {
+			text = firstLine(text, transcriptWidth)
+		}
+		lines := strings.Split(text, "\n")
+		fmt.Fprintf(w, "  %-16s  %-9s  %s\n", formatTime(m.Timestamp), m.Role, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "  %-16s  %-9s  %s\n", "", "", line)
+		}
+		for _, tool := range m.Tools {
+			if !a.args.Verbose {
+				tool = firstLine(tool, transcriptWidth)
+			}
+			fmt.Fprintf(w, "  %-16s  %-9s  > %s\n", "", "", tool)
+		}
+	}
+
+	for _, path := range linked {
+		a.showLinked(path)
+	}
+	return 0
+}
+
+// showLinked describes a todo file, file-history or session-env directory,
+// or the agent transcripts of a session.
+func (a *app) showLinked(path string) {
+	w := a.stdout
+	switch filepath.Dir(path) {
+	case a.paths.Todos:
+		fmt.Fprintf(w, "\nTodos (%s):\n", path)
+		todos, err := claude.LoadTodos(path)
+		if err != nil {
+			fmt.Fprintf(w, "  %v\n", err)
+			return
+		}
+		if len(todos) == 0 {
+			fmt.Fprintln(w, "  (empty)")
+		}
+		for _, t := range todos {
+			fmt.Fprintf(w, "  [%s] %s\n", t.Status, t.Content)
+		}
+	case a.paths.FileHistory:
+		files, snapshots, size := dirStats(path)
+		fmt.Fprintf(w, "\nFile history (%s):\n  %d snapshots of %d files, %s\n", path, snapshots, files, ui.FormatSize(size))
+	case a.paths.SessionEnv:
+		_, count, size := dirStats(path)
+		fmt.Fprintf(w, "\nSession env (%s):\n  %d files, %s\n", path, count, ui.FormatSize(size))
+	default:
+		if filepath.Dir(filepath.Dir(path)) == a.paths.Projects {
+			_, count, size := dirStats(path)
+			fmt.Fprintf(w, "\nAgents (%s):\n  %d files, %s\n", path, count, ui.FormatSize(size))
+		}
+	}
+}
+
+// dirStats returns the number of distinct names, the number of files and the
+// total size of a directory. Names are compared without an "@v<version>"
+// suffix, so that for file-history directories, whose snapshots are named
+// <file hash>@v<version>, they count the files that have snapshots.
+func dirStats(dir string) (names, files int, size int64) {
+	seen := make(map[string]bool)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files++
+		size += info.Size()
+		name, _, _ := strings.Cut(d.Name(), "@")
+		seen[name] = true
+		return nil
+	})
+	return len(seen), files, size
+}
+
+// firstLine returns the first line of s, shortened to width characters.
+func firstLine(s string, width int) string {
+	line, rest, more := strings.Cut(s, "\n")
+	if r := []rune(line); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	if more && strings.TrimSpace(rest) != "" {
+		return line + " …"
+	}
+	return line
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs_ShowSession(t *testing.T) {
+	args, err := parseArgs([]string{"show", "session", "abc123"})
+	require.NoError(t, err)
+	assert.Equal(t, "show", args.Command)
+	assert.Equal(t, "session", args.Subcommand)
+	assert.Equal(t, []string{"abc123"}, args.Targets)
+
+	// show is still the subcommand of config
+	args, err = parseArgs([]string{"config", "show"})
+	require.NoError(t, err)
+	assert.Equal(t, "config", args.Command)
+	assert.Equal(t, "show", args.Subcommand)
+}
+
+func TestRunCLI_ShowSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	session := setupFullSession(t, tmpDir)
+	history := filepath.Join(tmpDir, ".claude", "file-history", "full1")
+	require.NoError(t, os.MkdirAll(history, 0755))
+	for _, name := range []string{"aaa@v1", "aaa@v2", "bbb@v1"} {
+		require.NoError(t, os.WriteFile(filepath.Join(history, name), []byte("x"), 0644))
+	}
+	todo := filepath.Join(tmpDir, ".claude", "todos", "full1-agent-full1.json")
+	require.NoError(t, os.WriteFile(todo, []byte(`[{"content":"Use a temp dir","status":"completed"}]`), 0644))
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"show", "session", "full"}, strings.NewReader(""), &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	out := stdout.String()
+	assert.Contains(t, out, "Session full1")
+	assert.Contains(t, out, "File:        "+session)
+	assert.Contains(t, out, "Summary:     Fix flaky upload test")
+	assert.Contains(t, out, "user       The upload test fails randomly, can you look?")
+	assert.Contains(t, out, "> Read ")
+	assert.Contains(t, out, "[completed] Use a temp dir")
+	assert.Contains(t, out, "3 snapshots of 2 files")
+}
+
+func TestRunCLI_ShowSessionNDJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupFullSession(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"show", "session", "full1", "--output", "ndjson"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	kinds := map[string]int{}
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		kinds[record["kind"].(string)]++
+	}
+	assert.Equal(t, map[string]int{"header": 1, "session": 1, "message": 5}, kinds)
+}
+
+func TestRunCLI_ShowSessionErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupSessions(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"show", "session", "missing"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "no session missing")
+
+	stderr.Reset()
+	code = runCLI([]string{"show", "session"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Usage: cccc show session <id>")
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// findStateChanges loads the state file and selects the entries to prune or
+// trim, along with the kept entries of projects protected by the policy or a pin.
+// Errors are reported on stderr.
+func (a *app) findStateChanges() (*cleaner.StateResult, []ui.Change, bool) {
+	keepHistory := -1
+	if a.args.KeepHistory != "" {
+		n, err := strconv.Atoi(a.args.KeepHistory)
+		if err != nil || n < 0 {
+			fmt.Fprintf(a.stderr, "Error: invalid --keep-history value: %s\n", a.args.KeepHistory)
+			return nil, nil, false
+		}
+		keepHistory = n
+	}
+
+	state, err := claude.LoadState(a.paths.State)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error loading state file:", err)
+		return nil, nil, false
+	}
+
+	protected := cleaner.ProtectStateEntries(state, a.protect)
+	return cleaner.FindStaleStateEntries(state, keepHistory), protected, true
+}
+
+// stateRecords returns the machine-readable records of a state result.
+func stateRecords(result *cleaner.StateResult, includeKept bool) []any {
+	var records []any
+	for _, p := range result.Stale {
+		records = append(records, output.NewStateEntry(p, output.StatusStale))
+	}
+	for _, p := range result.Trim {
+		records = append(records, output.NewStateEntry(p, output.StatusTrim))
+	}
+	if includeKept {
+		for _, p := range result.Kept {
+			records = append(records, output.NewStateEntry(p, unavailableStatus(result.Unavailable[p.Path])))
+		}
+	}
+	return records
+}
+
+// unavailableStatus returns the record status of a kept entry; entries that
+// are not unavailable are ok.
+func unavailableStatus(u cleaner.Unavailability) string {
+	switch u.Status {
+	case "":
+		return output.StatusOK
+	case mounts.StatusPermissionDenied:
+		return output.StatusDenied
+	default:
+		return output.StatusUnmounted
+	}
+}
+
+// cleanState prunes entries of missing projects from the global state file
+// and trims prompt histories.
+func (a *app) cleanState(q cleaner.Quarantine) int {
+	result, protected, ok := a.findStateChanges()
+	if !ok {
+		return 1
+	}
+
+	if result.IsEmpty() {
+		a.printf("No stale state entries found.\n")
+		return 0
+	}
+
+	preview := cleaner.BuildStatePreview(result)
+	preview.Kept = append(preview.Kept, protected...)
+
+	if a.args.DryRun {
+		a.showDryRun(preview, "state", stateRecords(result, false))
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "state")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	// All changes are applied in a single rewrite, so they succeed or fail together
+	err = cleaner.ApplyStateClean(result, q, false)
+	if err != nil {
+		fmt.Fprintf(a.stderr, "Error cleaning %s: %v\n", result.Path, err)
+	} else if auditLogger != nil {
+		for _, details := range result.FormatAuditDetails() {
+			_ = auditLogger.LogWithDetails(ui.ActionModify, result.Path, details)
+		}
+	}
+
+	if a.machine() {
+		summary := output.NewSummary("state")
+		for _, p := range result.Stale {
+			a.emit(output.NewResult("state", string(ui.ActionDelete), p.Path, p.Size, err))
+		}
+		for _, p := range result.Trim {
+			a.emit(output.NewResult("state", string(ui.ActionModify), p.Path, 0, err))
+		}
+		if err != nil {
+			summary.Errors = len(result.Stale) + len(result.Trim)
+		} else {
+			summary.Items = len(result.Stale) + len(result.Trim)
+			summary.Size = preview.TotalSize()
+		}
+		a.emit(summary)
+	}
+	if err != nil {
+		return 1
+	}
+
+	a.printf("Removed %d state entries and trimmed %d histories in %s\n", len(result.Stale), len(result.Trim), result.Path)
+	return 0
+}
+
+// listState lists the project entries of the global state file.
+func (a *app) listState() int {
+	result, protected, ok := a.findStateChanges()
+	if !ok {
+		return 1
+	}
+
+	if a.machine() {
+		for _, r := range stateRecords(result, true) {
+			a.emit(r)
+		}
+		return 0
+	}
+
+	total := len(result.Stale) + len(result.Trim) + len(result.Kept) + len(protected)
+	if total == 0 {
+		fmt.Fprintf(a.stdout, "No project entries found in %s.\n", result.Path)
+		return 0
+	}
+
+	preview := cleaner.BuildStatePreview(result)
+	preview.Kept = append(preview.Kept, protected...)
+	_ = preview.Display(a.stdout)
+
+	return 0
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupStateFile writes ~/.claude.json below tmpDir with one missing project
+// and one existing project holding two history entries.
+func setupStateFile(t *testing.T, tmpDir string) string {
+	existing := filepath.ToSlash(filepath.Join(tmpDir, "existing"))
+	require.NoError(t, os.MkdirAll(existing, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".claude"), 0755))
+
+	content := `{
+  "projects": {
+    "/this/path/does/not/exist": {"history": [{"display": "x"}]},
+    "` + existing + `": {"history": [{"display": "a"}, {"display": "b"}]}
+  },
+  "userID": "abc"
+}
+`
+	statePath := filepath.Join(tmpDir, ".claude.json")
+	require.NoError(t, os.WriteFile(statePath, []byte(content), 0600))
+	return statePath
+}
+
+func TestParseArgs_CleanStateKeepHistory(t *testing.T) {
+	args, err := parseArgs([]string{"clean", "state", "--keep-history", "50"})
+	require.NoError(t, err)
+	assert.Equal(t, "clean", args.Command)
+	assert.Equal(t, "state", args.Subcommand)
+	assert.Equal(t, "50", args.KeepHistory)
+}
+
+func TestRunCLI_ListState(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStateFile(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"list", "state", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	var doc jsonOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Records, 2)
+	assert.Equal(t, "stateEntry", doc.Records[0]["kind"])
+	assert.Equal(t, "stale", doc.Records[0]["status"])
+	assert.Equal(t, "ok", doc.Records[1]["status"])
+}
+
+func TestRunCLI_CleanStateDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := setupStateFile(t, tmpDir)
+	before, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "state", "--dry-run", "--keep-history", "1"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "/this/path/does/not/exist")
+	assert.Contains(t, stdout.String(), "trim history from 2 to 1 entries")
+
+	after, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestRunCLI_CleanStateAndRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := setupStateFile(t, tmpDir)
+	before, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "state", "--yes", "--keep-history", "1"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Removed 1 state entries and trimmed 1 histories")
+
+	data, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "/this/path/does/not/exist")
+	assert.NotContains(t, string(data), `"display": "b"`)
+	assert.Contains(t, string(data), `"userID": "abc"`)
+
+	audit, err := os.ReadFile(filepath.Join(tmpDir, ".claude", "cccc-audit.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(audit), `removed projects["/this/path/does/not/exist"]`)
+
+	ids := trashRunIDs(t, tmpDir)
+	require.Len(t, ids, 1)
+
+	stdout.Reset()
+	code = runCLI([]string{"restore", ids[0], "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	after, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestRunCLI_CleanStateInvalidKeepHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStateFile(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "state", "--keep-history", "-3"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "invalid --keep-history")
+}
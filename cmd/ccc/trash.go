@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/trash"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// handleRestore handles the "restore" command.
+func (a *app) handleRestore() int {
+	if len(a.args.Targets) == 0 {
+		fmt.Fprintln(a.stderr, "Usage: cccc restore <run-id> [item...]")
+		return 1
+	}
+
+	store := trash.NewStore(trash.DefaultDir(a.paths.Root))
+	runID := a.args.Targets[0]
+
+	manifest, err := store.Load(runID)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	ids, err := selectTrashItems(manifest, a.args.Targets[1:])
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+
+	preview := trash.BuildRestorePreview(manifest, ids)
+	if len(preview.Changes) == 0 {
+		a.printf("Nothing to restore in run %s.\n", runID)
+		return 0
+	}
+
+	if a.args.DryRun {
+		a.showDryRun(preview, "restore", nil)
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "restore")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	restored, err := store.Restore(runID, ids)
+	summary := output.NewSummary("restore")
+	for _, item := range restored {
+		if auditLogger != nil {
+			_ = auditLogger.Log(ui.ActionRestore, item.Path, item.Size)
+		}
+		if a.machine() {
+			a.emit(output.NewResult("restore", string(ui.ActionRestore), item.Path, item.Size, nil))
+			summary.Items++
+			summary.Size += item.Size
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error restoring:", err)
+		if a.machine() {
+			summary.Errors++
+			a.emit(summary)
+		}
+		return 1
+	}
+
+	if a.machine() {
+		a.emit(summary)
+	}
+	a.printf("Restored %d items from trash run %s\n", len(restored), runID)
+	return 0
+}
+
+// selectTrashItems resolves restore targets, given as item numbers or original
+// paths, to item IDs. No targets selects every item.
+func selectTrashItems(m *trash.Manifest, targets []string) ([]int, error) {
+	var ids []int
+	for _, target := range targets {
+		if id, err := strconv.Atoi(target); err == nil {
+			ids = append(ids, id)
+			continue
+		}
+
+		abs, err := filepath.Abs(target)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, item := range m.Items {
+			if item.Path == abs {
+				ids = append(ids, item.ID)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("run %s has no item for %s", m.RunID, target)
+		}
+	}
+	return ids, nil
+}
+
+// handleTrash handles the "trash" command and subcommands.
+func (a *app) handleTrash() int {
+	store := trash.NewStore(trash.DefaultDir(a.paths.Root))
+
+	switch a.args.Subcommand {
+	case "list", "":
+		return a.listTrash(store)
+	case "purge":
+		return a.purgeTrash(store)
+	default:
+		fmt.Fprintf(a.stderr, "Unknown trash subcommand: %s\n", a.args.Subcommand)
+		return 1
+	}
+}
+
+// listTrash lists the runs held in the trash.
+func (a *app) listTrash(store *trash.Store) int {
+	manifests, err := store.List()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error reading trash:", err)
+		return 1
+	}
+
+	if a.machine() {
+		for _, m := range manifests {
+			a.emit(output.NewTrashRun(m))
+		}
+		return 0
+	}
+
+	if len(manifests) == 0 {
+		fmt.Fprintln(a.stdout, "Trash is empty.")
+		return 0
+	}
+
+	var total int64
+	fmt.Fprintln(a.stdout, "Trash:")
+	for _, m := range manifests {
+		total += m.TotalSize()
+		fmt.Fprintf(a.stdout, "  [%s] %s\n", m.RunID, m.Command)
+		fmt.Fprintf(a.stdout, "        %d items, %s, created: %s\n",
+			len(m.Pending()), ui.FormatSize(m.TotalSize()), m.Created.Local().Format("2006-01-02 15:04"))
+
+		if a.args.Verbose {
+			for _, item := range m.Pending() {
+				fmt.Fprintf(a.stdout, "        #%d %s %s (%s)\n", item.ID, item.Kind, item.Path, ui.FormatSize(item.Size))
+			}
+		}
+	}
+
+	fmt.Fprintf(a.stdout, "\nTotal: %d runs, %s\n", len(manifests), ui.FormatSize(total))
+	return 0
+}
+
+// purgeTrash permanently deletes trash runs older than --older-than.
+func (a *app) purgeTrash(store *trash.Store) int {
+	if a.args.OlderThan == "" {
+		fmt.Fprintln(a.stderr, "Error: trash purge requires --older-than (e.g. --older-than 30d)")
+		return 1
+	}
+
+	age, err := ui.ParseAge(a.args.OlderThan)
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	cutoff := time.Now().Add(-age)
+
+	manifests, err := store.List()
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error reading trash:", err)
+		return 1
+	}
+
+	var old []trash.Manifest
+	for _, m := range manifests {
+		if m.Created.Before(cutoff) {
+			old = append(old, m)
+		}
+	}
+
+	if len(old) == 0 {
+		a.printf("No trash runs older than %s.\n", a.args.OlderThan)
+		return 0
+	}
+
+	preview := trash.BuildPurgePreview(store, old)
+
+	if a.args.DryRun {
+		var records []any
+		for _, m := range old {
+			records = append(records, output.NewTrashRun(m))
+		}
+		a.showDryRun(preview, "trash", records)
+		return 0
+	}
+
+	confirmed, err := a.confirm(preview, "trash")
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error:", err)
+		return 1
+	}
+	if !confirmed {
+		return 0
+	}
+
+	auditLogger := a.openAuditLog()
+	if auditLogger != nil {
+		defer auditLogger.Close()
+	}
+
+	purged, err := store.Purge(cutoff)
+	summary := output.NewSummary("trash")
+	var totalSize int64
+	for _, m := range purged {
+		totalSize += m.TotalSize()
+		runDir := filepath.Join(store.Dir, m.RunID)
+		if auditLogger != nil {
+			_ = auditLogger.LogWithDetails(ui.ActionDelete, runDir,
+				fmt.Sprintf("purged trash run (%s, %d items, %s)", m.Command, len(m.Pending()), ui.FormatSize(m.TotalSize())))
+		}
+		if a.machine() {
+			a.emit(output.NewResult("trash", string(ui.ActionDelete), runDir, m.TotalSize(), nil))
+			summary.Items++
+		}
+	}
+	summary.Size = totalSize
+	if err != nil {
+		fmt.Fprintln(a.stderr, "Error purging trash:", err)
+		if a.machine() {
+			summary.Errors++
+			a.emit(summary)
+		}
+		return 1
+	}
+
+	if a.machine() {
+		a.emit(summary)
+	}
+	a.printf("Purged %d trash runs, freed %s\n", len(purged), ui.FormatSize(totalSize))
+	return 0
+}
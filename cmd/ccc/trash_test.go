@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupStaleProject creates a single stale project below tmpDir/.claude and
+// returns its session data directory.
+func setupStaleProject(t *testing.T, tmpDir string) string {
+	projectDir := filepath.Join(tmpDir, ".claude", "projects", "-nonexistent-path")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	nonexistentPath := filepath.Join(tmpDir, "this-path-does-not-exist-anywhere")
+	sessionData := `{"sessionId":"sess1","cwd":"` + filepath.ToSlash(nonexistentPath) + `","timestamp":"2025-01-01T00:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "session.jsonl"), []byte(sessionData), 0644))
+	return projectDir
+}
+
+// trashRunIDs returns the IDs of the runs in the trash below tmpDir/.claude.
+func trashRunIDs(t *testing.T, tmpDir string) []string {
+	entries, err := os.ReadDir(filepath.Join(tmpDir, ".claude", "cccc-trash"))
+	require.NoError(t, err)
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.Name())
+	}
+	return ids
+}
+
+func TestParseArgs_Restore(t *testing.T) {
+	args, err := parseArgs([]string{"restore", "20251206T160000Z", "2", "/some/path"})
+	require.NoError(t, err)
+	assert.Equal(t, "restore", args.Command)
+	assert.Equal(t, []string{"20251206T160000Z", "2", "/some/path"}, args.Targets)
+}
+
+func TestParseArgs_TrashPurgeOlderThan(t *testing.T) {
+	for _, argv := range [][]string{
+		{"trash", "purge", "--older-than", "30d"},
+		{"trash", "purge", "--older-than=30d"},
+	} {
+		args, err := parseArgs(argv)
+		require.NoError(t, err)
+		assert.Equal(t, "trash", args.Command)
+		assert.Equal(t, "purge", args.Subcommand)
+		assert.Equal(t, "30d", args.OlderThan)
+	}
+}
+
+func TestParseArgs_TrashList(t *testing.T) {
+	args, err := parseArgs([]string{"trash", "list"})
+	require.NoError(t, err)
+	assert.Equal(t, "trash", args.Command)
+	assert.Equal(t, "list", args.Subcommand)
+}
+
+func TestParseArgs_MissingFlagValue(t *testing.T) {
+	_, err := parseArgs([]string{"trash", "purge", "--older-than"})
+	assert.Error(t, err)
+}
+
+func TestParseArgs_BooleanFlagWithValue(t *testing.T) {
+	_, err := parseArgs([]string{"clean", "--dry-run=true"})
+	assert.Error(t, err)
+}
+
+func TestRunCLI_CleanThenRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+	before, err := os.ReadFile(filepath.Join(projectDir, "session.jsonl"))
+	require.NoError(t, err)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"clean", "projects", "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.NoDirExists(t, projectDir)
+	assert.Contains(t, stdout.String(), "cccc restore")
+
+	ids := trashRunIDs(t, tmpDir)
+	require.Len(t, ids, 1)
+
+	stdout.Reset()
+	code = runCLI([]string{"restore", ids[0], "--yes"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Restored 1 items")
+
+	after, err := os.ReadFile(filepath.Join(projectDir, "session.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	auditLog, err := os.ReadFile(filepath.Join(tmpDir, ".claude", "cccc-audit.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(auditLog), "RESTORE")
+}
+
+func TestRunCLI_RestoreDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := setupStaleProject(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, runCLI([]string{"clean", "projects", "-y"}, strings.NewReader(""), &stdout, &stderr))
+	ids := trashRunIDs(t, tmpDir)
+	require.Len(t, ids, 1)
+
+	stdout.Reset()
+	code := runCLI([]string{"restore", ids[0], "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "[DRY RUN]")
+	assert.Contains(t, stdout.String(), "RESTORE")
+	assert.NoDirExists(t, projectDir)
+}
+
+func TestRunCLI_RestoreRequiresRunID(t *testing.T) {
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"restore"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Usage")
+}
+
+func TestRunCLI_RestoreUnknownRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"restore", "nope"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "not found")
+}
+
+func TestRunCLI_TrashList(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStaleProject(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"trash", "list"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "Trash is empty")
+
+	require.Equal(t, 0, runCLI([]string{"clean", "projects", "-y"}, strings.NewReader(""), &stdout, &stderr))
+
+	stdout.Reset()
+	code = runCLI([]string{"trash", "list", "--verbose"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "clean projects")
+	assert.Contains(t, stdout.String(), "-nonexistent-path")
+}
+
+func TestRunCLI_TrashPurge(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupStaleProject(t, tmpDir)
+
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, runCLI([]string{"clean", "projects", "-y"}, strings.NewReader(""), &stdout, &stderr))
+	require.Len(t, trashRunIDs(t, tmpDir), 1)
+
+	// The run is too recent for a 30 day threshold
+	stdout.Reset()
+	code := runCLI([]string{"trash", "purge", "--older-than", "30d", "-y"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "No trash runs older than")
+	assert.Len(t, trashRunIDs(t, tmpDir), 1)
+
+	stdout.Reset()
+	code = runCLI([]string{"trash", "purge", "--older-than=0d", "-y"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), "Purged 1 trash runs")
+	assert.Empty(t, trashRunIDs(t, tmpDir))
+}
+
+func TestRunCLI_TrashPurgeRequiresOlderThan(t *testing.T) {
+	tmpDir := t.TempDir()
+	cleanup := setTestHome(t, tmpDir)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"trash", "purge"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "--older-than")
+}
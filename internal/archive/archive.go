@@ -0,0 +1,333 @@
+// Package archive writes and reads session bundles: compressed tar files
+// holding session transcripts and their linked data, with a manifest of
+// checksums, so that they can be kept for compliance and imported again.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// manifestName is the name of the manifest inside a bundle. It is written
+// after all files, when their checksums are known.
+const manifestName = "manifest.json"
+
+// manifestVersion is the version of the bundle format.
+const manifestVersion = 1
+
+// Directories below the Claude home that a bundle may hold.
+var allowedDirs = map[string]bool{
+	"projects":     true,
+	"todos":        true,
+	"file-history": true,
+	"session-env":  true,
+}
+
+// Entry describes a file held in a bundle.
+type Entry struct {
+	Path    string    `json:"path"` // Slash-separated, relative to the Claude home
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	Version int       `json:"version"`
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+	Files   []Entry   `json:"files"`
+}
+
+// TotalSize returns the total size of all files in the bundle.
+func (m *Manifest) TotalSize() int64 {
+	var total int64
+	for _, e := range m.Files {
+		total += e.Size
+	}
+	return total
+}
+
+// compression returns the compression of a bundle from its file name.
+func compression(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return "zstd", nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "gzip", nil
+	default:
+		return "", fmt.Errorf("unsupported bundle %s: use a .tar.zst or .tar.gz file name", name)
+	}
+}
+
+// Writer adds files below a Claude home directory to a new bundle. Nothing
+// is written until the first file is added, and the bundle only appears at
+// its path once it is closed.
+type Writer struct {
+	path     string
+	root     string
+	format   string
+	manifest Manifest
+	added    map[string]bool
+
+	file *os.File
+	zw   io.WriteCloser
+	tw   *tar.Writer
+}
+
+// Create returns a writer for a new bundle at path, holding files below the
+// Claude home root. command is recorded in the manifest.
+func Create(path, root, command string) (*Writer, error) {
+	format, err := compression(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists", path)
+	}
+
+	return &Writer{
+		path:   path,
+		root:   root,
+		format: format,
+		manifest: Manifest{
+			Version: manifestVersion,
+			Command: command,
+			Created: time.Now().UTC(),
+			Files:   []Entry{},
+		},
+		added: make(map[string]bool),
+	}, nil
+}
+
+// Path returns the path of the bundle.
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Manifest returns the manifest of the files added so far.
+func (w *Writer) Manifest() Manifest {
+	return w.manifest
+}
+
+// Contains reports whether path is below the Claude home, in a directory
+// that bundles may hold.
+func (w *Writer) Contains(path string) bool {
+	_, err := w.relative(path)
+	return err == nil
+}
+
+// relative returns the slash-separated path of path relative to the Claude home.
+func (w *Writer) relative(path string) (string, error) {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if err := validPath(rel); err != nil {
+		return "", fmt.Errorf("%s is not session data below %s", path, w.root)
+	}
+	return rel, nil
+}
+
+// Add adds a file, or a directory with everything below it, to the bundle.
+// Files that were added before are skipped.
+func (w *Writer) Add(path string) error {
+	if _, err := w.relative(path); err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := w.relative(p)
+		if err != nil {
+			return err
+		}
+		if w.added[rel] {
+			return nil
+		}
+		if err := w.addFile(p, rel); err != nil {
+			return fmt.Errorf("archiving %s: %w", p, err)
+		}
+		w.added[rel] = true
+		return nil
+	})
+}
+
+// addFile writes a regular file to the bundle and records its checksum.
+func (w *Writer) addFile(path, rel string) error {
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	cleanPath := filepath.Clean(path)
+	f, err := os.Open(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     rel,
+		Size:     info.Size(),
+		Mode:     0600,
+		ModTime:  info.ModTime(),
+		Format:   tar.FormatPAX,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	// Files are only archived up to the size they had when they were opened
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w.tw, hash), io.LimitReader(f, info.Size()))
+	if err != nil {
+		return err
+	}
+	if n != info.Size() {
+		return errors.New("file shrank while it was archived")
+	}
+
+	w.manifest.Files = append(w.manifest.Files, Entry{
+		Path:    rel,
+		Size:    info.Size(),
+		SHA256:  hex.EncodeToString(hash.Sum(nil)),
+		ModTime: info.ModTime().UTC(),
+	})
+	return nil
+}
+
+// open creates the temporary bundle file on first use.
+func (w *Writer) open() error {
+	if w.tw != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.path), 0700); err != nil {
+		return err
+	}
+	cleanPath := filepath.Clean(w.path + ".tmp")
+	f, err := os.OpenFile(cleanPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return err
+	}
+
+	var zw io.WriteCloser
+	switch w.format {
+	case "zstd":
+		zw, err = zstd.NewWriter(f)
+	default:
+		zw = gzip.NewWriter(f)
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	w.file, w.zw, w.tw = f, zw, tar.NewWriter(zw)
+	return nil
+}
+
+// Close writes the manifest and moves the bundle into place. If no file was
+// added, nothing is written.
+func (w *Writer) Close() error {
+	if w.tw == nil {
+		return nil
+	}
+
+	err := w.writeManifest()
+	if cerr := w.tw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := w.zw.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	w.tw = nil
+
+	tmp := w.file.Name()
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, w.path)
+}
+
+// Abort discards the bundle.
+func (w *Writer) Abort() {
+	if w.tw == nil {
+		return
+	}
+	_ = w.zw.Close()
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+	w.tw = nil
+}
+
+// writeManifest writes the manifest as the last entry of the bundle.
+func (w *Writer) writeManifest() error {
+	data, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     manifestName,
+		Size:     int64(len(data)),
+		Mode:     0600,
+		ModTime:  w.manifest.Created,
+		Format:   tar.FormatPAX,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = w.tw.Write(data)
+	return err
+}
+
+// validPath checks that a path read from a bundle stays below one of the
+// directories a bundle may hold.
+func validPath(rel string) error {
+	if rel == "" || path.IsAbs(rel) || strings.Contains(rel, `\`) {
+		return fmt.Errorf("invalid path %q", rel)
+	}
+	clean := path.Clean(rel)
+	if clean != rel || clean == "." || strings.HasPrefix(clean, "../") || clean == ".." {
+		return fmt.Errorf("invalid path %q", rel)
+	}
+	top, _, _ := strings.Cut(clean, "/")
+	if !allowedDirs[top] || top == clean {
+		return fmt.Errorf("invalid path %q", rel)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile creates a file below root with the given content.
+func writeFile(t *testing.T, root, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// sessionTree creates a session with a todo and file-history below root.
+func sessionTree(t *testing.T, root string) {
+	writeFile(t, root, "projects/-p/s1.jsonl", `{"sessionId":"s1","cwd":"/p"}`+"\n")
+	writeFile(t, root, "todos/s1-agent-s1.json", "[]")
+	writeFile(t, root, "file-history/s1/abc@v1", "version 1")
+	writeFile(t, root, "file-history/s1/abc@v2", "version 2")
+}
+
+func TestWriterAndImport(t *testing.T) {
+	for _, name := range []string{"bundle.tar.zst", "bundle.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			src := t.TempDir()
+			sessionTree(t, src)
+			bundle := filepath.Join(t.TempDir(), name)
+
+			w, err := Create(bundle, src, "archive s1")
+			require.NoError(t, err)
+			require.NoError(t, w.Add(filepath.Join(src, "projects", "-p")))
+			require.NoError(t, w.Add(filepath.Join(src, "todos", "s1-agent-s1.json")))
+			require.NoError(t, w.Add(filepath.Join(src, "file-history", "s1")))
+			// Files are only added once
+			require.NoError(t, w.Add(filepath.Join(src, "projects", "-p", "s1.jsonl")))
+			assert.NoFileExists(t, bundle, "the bundle appears when it is closed")
+			require.NoError(t, w.Close())
+
+			manifest, err := Verify(bundle)
+			require.NoError(t, err)
+			assert.Equal(t, "archive s1", manifest.Command)
+			assert.Len(t, manifest.Files, 4)
+
+			dst := t.TempDir()
+			result, err := Import(bundle, dst, false)
+			require.NoError(t, err)
+			assert.Len(t, result.Restored, 4)
+
+			data, err := os.ReadFile(filepath.Join(dst, "file-history", "s1", "abc@v2"))
+			require.NoError(t, err)
+			assert.Equal(t, "version 2", string(data))
+
+			// Importing again finds everything in place
+			result, err = Import(bundle, dst, false)
+			require.NoError(t, err)
+			assert.Empty(t, result.Restored)
+			assert.Len(t, result.Identical, 4)
+		})
+	}
+}
+
+func TestImport_KeepsConflictingFiles(t *testing.T) {
+	src := t.TempDir()
+	sessionTree(t, src)
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.zst")
+	w, err := Create(bundle, src, "archive")
+	require.NoError(t, err)
+	require.NoError(t, w.Add(filepath.Join(src, "todos", "s1-agent-s1.json")))
+	require.NoError(t, w.Close())
+
+	dst := t.TempDir()
+	todo := writeFile(t, dst, "todos/s1-agent-s1.json", `[{"content":"newer"}]`)
+
+	result, err := Import(bundle, dst, false)
+	require.NoError(t, err)
+	require.Len(t, result.Conflicts, 1)
+	assert.Empty(t, result.Restored)
+
+	data, err := os.ReadFile(todo)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"content":"newer"}]`, string(data))
+}
+
+func TestImport_DryRun(t *testing.T) {
+	src := t.TempDir()
+	sessionTree(t, src)
+	bundle := filepath.Join(t.TempDir(), "bundle.tgz")
+	w, err := Create(bundle, src, "archive")
+	require.NoError(t, err)
+	require.NoError(t, w.Add(filepath.Join(src, "projects", "-p")))
+	require.NoError(t, w.Close())
+
+	dst := t.TempDir()
+	result, err := Import(bundle, dst, true)
+	require.NoError(t, err)
+	assert.Len(t, result.Restored, 1)
+	assert.NoDirExists(t, filepath.Join(dst, "projects"))
+}
+
+func TestCreate(t *testing.T) {
+	root := t.TempDir()
+
+	_, err := Create(filepath.Join(root, "bundle.zip"), root, "archive")
+	assert.ErrorContains(t, err, "use a .tar.zst or .tar.gz")
+
+	existing := writeFile(t, root, "existing.tar.gz", "")
+	_, err = Create(existing, root, "archive")
+	assert.ErrorContains(t, err, "already exists")
+
+	// Nothing is written without files
+	empty := filepath.Join(root, "empty.tar.zst")
+	w, err := Create(empty, root, "archive")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.NoFileExists(t, empty)
+
+	// Only session data below the Claude home can be added
+	w, err = Create(empty, root, "archive")
+	require.NoError(t, err)
+	assert.Error(t, w.Add(filepath.Join(root, "settings.json")))
+	assert.Error(t, w.Add(filepath.Join(t.TempDir(), "projects", "x")))
+	assert.False(t, w.Contains(filepath.Join(root, "..", "outside")))
+	assert.True(t, w.Contains(filepath.Join(root, "todos", "x.json")))
+}
+
+func TestVerify_RejectsTamperedBundles(t *testing.T) {
+	src := t.TempDir()
+	sessionTree(t, src)
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	w, err := Create(bundle, src, "archive")
+	require.NoError(t, err)
+	require.NoError(t, w.Add(filepath.Join(src, "todos", "s1-agent-s1.json")))
+	manifest := w.Manifest()
+	require.NoError(t, w.Close())
+
+	// Same manifest, different content
+	tampered := filepath.Join(t.TempDir(), "tampered.tar.gz")
+	writeBundle(t, tampered, map[string]string{"todos/s1-agent-s1.json": "[1]"}, manifest)
+	_, err = Verify(tampered)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	// A path that escapes the Claude home
+	escaping := filepath.Join(t.TempDir(), "escaping.tar.gz")
+	writeBundle(t, escaping, map[string]string{"todos/../../etc/passwd": "x"}, manifest)
+	_, err = Verify(escaping)
+	assert.ErrorContains(t, err, "invalid path")
+}
+
+// writeBundle writes a gzip bundle with the given files and manifest.
+func writeBundle(t *testing.T, path string, files map[string]string, manifest Manifest) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(content)), Mode: 0600}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	w := &Writer{tw: tw, manifest: manifest}
+	require.NoError(t, w.writeManifest())
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+}
+
+func TestValidPath(t *testing.T) {
+	for _, p := range []string{"projects/-p/s.jsonl", "file-history/s/abc@v1", "session-env/s/x"} {
+		assert.NoError(t, validPath(p), p)
+	}
+	for _, p := range []string{"", "/etc/passwd", "../x", "projects/../../x", "settings.json", "projects", "cccc-trash/x", "todos/./x", `todos\x`} {
+		assert.Error(t, validPath(p), p)
+	}
+}
@@ -0,0 +1,241 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ImportResult lists what importing a bundle did, or would do, with each file.
+type ImportResult struct {
+	Manifest  *Manifest
+	Restored  []Entry // Written to the Claude home
+	Identical []Entry // Already present with the same content
+	Conflicts []Entry // Present with different content, left alone
+}
+
+// reader reads the entries of a bundle.
+type reader struct {
+	*tar.Reader
+	close func()
+}
+
+// openBundle opens a bundle for reading.
+func openBundle(path string) (*reader, error) {
+	format, err := compression(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanPath := filepath.Clean(path)
+	f, err := os.Open(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	closeFn := func() { _ = f.Close() }
+	switch format {
+	case "zstd":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		r = zr
+		closeFn = func() { zr.Close(); _ = f.Close() }
+	default:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		r = zr
+	}
+
+	return &reader{Reader: tar.NewReader(r), close: closeFn}, nil
+}
+
+// Verify reads a bundle and checks every file against the checksums of its
+// manifest. It returns the manifest.
+func Verify(path string) (*Manifest, error) {
+	r, err := openBundle(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.close()
+
+	sums := make(map[string]string)
+	var manifest *Manifest
+	for {
+		header, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt bundle %s: %w", path, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("corrupt bundle %s: unexpected entry %q", path, header.Name)
+		}
+
+		if header.Name == manifestName {
+			var data bytes.Buffer
+			if _, err := io.Copy(&data, r); err != nil {
+				return nil, fmt.Errorf("corrupt bundle %s: %w", path, err)
+			}
+			manifest = &Manifest{}
+			if err := json.Unmarshal(data.Bytes(), manifest); err != nil {
+				return nil, fmt.Errorf("corrupt manifest in %s: %w", path, err)
+			}
+			continue
+		}
+
+		if err := validPath(header.Name); err != nil {
+			return nil, fmt.Errorf("corrupt bundle %s: %w", path, err)
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, r); err != nil {
+			return nil, fmt.Errorf("corrupt bundle %s: %w", path, err)
+		}
+		sums[header.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("corrupt bundle %s: no manifest", path)
+	}
+	if manifest.Version != manifestVersion {
+		return nil, fmt.Errorf("bundle %s has unsupported version %d", path, manifest.Version)
+	}
+	if len(sums) != len(manifest.Files) {
+		return nil, fmt.Errorf("corrupt bundle %s: %d files, manifest lists %d", path, len(sums), len(manifest.Files))
+	}
+	for _, e := range manifest.Files {
+		if sums[e.Path] != e.SHA256 {
+			return nil, fmt.Errorf("corrupt bundle %s: checksum mismatch for %s", path, e.Path)
+		}
+	}
+
+	return manifest, nil
+}
+
+// Import verifies a bundle and writes its files below the Claude home root.
+// Existing files are never overwritten: files with the same content are
+// reported as identical, others as conflicts. If dryRun is true, only
+// reports what would be done.
+func Import(path, root string, dryRun bool) (*ImportResult, error) {
+	manifest, err := Verify(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Manifest: manifest}
+	restore := make(map[string]Entry)
+	for _, e := range manifest.Files {
+		sum, err := fileSum(filepath.Join(root, filepath.FromSlash(e.Path)))
+		switch {
+		case os.IsNotExist(err):
+			restore[e.Path] = e
+		case err != nil:
+			return nil, err
+		case sum == e.SHA256:
+			result.Identical = append(result.Identical, e)
+		default:
+			result.Conflicts = append(result.Conflicts, e)
+		}
+	}
+
+	if dryRun || len(restore) == 0 {
+		for _, e := range manifest.Files {
+			if _, ok := restore[e.Path]; ok {
+				result.Restored = append(result.Restored, e)
+			}
+		}
+		return result, nil
+	}
+
+	r, err := openBundle(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.close()
+
+	for {
+		header, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		e, ok := restore[header.Name]
+		if !ok {
+			continue
+		}
+		if err := extract(r, filepath.Join(root, filepath.FromSlash(e.Path)), e); err != nil {
+			return result, fmt.Errorf("importing %s: %w", e.Path, err)
+		}
+		result.Restored = append(result.Restored, e)
+	}
+
+	return result, nil
+}
+
+// extract writes the current entry of r to target, verifying its checksum
+// before the file is moved into place.
+func extract(r io.Reader, target string, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+
+	tmp := filepath.Clean(target + ".cccc-import")
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && hex.EncodeToString(hash.Sum(nil)) != e.SHA256 {
+		err = errors.New("checksum mismatch")
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, e.ModTime, e.ModTime)
+	}
+	if err == nil {
+		// Link rather than rename, so that a file created in the meantime
+		// is not overwritten
+		err = os.Link(tmp, target)
+	}
+	_ = os.Remove(tmp)
+	return err
+}
+
+// fileSum returns the SHA-256 checksum of a file.
+func fileSum(path string) (string, error) {
+	cleanPath := filepath.Clean(path)
+	f, err := os.Open(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
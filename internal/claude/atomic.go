@@ -0,0 +1,142 @@
+package claude
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrConcurrentModification is returned by EditFile and RewriteFile when the file kept
+// changing underneath every attempt to rewrite it.
+var ErrConcurrentModification = errors.New("file was modified concurrently")
+
+// maxEditAttempts bounds how often RewriteFile retries after losing a race.
+const maxEditAttempts = 5
+
+// beforePublish is called right before replaceFile checks the file and
+// publishes the new content. Tests use it to change the file in between.
+var beforePublish = func(path string) {}
+
+// EditFile applies edit to the JSON document at path and atomically replaces
+// the file with the result. The new content is written to a temporary file in
+// the same directory and renamed over the original, so readers never see a
+// partially written file. If the file changes between reading and replacing
+// it, the edit is re-applied to the new content. It reports whether the file
+// was changed.
+func EditFile(path string, edit func(doc *Document) error) (bool, error) {
+	return RewriteFile(path, func(original []byte) ([]byte, error) {
+		doc, err := ParseDocument(original)
+		if err != nil {
+			return nil, err
+		}
+		if err := edit(doc); err != nil {
+			return nil, err
+		}
+		return doc.Bytes(), nil
+	})
+}
+
+// RewriteFile atomically replaces the file at path with the result of
+// rewrite, which is given its current content, like EditFile does for JSON
+// documents. It reports whether the file was changed.
+func RewriteFile(path string, rewrite func(original []byte) ([]byte, error)) (bool, error) {
+	cleanPath := filepath.Clean(path)
+
+	for attempt := 0; attempt < maxEditAttempts; attempt++ {
+		original, err := os.ReadFile(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+		if err != nil {
+			return false, err
+		}
+		if original == nil {
+			original = []byte{}
+		}
+
+		data, err := rewrite(original)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(data, original) {
+			return false, nil
+		}
+
+		err = replaceFile(cleanPath, original, data)
+		if errors.Is(err, ErrConcurrentModification) {
+			continue
+		}
+		return err == nil, err
+	}
+
+	return false, ErrConcurrentModification
+}
+
+// CreateFile atomically creates the file at path with data, and its directory
+// if needed, like EditFile replaces one. If the file exists, it fails with an
+// error that wraps fs.ErrExist and leaves the file alone.
+func CreateFile(path string, data []byte) error {
+	cleanPath := filepath.Clean(path)
+	if err := os.MkdirAll(filepath.Dir(cleanPath), 0700); err != nil {
+		return err
+	}
+	err := replaceFile(cleanPath, nil, data)
+	if errors.Is(err, ErrConcurrentModification) {
+		return fmt.Errorf("%s: %w", path, fs.ErrExist)
+	}
+	return err
+}
+
+// replaceFile atomically replaces path with data. If expected is non-nil the
+// file must still hold exactly those bytes right before the rename, and if it
+// is nil the file must not exist when the new one is linked into place,
+// otherwise ErrConcurrentModification is returned and the file is left alone.
+func replaceFile(path string, expected, data []byte) error {
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".cccc-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+
+	beforePublish(path)
+	if expected == nil {
+		// Link rather than rename, so that a file created in the meantime
+		// is not overwritten
+		err := os.Link(tmpName, path)
+		if errors.Is(err, fs.ErrExist) {
+			return ErrConcurrentModification
+		}
+		return err
+	}
+
+	current, err := os.ReadFile(path) // #nosec G304 -- path is sanitized by the caller
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, expected) {
+		return ErrConcurrentModification
+	}
+
+	return os.Rename(tmpName, path)
+}
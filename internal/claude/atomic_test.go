@@ -0,0 +1,158 @@
+package claude
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a":1,"b":2}`), 0640))
+
+	changed, err := EditFile(path, func(doc *Document) error {
+		_, err := doc.RemoveKeys(nil, []string{"a"})
+		return err
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":2}`, string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm(), "mode should be kept")
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestEditFile_NoChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a":1}`), 0600))
+
+	changed, err := EditFile(path, func(doc *Document) error {
+		_, err := doc.RemoveKeys(nil, []string{"missing"})
+		return err
+	})
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestEditFile_ConcurrentModification(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a":1,"b":2}`), 0600))
+
+	// Simulate another process rewriting the file while the first edit is in
+	// progress; the edit must be re-applied to the new content.
+	attempts := 0
+	changed, err := EditFile(path, func(doc *Document) error {
+		attempts++
+		if attempts == 1 {
+			require.NoError(t, os.WriteFile(path, []byte(`{"a":1,"b":2,"c":3}`), 0600))
+		}
+		_, err := doc.RemoveKeys(nil, []string{"a"})
+		return err
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, attempts)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"b":2,"c":3}`, string(data))
+}
+
+func TestEditFile_GivesUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"n":0}`), 0600))
+
+	n := 0
+	_, err := EditFile(path, func(doc *Document) error {
+		n++
+		require.NoError(t, os.WriteFile(path, []byte(`{"n":`+strconv.Itoa(n)+`}`), 0600))
+		_, err := doc.RemoveKeys(nil, []string{"n"})
+		return err
+	})
+	assert.ErrorIs(t, err, ErrConcurrentModification)
+}
+
+func TestEditFile_EditError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a":1}`), 0600))
+
+	_, err := EditFile(path, func(doc *Document) error {
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+}
+
+func TestRewriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("line 1\nline 2\n"), 0600))
+
+	changed, err := RewriteFile(path, func(original []byte) ([]byte, error) {
+		return append(original, "line 3\n"...), nil
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\nline 3\n", string(data))
+
+	changed, err = RewriteFile(path, func(original []byte) ([]byte, error) {
+		return original, nil
+	})
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestCreateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+
+	require.NoError(t, CreateFile(path, []byte("{}\n")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// An existing file is never replaced
+	err = CreateFile(path, []byte("{\"model\":\"opus\"}\n"))
+	assert.ErrorIs(t, err, fs.ErrExist)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(data))
+}
+
+func TestCreateFileRace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	t.Cleanup(func() { beforePublish = func(string) {} })
+	beforePublish = func(string) {
+		require.NoError(t, os.WriteFile(path, []byte("{\"model\":\"opus\"}\n"), 0600))
+	}
+
+	// A file created between the check and the link is not overwritten
+	err := CreateFile(path, []byte("{}\n"))
+	assert.ErrorIs(t, err, fs.ErrExist)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"model\":\"opus\"}\n", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file left behind")
+}
@@ -9,6 +9,11 @@ import (
 // Settings represents Claude Code settings configuration.
 type Settings struct {
 	Permissions Permissions `json:"permissions"`
+
+	// Other lists the keys the file defines besides the allow, deny and ask
+	// permission lists, e.g. "env", "hooks" or "permissions.defaultMode".
+	// They are not modelled here but must survive any rewrite of the file.
+	Other []string `json:"-"`
 }
 
 // Permissions represents the permissions configuration.
@@ -18,6 +23,23 @@ type Permissions struct {
 	Ask   []string `json:"ask"`
 }
 
+// Settings layers, from the highest precedence to the lowest. Claude Code
+// merges the permission lists of all layers, so an entry of a lower layer that
+// a higher one already has is redundant.
+const (
+	LayerManaged = "managed" // managed-settings.json deployed by administrators
+	LayerUser    = "user"    // ~/.claude/settings.json
+	LayerProject = "project" // <project>/.claude/settings.json, shared in the repository
+	LayerLocal   = "local"   // <project>/.claude/settings.local.json
+)
+
+// SettingsLayer is the settings file of one layer.
+type SettingsLayer struct {
+	Name     string
+	Path     string
+	Settings *Settings
+}
+
 // LoadSettings loads settings from the given path.
 // Returns an empty Settings if the file doesn't exist.
 func LoadSettings(path string) (*Settings, error) {
@@ -29,7 +51,12 @@ func LoadSettings(path string) (*Settings, error) {
 	if err != nil {
 		return nil, err
 	}
+	return ParseSettings(data)
+}
 
+// ParseSettings parses the content of a settings file.
+// Returns an empty Settings if data is empty.
+func ParseSettings(data []byte) (*Settings, error) {
 	if len(data) == 0 {
 		return &Settings{}, nil
 	}
@@ -39,10 +66,36 @@ func LoadSettings(path string) (*Settings, error) {
 		return nil, err
 	}
 
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	settings.Other = otherSettingsKeys(doc)
+
 	return &settings, nil
 }
 
+// otherSettingsKeys returns the keys of a settings document that are not
+// permission lists. Keys nested in "permissions" are prefixed with "permissions.".
+func otherSettingsKeys(doc *Document) []string {
+	var other []string
+	for _, key := range doc.Keys() {
+		if key != "permissions" {
+			other = append(other, key)
+		}
+	}
+	for _, key := range doc.Keys("permissions") {
+		switch key {
+		case "allow", "deny", "ask":
+		default:
+			other = append(other, "permissions."+key)
+		}
+	}
+	return other
+}
+
 // Diff returns a new Settings containing entries in s that are not in other.
+// Non-permission keys of s are carried over unchanged.
 func (s *Settings) Diff(other *Settings) *Settings {
 	return &Settings{
 		Permissions: Permissions{
@@ -50,14 +103,16 @@ func (s *Settings) Diff(other *Settings) *Settings {
 			Deny:  diffSlice(s.Permissions.Deny, other.Permissions.Deny),
 			Ask:   diffSlice(s.Permissions.Ask, other.Permissions.Ask),
 		},
+		Other: s.Other,
 	}
 }
 
-// IsEmpty returns true if all permission lists are empty.
+// IsEmpty returns true if all permission lists are empty and no other keys are set.
 func (s *Settings) IsEmpty() bool {
 	return len(s.Permissions.Allow) == 0 &&
 		len(s.Permissions.Deny) == 0 &&
-		len(s.Permissions.Ask) == 0
+		len(s.Permissions.Ask) == 0 &&
+		len(s.Other) == 0
 }
 
 // diffSlice returns elements in a that are not in b.
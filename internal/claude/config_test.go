@@ -100,6 +100,46 @@ func TestLoadSettings_PartialPermissions(t *testing.T) {
 	assert.Empty(t, settings.Permissions.Ask)
 }
 
+func TestLoadSettings_RecordsOtherKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+
+	content := `{
+		"env": {"FOO": "bar"},
+		"permissions": {
+			"allow": ["Bash(git:*)"],
+			"defaultMode": "acceptEdits",
+			"additionalDirectories": ["../shared"]
+		},
+		"hooks": {},
+		"model": "opus"
+	}`
+	require.NoError(t, os.WriteFile(settingsPath, []byte(content), 0644))
+
+	settings, err := LoadSettings(settingsPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"env", "hooks", "model",
+		"permissions.defaultMode", "permissions.additionalDirectories",
+	}, settings.Other)
+}
+
+func TestSettings_Diff_KeepsOtherKeys(t *testing.T) {
+	local := &Settings{
+		Permissions: Permissions{Allow: []string{"Bash(git:*)"}},
+		Other:       []string{"env"},
+	}
+	global := &Settings{
+		Permissions: Permissions{Allow: []string{"Bash(git:*)"}},
+	}
+
+	diff := local.Diff(global)
+
+	assert.Empty(t, diff.Permissions.Allow)
+	assert.Equal(t, []string{"env"}, diff.Other)
+	assert.False(t, diff.IsEmpty())
+}
+
 func TestSettings_Diff_AllUnique(t *testing.T) {
 	local := &Settings{
 		Permissions: Permissions{
@@ -275,6 +315,13 @@ func TestSettings_IsEmpty(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "has other keys",
+			settings: &Settings{
+				Other: []string{"env"},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,632 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Document is a JSON document that can be edited without disturbing anything
+// outside the edited values. Key order, unknown keys, whitespace and
+// indentation of the original text are preserved byte for byte.
+type Document struct {
+	data []byte
+	root *jsonNode
+}
+
+// jsonKind identifies the type of a JSON value.
+type jsonKind int
+
+const (
+	kindObject jsonKind = iota
+	kindArray
+	kindString
+	kindNumber
+	kindLiteral // true, false or null
+)
+
+// jsonNode is a parsed JSON value with its byte span in the source text.
+type jsonNode struct {
+	kind    jsonKind
+	start   int // offset of the first byte of the value
+	end     int // offset just past the last byte of the value
+	members []jsonMember
+	elems   []*jsonNode
+}
+
+// jsonMember is a single key/value pair of a JSON object.
+type jsonMember struct {
+	key   string
+	start int // offset of the opening quote of the key
+	value *jsonNode
+}
+
+// ErrNotFound is returned when a path does not resolve to a value of the expected kind.
+var ErrNotFound = errors.New("path not found in document")
+
+// ParseDocument parses data into an editable Document.
+func ParseDocument(data []byte) (*Document, error) {
+	if !json.Valid(data) {
+		// Let encoding/json produce a descriptive syntax error.
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid JSON document")
+	}
+
+	p := &jsonParser{data: data}
+	root, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{data: data, root: root}, nil
+}
+
+// Bytes returns the current text of the document.
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+// Keys returns the keys of the object at path, in document order.
+// Returns nil if path does not resolve to an object.
+func (d *Document) Keys(path ...string) []string {
+	node := d.find(path)
+	if node == nil || node.kind != kindObject {
+		return nil
+	}
+
+	keys := make([]string, 0, len(node.members))
+	for _, m := range node.members {
+		keys = append(keys, m.key)
+	}
+	return keys
+}
+
+// Has reports whether path resolves to a value.
+func (d *Document) Has(path ...string) bool {
+	return d.find(path) != nil
+}
+
+// Raw returns the source text of the value at path.
+func (d *Document) Raw(path ...string) ([]byte, bool) {
+	node := d.find(path)
+	if node == nil {
+		return nil, false
+	}
+	return d.data[node.start:node.end], true
+}
+
+// Len returns the number of elements of the array or members of the object at path.
+func (d *Document) Len(path ...string) int {
+	node := d.find(path)
+	if node == nil {
+		return 0
+	}
+	if node.kind == kindArray {
+		return len(node.elems)
+	}
+	return len(node.members)
+}
+
+// RemoveStrings removes every string element of the array at path whose value
+// is in values. It returns the number of elements removed. A missing array is
+// not an error and removes nothing.
+func (d *Document) RemoveStrings(path []string, values []string) (int, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	node := d.find(path)
+	if node == nil {
+		return 0, nil
+	}
+	if node.kind != kindArray {
+		return 0, fmt.Errorf("%w: %v is not an array", ErrNotFound, path)
+	}
+
+	remove := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		remove[v] = struct{}{}
+	}
+
+	keep := make([]bool, len(node.elems))
+	removed := 0
+	for i, elem := range node.elems {
+		keep[i] = true
+		if elem.kind != kindString {
+			continue
+		}
+		s, err := d.decodeString(elem)
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := remove[s]; ok {
+			keep[i] = false
+			removed++
+		}
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	spans := make([][2]int, len(node.elems))
+	for i, elem := range node.elems {
+		spans[i] = [2]int{elem.start, elem.end}
+	}
+
+	return removed, d.splice(node, spans, keep)
+}
+
+// AppendStrings appends the values that are not in it yet to the string
+// array at path, creating the array and the objects leading to it if they are
+// missing. New elements and members follow the indentation of the document.
+// It returns the number of elements appended.
+func (d *Document) AppendStrings(path []string, values []string) (int, error) {
+	// Find the deepest existing value on path
+	node, depth := d.root, 0
+	for ; depth < len(path); depth++ {
+		if node.kind != kindObject {
+			return 0, fmt.Errorf("%w: %v is not an object", ErrNotFound, path[:depth])
+		}
+		next := d.find(path[:depth+1])
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	if depth < len(path) {
+		if len(values) == 0 {
+			return 0, nil
+		}
+		// Build the missing part of the path around the values
+		var value any = values
+		for i := len(path) - 1; i > depth; i-- {
+			value = map[string]any{path[i]: value}
+		}
+		return len(values), d.insertMember(node, path[depth], value)
+	}
+
+	if node.kind != kindArray {
+		return 0, fmt.Errorf("%w: %v is not an array", ErrNotFound, path)
+	}
+	existing := make(map[string]bool, len(node.elems))
+	for _, elem := range node.elems {
+		if elem.kind == kindString {
+			s, err := d.decodeString(elem)
+			if err != nil {
+				return 0, err
+			}
+			existing[s] = true
+		}
+	}
+	var add []any
+	for _, v := range values {
+		if !existing[v] {
+			add = append(add, v)
+			existing[v] = true
+		}
+	}
+	if len(add) == 0 {
+		return 0, nil
+	}
+
+	spans := make([][2]int, len(node.elems))
+	for i, elem := range node.elems {
+		spans[i] = [2]int{elem.start, elem.end}
+	}
+	return len(add), d.insert(node, spans, add, func(v any, indent string) ([]byte, error) {
+		return marshalJSON(v, indent, d.valueIndent())
+	})
+}
+
+// insertMember adds the member key with value to the object node.
+func (d *Document) insertMember(node *jsonNode, key string, value any) error {
+	spans := make([][2]int, len(node.members))
+	for i, m := range node.members {
+		spans[i] = [2]int{m.start, m.value.end}
+	}
+	return d.insert(node, spans, []any{value}, func(v any, indent string) ([]byte, error) {
+		k, err := marshalJSON(key, "", "")
+		if err != nil {
+			return nil, err
+		}
+		val, err := marshalJSON(v, indent, d.valueIndent())
+		if err != nil {
+			return nil, err
+		}
+		if d.multiline() {
+			return append(append(k, ": "...), val...), nil
+		}
+		return append(append(k, ':'), val...), nil
+	})
+}
+
+// insert adds items after the existing ones, whose spans are given, at the
+// end of container. The separator between existing items is reused; an empty
+// container is laid out like the rest of the document.
+func (d *Document) insert(container *jsonNode, spans [][2]int, items []any, render func(v any, indent string) ([]byte, error)) error {
+	closing := container.end - 1
+
+	var at int
+	var sep, lead, trail []byte
+	switch n := len(spans); {
+	case n >= 2:
+		at = spans[n-1][1]
+		sep = d.data[spans[n-2][1]:spans[n-1][0]]
+	case n == 1:
+		at = spans[0][1]
+		sep = append([]byte(","), d.data[container.start+1:spans[0][0]]...)
+	case d.multiline():
+		at = closing
+		indent := lineIndent(d.data, container.start)
+		sep = []byte(",\n" + indent + d.indentUnit())
+		lead = []byte("\n" + indent + d.indentUnit())
+		trail = []byte("\n" + indent)
+	default:
+		at = closing
+		sep = []byte(",")
+	}
+	indent := string(sep[bytes.LastIndexByte(sep, '\n')+1:])
+	if !bytes.Contains(sep, []byte("\n")) {
+		indent = ""
+	}
+
+	out := make([]byte, 0, len(d.data)+64)
+	if len(spans) == 0 {
+		out = append(out, d.data[:container.start+1]...)
+		out = append(out, lead...)
+	} else {
+		out = append(out, d.data[:at]...)
+	}
+	for i, v := range items {
+		if i > 0 || len(spans) > 0 {
+			out = append(out, sep...)
+		}
+		text, err := render(v, indent)
+		if err != nil {
+			return err
+		}
+		out = append(out, text...)
+	}
+	if len(spans) == 0 {
+		out = append(out, trail...)
+		out = append(out, d.data[closing:]...)
+	} else {
+		out = append(out, d.data[at:]...)
+	}
+
+	return d.reset(out)
+}
+
+// multiline reports whether the document is laid out over several lines.
+func (d *Document) multiline() bool {
+	return bytes.Contains(d.data[d.root.start:d.root.end], []byte("\n"))
+}
+
+// indentUnit returns the indentation of the first member of the document, or
+// two spaces.
+func (d *Document) indentUnit() string {
+	if d.root.kind == kindObject && len(d.root.members) > 0 {
+		if indent := lineIndent(d.data, d.root.members[0].start); indent != "" {
+			return indent
+		}
+	}
+	return "  "
+}
+
+// valueIndent returns the indentation unit of new values, or nothing for a
+// document on a single line.
+func (d *Document) valueIndent() string {
+	if !d.multiline() {
+		return ""
+	}
+	return d.indentUnit()
+}
+
+// lineIndent returns the whitespace that starts the line containing pos.
+func lineIndent(data []byte, pos int) string {
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	end := start
+	for end < len(data) && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// marshalJSON encodes v without escaping HTML characters, indented like
+// json.MarshalIndent if indent is not empty.
+func marshalJSON(v any, prefix, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if indent != "" {
+		enc.SetIndent(prefix, indent)
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// RemoveKeys removes the members of the object at path whose keys are in keys.
+// It returns the number of members removed.
+func (d *Document) RemoveKeys(path []string, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	node := d.find(path)
+	if node == nil {
+		return 0, nil
+	}
+	if node.kind != kindObject {
+		return 0, fmt.Errorf("%w: %v is not an object", ErrNotFound, path)
+	}
+
+	remove := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		remove[k] = struct{}{}
+	}
+
+	keep := make([]bool, len(node.members))
+	spans := make([][2]int, len(node.members))
+	removed := 0
+	for i, m := range node.members {
+		spans[i] = [2]int{m.start, m.value.end}
+		keep[i] = true
+		if _, ok := remove[m.key]; ok {
+			keep[i] = false
+			removed++
+		}
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	return removed, d.splice(node, spans, keep)
+}
+
+// TruncateArray removes all but the first n elements of the array at path.
+// It returns the number of elements removed. A missing array is not an error
+// and removes nothing.
+func (d *Document) TruncateArray(path []string, n int) (int, error) {
+	node := d.find(path)
+	if node == nil {
+		return 0, nil
+	}
+	if node.kind != kindArray {
+		return 0, fmt.Errorf("%w: %v is not an array", ErrNotFound, path)
+	}
+	if n < 0 {
+		n = 0
+	}
+	if len(node.elems) <= n {
+		return 0, nil
+	}
+
+	keep := make([]bool, len(node.elems))
+	spans := make([][2]int, len(node.elems))
+	for i, elem := range node.elems {
+		spans[i] = [2]int{elem.start, elem.end}
+		keep[i] = i < n
+	}
+
+	removed := len(node.elems) - n
+	return removed, d.splice(node, spans, keep)
+}
+
+// splice rewrites the contents of container so that only the items marked in
+// keep remain. The separator that followed each kept item, and the whitespace
+// before the first and after the last item, are carried over from the source.
+func (d *Document) splice(container *jsonNode, spans [][2]int, keep []bool) error {
+	open, closing := container.start, container.end-1
+	n := len(spans)
+
+	out := make([]byte, 0, len(d.data))
+	out = append(out, d.data[:open+1]...)
+
+	var kept []int
+	for i := range spans {
+		if keep[i] {
+			kept = append(kept, i)
+		}
+	}
+
+	if len(kept) > 0 {
+		out = append(out, d.data[open+1:spans[0][0]]...)
+		for j, i := range kept {
+			out = append(out, d.data[spans[i][0]:spans[i][1]]...)
+			if j < len(kept)-1 {
+				out = append(out, d.data[spans[i][1]:spans[i+1][0]]...)
+			}
+		}
+		out = append(out, d.data[spans[n-1][1]:closing]...)
+	}
+
+	out = append(out, d.data[closing:]...)
+
+	return d.reset(out)
+}
+
+// reset replaces the document text and re-parses it.
+func (d *Document) reset(data []byte) error {
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return fmt.Errorf("edit produced invalid JSON: %w", err)
+	}
+	*d = *doc
+	return nil
+}
+
+// find resolves a path of object keys to a node. When an object repeats a
+// key, the last occurrence wins, matching encoding/json.
+func (d *Document) find(path []string) *jsonNode {
+	node := d.root
+	for _, key := range path {
+		if node == nil || node.kind != kindObject {
+			return nil
+		}
+		var next *jsonNode
+		for _, m := range node.members {
+			if m.key == key {
+				next = m.value
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// decodeString returns the Go value of a string node.
+func (d *Document) decodeString(node *jsonNode) (string, error) {
+	return unquoteJSON(d.data[node.start:node.end])
+}
+
+// unquoteJSON decodes a quoted JSON string token.
+func unquoteJSON(token []byte) (string, error) {
+	var s string
+	if err := json.Unmarshal(token, &s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// jsonParser builds a jsonNode tree from text already validated by json.Valid.
+type jsonParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) parseValue() (*jsonNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, errors.New("unexpected end of JSON input")
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"':
+		return p.parseString()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseScalar(kindNumber), nil
+	default:
+		return p.parseScalar(kindLiteral), nil
+	}
+}
+
+func (p *jsonParser) parseObject() (*jsonNode, error) {
+	node := &jsonNode{kind: kindObject, start: p.pos}
+	p.pos++ // {
+
+	for {
+		p.skipSpace()
+		if p.data[p.pos] == '}' {
+			p.pos++
+			node.end = p.pos
+			return node, nil
+		}
+		if p.data[p.pos] == ',' {
+			p.pos++
+			p.skipSpace()
+		}
+
+		keyNode, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		key, err := unquoteJSON(p.data[keyNode.start:keyNode.end])
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		p.pos++ // :
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		node.members = append(node.members, jsonMember{key: key, start: keyNode.start, value: value})
+	}
+}
+
+func (p *jsonParser) parseArray() (*jsonNode, error) {
+	node := &jsonNode{kind: kindArray, start: p.pos}
+	p.pos++ // [
+
+	for {
+		p.skipSpace()
+		if p.data[p.pos] == ']' {
+			p.pos++
+			node.end = p.pos
+			return node, nil
+		}
+		if p.data[p.pos] == ',' {
+			p.pos++
+		}
+
+		elem, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.elems = append(node.elems, elem)
+	}
+}
+
+func (p *jsonParser) parseString() (*jsonNode, error) {
+	if p.data[p.pos] != '"' {
+		return nil, fmt.Errorf("expected string at offset %d", p.pos)
+	}
+
+	node := &jsonNode{kind: kindString, start: p.pos}
+	p.pos++ // opening quote
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '"':
+			p.pos++
+			node.end = p.pos
+			return node, nil
+		default:
+			p.pos++
+		}
+	}
+
+	return nil, errors.New("unterminated string")
+}
+
+func (p *jsonParser) parseScalar(kind jsonKind) *jsonNode {
+	node := &jsonNode{kind: kind, start: p.pos}
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			node.end = p.pos
+			return node
+		}
+		p.pos++
+	}
+	node.end = p.pos
+	return node
+}
@@ -0,0 +1,294 @@
+package claude
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDocument_Invalid(t *testing.T) {
+	_, err := ParseDocument([]byte(`{"a":`))
+	assert.Error(t, err)
+}
+
+func TestDocument_Keys(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"z":1,"a":{"y":true,"b":null},"m":"x"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"z", "a", "m"}, doc.Keys())
+	assert.Equal(t, []string{"y", "b"}, doc.Keys("a"))
+	assert.Nil(t, doc.Keys("m"))
+	assert.Nil(t, doc.Keys("missing"))
+}
+
+func TestDocument_RemoveStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		remove   []string
+		expected string
+	}{
+		{
+			name:     "first of several",
+			input:    `{"l":["a","b","c"]}`,
+			remove:   []string{"a"},
+			expected: `{"l":["b","c"]}`,
+		},
+		{
+			name:     "middle",
+			input:    `{"l":["a","b","c"]}`,
+			remove:   []string{"b"},
+			expected: `{"l":["a","c"]}`,
+		},
+		{
+			name:     "last",
+			input:    `{"l":["a","b","c"]}`,
+			remove:   []string{"c"},
+			expected: `{"l":["a","b"]}`,
+		},
+		{
+			name:     "only element",
+			input:    `{"l":["a"]}`,
+			remove:   []string{"a"},
+			expected: `{"l":[]}`,
+		},
+		{
+			name:     "all elements",
+			input:    "{\n  \"l\": [\n    \"a\",\n    \"b\"\n  ]\n}\n",
+			remove:   []string{"a", "b"},
+			expected: "{\n  \"l\": []\n}\n",
+		},
+		{
+			name:     "indented last",
+			input:    "{\n  \"l\": [\n    \"a\",\n    \"b\"\n  ]\n}\n",
+			remove:   []string{"b"},
+			expected: "{\n  \"l\": [\n    \"a\"\n  ]\n}\n",
+		},
+		{
+			name:     "indented first",
+			input:    "{\n\t\"l\": [\n\t\t\"a\",\n\t\t\"b\"\n\t]\n}",
+			remove:   []string{"a"},
+			expected: "{\n\t\"l\": [\n\t\t\"b\"\n\t]\n}",
+		},
+		{
+			name:     "non-adjacent",
+			input:    `{"l":["a", "b", "c", "d"]}`,
+			remove:   []string{"a", "c"},
+			expected: `{"l":["b", "d"]}`,
+		},
+		{
+			name:     "escaped value",
+			input:    `{"l":["Bash(echo \"hi\":*)","x"]}`,
+			remove:   []string{`Bash(echo "hi":*)`},
+			expected: `{"l":["x"]}`,
+		},
+		{
+			name:     "no match",
+			input:    `{"l":["a"]}`,
+			remove:   []string{"z"},
+			expected: `{"l":["a"]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(tc.input))
+			require.NoError(t, err)
+
+			_, err = doc.RemoveStrings([]string{"l"}, tc.remove)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, string(doc.Bytes()))
+			assert.True(t, json.Valid(doc.Bytes()))
+		})
+	}
+}
+
+func TestDocument_RemoveStrings_MissingPath(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"a":1}`))
+	require.NoError(t, err)
+
+	n, err := doc.RemoveStrings([]string{"permissions", "allow"}, []string{"x"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, `{"a":1}`, string(doc.Bytes()))
+}
+
+func TestDocument_RemoveStrings_NotArray(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"a":1}`))
+	require.NoError(t, err)
+
+	_, err = doc.RemoveStrings([]string{"a"}, []string{"x"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDocument_RemoveKeys(t *testing.T) {
+	input := "{\n  \"a\": 1,\n  \"b\": {\"x\": [1, 2]},\n  \"c\": \"z\"\n}\n"
+
+	doc, err := ParseDocument([]byte(input))
+	require.NoError(t, err)
+
+	n, err := doc.RemoveKeys(nil, []string{"b"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "{\n  \"a\": 1,\n  \"c\": \"z\"\n}\n", string(doc.Bytes()))
+}
+
+func TestDocument_TruncateArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		n        int
+		removed  int
+		expected string
+	}{
+		{
+			name:     "keeps first n",
+			input:    `{"h":[{"d":"a"}, {"d":"b"}, {"d":"c"}]}`,
+			n:        2,
+			removed:  1,
+			expected: `{"h":[{"d":"a"}, {"d":"b"}]}`,
+		},
+		{
+			name:     "zero empties array",
+			input:    "{\"h\": [\n  1,\n  2\n]}",
+			n:        0,
+			removed:  2,
+			expected: `{"h": []}`,
+		},
+		{
+			name:     "shorter than n",
+			input:    `{"h":[1]}`,
+			n:        5,
+			removed:  0,
+			expected: `{"h":[1]}`,
+		},
+		{
+			name:     "missing array",
+			input:    `{"x":1}`,
+			n:        0,
+			removed:  0,
+			expected: `{"x":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(tt.input))
+			require.NoError(t, err)
+
+			removed, err := doc.TruncateArray([]string{"h"}, tt.n)
+			require.NoError(t, err)
+			assert.Equal(t, tt.removed, removed)
+			assert.Equal(t, tt.expected, string(doc.Bytes()))
+		})
+	}
+}
+
+func TestDocument_TruncateArray_NotAnArray(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"h":{}}`))
+	require.NoError(t, err)
+
+	_, err = doc.TruncateArray([]string{"h"}, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDocument_AppendStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		path     []string
+		values   []string
+		expected string
+		added    int
+	}{
+		{
+			name:     "compact array",
+			input:    `{"l":["a","b"]}`,
+			path:     []string{"l"},
+			values:   []string{"c", "a", "d"},
+			expected: `{"l":["a","b","c","d"]}`,
+			added:    2,
+		},
+		{
+			name:     "indented array with one element",
+			input:    "{\n  \"l\": [\n    \"a\"\n  ]\n}\n",
+			path:     []string{"l"},
+			values:   []string{"b"},
+			expected: "{\n  \"l\": [\n    \"a\",\n    \"b\"\n  ]\n}\n",
+			added:    1,
+		},
+		{
+			name:     "empty indented array",
+			input:    "{\n  \"l\": []\n}\n",
+			path:     []string{"l"},
+			values:   []string{"a", "b"},
+			expected: "{\n  \"l\": [\n    \"a\",\n    \"b\"\n  ]\n}\n",
+			added:    2,
+		},
+		{
+			name:     "missing array",
+			input:    "{\n    \"permissions\": {\n        \"deny\": [\"x\"]\n    }\n}\n",
+			path:     []string{"permissions", "allow"},
+			values:   []string{"a"},
+			expected: "{\n    \"permissions\": {\n        \"deny\": [\"x\"],\n        \"allow\": [\n            \"a\"\n        ]\n    }\n}\n",
+			added:    1,
+		},
+		{
+			name:     "missing object",
+			input:    "{\n  \"model\": \"opus\"\n}\n",
+			path:     []string{"permissions", "allow"},
+			values:   []string{"Bash(a > b)"},
+			expected: "{\n  \"model\": \"opus\",\n  \"permissions\": {\n    \"allow\": [\n      \"Bash(a > b)\"\n    ]\n  }\n}\n",
+			added:    1,
+		},
+		{
+			name:     "empty document",
+			input:    "{\n}\n",
+			path:     []string{"permissions", "allow"},
+			values:   []string{"a"},
+			expected: "{\n  \"permissions\": {\n    \"allow\": [\n      \"a\"\n    ]\n  }\n}\n",
+			added:    1,
+		},
+		{
+			name:     "compact empty object",
+			input:    `{}`,
+			path:     []string{"permissions", "ask"},
+			values:   []string{"a"},
+			expected: `{"permissions":{"ask":["a"]}}`,
+			added:    1,
+		},
+		{
+			name:     "nothing new",
+			input:    `{"l":["a"]}`,
+			path:     []string{"l"},
+			values:   []string{"a"},
+			expected: `{"l":["a"]}`,
+			added:    0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := ParseDocument([]byte(tc.input))
+			require.NoError(t, err)
+
+			n, err := doc.AppendStrings(tc.path, tc.values)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.added, n)
+			assert.Equal(t, tc.expected, string(doc.Bytes()))
+			assert.True(t, json.Valid(doc.Bytes()))
+		})
+	}
+}
+
+func TestDocument_AppendStrings_NotArray(t *testing.T) {
+	doc, err := ParseDocument([]byte(`{"permissions":"none"}`))
+	require.NoError(t, err)
+
+	_, err = doc.AppendStrings([]string{"permissions", "allow"}, []string{"x"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,92 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileBackup is a snapshot that Claude Code took of a file before a session
+// edited it, stored as file-history/<session-id>/<hash>@v<version>.
+type FileBackup struct {
+	Source  string // The file the snapshot was taken of
+	Backup  string // Name of the snapshot file
+	Version int
+}
+
+// fileHistoryLine is a "file-history-snapshot" line of a session file, which
+// lists the snapshots of all files the session has edited so far.
+type fileHistoryLine struct {
+	Type     string `json:"type"`
+	Snapshot struct {
+		TrackedFileBackups map[string]struct {
+			BackupFileName *string `json:"backupFileName"`
+			Version        int     `json:"version"`
+		} `json:"trackedFileBackups"`
+	} `json:"snapshot"`
+}
+
+// fileHistoryMarker is searched for before a line is decoded, since most
+// lines are not file-history snapshots.
+var fileHistoryMarker = []byte(`"file-history-snapshot"`)
+
+// ReadFileBackups reads the file-history snapshots recorded in the session
+// file at path, keyed by the name of the snapshot file. Relative source paths
+// are resolved against cwd. Invalid lines are skipped.
+func ReadFileBackups(path, cwd string) (map[string]FileBackup, error) {
+	cleanPath := filepath.Clean(path)
+	file, err := os.Open(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	backups := make(map[string]FileBackup)
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+
+		var fl fileHistoryLine
+		if bytes.Contains(line, fileHistoryMarker) && json.Unmarshal(bytes.TrimSpace(line), &fl) == nil && fl.Type == "file-history-snapshot" {
+			for source, b := range fl.Snapshot.TrackedFileBackups {
+				if b.BackupFileName == nil || *b.BackupFileName == "" {
+					continue // The file did not exist before it was edited
+				}
+				source = filepath.FromSlash(source)
+				if !filepath.IsAbs(source) && cwd != "" {
+					source = filepath.Join(filepath.FromSlash(cwd), source)
+				}
+				backups[*b.BackupFileName] = FileBackup{Source: source, Backup: *b.BackupFileName, Version: b.Version}
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+
+	return backups, nil
+}
+
+// ParseBackupName splits the name of a snapshot file, <hash>@v<version>,
+// into the hash of the file it belongs to and its version. It reports false
+// for other names.
+func ParseBackupName(name string) (string, int, bool) {
+	hash, version, found := strings.Cut(name, "@v")
+	if !found || hash == "" {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(version)
+	if err != nil || n < 0 {
+		return "", 0, false
+	}
+	return hash, n, true
+}
@@ -0,0 +1,41 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	content := `{"type":"user","sessionId":"s1","cwd":"/work","message":{"role":"user","content":"edit"}}
+{"type":"file-history-snapshot","messageId":"m1","snapshot":{"messageId":"m1","trackedFileBackups":{"/work/main.go":{"backupFileName":"aaa@v1","version":1},"new.go":{"backupFileName":null,"version":1}}},"isSnapshotUpdate":false}
+{"type":"file-history-snapshot","messageId":"m2","snapshot":{"messageId":"m2","trackedFileBackups":{"/work/main.go":{"backupFileName":"aaa@v2","version":2},"docs/readme.md":{"backupFileName":"bbb@v1","version":1}}},"isSnapshotUpdate":true}
+{not json
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	backups, err := ReadFileBackups(path, "/work")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]FileBackup{
+		"aaa@v1": {Source: filepath.FromSlash("/work/main.go"), Backup: "aaa@v1", Version: 1},
+		"aaa@v2": {Source: filepath.FromSlash("/work/main.go"), Backup: "aaa@v2", Version: 2},
+		"bbb@v1": {Source: filepath.Join(filepath.FromSlash("/work"), "docs", "readme.md"), Backup: "bbb@v1", Version: 1},
+	}, backups)
+}
+
+func TestParseBackupName(t *testing.T) {
+	hash, version, ok := ParseBackupName("3f2a9c@v12")
+	assert.True(t, ok)
+	assert.Equal(t, "3f2a9c", hash)
+	assert.Equal(t, 12, version)
+
+	for _, name := range []string{"3f2a9c", "@v1", "3f2a9c@vx", "3f2a9c@v-1"} {
+		_, _, ok := ParseBackupName(name)
+		assert.False(t, ok, name)
+	}
+}
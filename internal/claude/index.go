@@ -0,0 +1,239 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// indexVersion is bumped whenever SessionInfo or the parsing of session
+// files changes, so that stale indexes are rebuilt rather than trusted.
+const indexVersion = 7
+
+// Index caches the metadata of session files between runs, keyed by path,
+// size and modification time. It is safe for concurrent use.
+type Index struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]indexEntry
+	seen    map[string]bool
+	changed bool
+	hits    int
+	misses  int
+}
+
+// indexEntry is the cached result of parsing a single session file.
+type indexEntry struct {
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mtime"`
+	Invalid   bool      `json:"invalid,omitempty"` // The file could not be parsed
+	ID        string    `json:"id,omitempty"`
+	AgentID   string    `json:"agentId,omitempty"`
+	CWD       string    `json:"cwd,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	BadLines  int       `json:"badLines,omitempty"`
+	ValidSize int64     `json:"validSize,omitempty"`
+
+	LastTimestamp     time.Time  `json:"lastTimestamp,omitzero"`
+	UserMessages      int        `json:"userMessages,omitempty"`
+	AssistantMessages int        `json:"assistantMessages,omitempty"`
+	ToolUses          int        `json:"toolUses,omitempty"`
+	Version           string     `json:"version,omitempty"`
+	GitBranch         string     `json:"gitBranch,omitempty"`
+	Models            []string   `json:"models,omitempty"`
+	Usage             TokenUsage `json:"usage,omitzero"`
+	Summary           string     `json:"summary,omitempty"`
+	Slugs             []string   `json:"slugs,omitempty"`
+}
+
+// indexFile is the on-disk format of an Index.
+type indexFile struct {
+	Version int                   `json:"version"`
+	Files   map[string]indexEntry `json:"files"`
+}
+
+// DefaultIndexPath returns the default index file for a given Claude home directory.
+func DefaultIndexPath(claudeHome string) string {
+	return filepath.Join(claudeHome, "cccc-index")
+}
+
+// NewIndex returns an empty index that is stored at path. Saving it
+// replaces whatever the file holds.
+func NewIndex(path string) *Index {
+	return &Index{
+		path:    path,
+		entries: make(map[string]indexEntry),
+		seen:    make(map[string]bool),
+		changed: true,
+	}
+}
+
+// LoadIndex reads the index stored at path. A missing file, or one written
+// by a different version, yields an empty index.
+func LoadIndex(path string) (*Index, error) {
+	idx := NewIndex(path)
+
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f indexFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("corrupt index %s: %w", path, err)
+	}
+	if f.Version != indexVersion {
+		return idx, nil
+	}
+	for file, entry := range f.Files {
+		idx.entries[file] = entry
+	}
+	idx.changed = false
+
+	return idx, nil
+}
+
+// Path returns the file the index is stored in.
+func (idx *Index) Path() string {
+	return idx.path
+}
+
+// Len returns the number of indexed files.
+func (idx *Index) Len() int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return len(idx.entries)
+}
+
+// Stats returns the number of lookups answered from the index and the number
+// of files that had to be parsed.
+func (idx *Index) Stats() (hits, misses int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.hits, idx.misses
+}
+
+// parse returns the metadata of a session file like ParseSessionFile, from
+// the index if the file's size and modification time are unchanged.
+func (idx *Index) parse(path string) (*SessionInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.Lock()
+	entry, ok := idx.entries[path]
+	idx.seen[path] = true
+	if ok && entry.Size == stat.Size() && entry.ModTime.Equal(stat.ModTime()) {
+		idx.hits++
+		idx.mu.Unlock()
+		if entry.Invalid {
+			return nil, ErrNoCWD
+		}
+		return entry.info(path), nil
+	}
+	idx.misses++
+	idx.mu.Unlock()
+
+	info, err := ParseSessionFile(path)
+
+	entry = indexEntry{Size: stat.Size(), ModTime: stat.ModTime(), Invalid: err != nil}
+	if info != nil {
+		entry.ID = info.ID
+		entry.AgentID = info.AgentID
+		entry.CWD = info.CWD
+		entry.Timestamp = info.Timestamp
+		entry.BadLines = info.BadLines
+		entry.ValidSize = info.ValidSize
+		entry.LastTimestamp = info.LastTimestamp
+		entry.UserMessages = info.UserMessages
+		entry.AssistantMessages = info.AssistantMessages
+		entry.ToolUses = info.ToolUses
+		entry.Version = info.Version
+		entry.GitBranch = info.GitBranch
+		entry.Models = info.Models
+		entry.Usage = info.Usage
+		entry.Summary = info.Summary
+		entry.Slugs = info.Slugs
+	}
+	idx.mu.Lock()
+	idx.entries[path] = entry
+	idx.changed = true
+	idx.mu.Unlock()
+
+	return info, err
+}
+
+// info converts a cached entry back into session metadata.
+func (e indexEntry) info(path string) *SessionInfo {
+	return &SessionInfo{
+		ID:        e.ID,
+		AgentID:   e.AgentID,
+		CWD:       e.CWD,
+		Timestamp: e.Timestamp,
+		FilePath:  path,
+		Size:      e.Size,
+		IsEmpty:   e.Size == 0,
+		BadLines:  e.BadLines,
+		ValidSize: e.ValidSize,
+
+		LastTimestamp:     e.LastTimestamp,
+		UserMessages:      e.UserMessages,
+		AssistantMessages: e.AssistantMessages,
+		ToolUses:          e.ToolUses,
+		Version:           e.Version,
+		GitBranch:         e.GitBranch,
+		Models:            e.Models,
+		Usage:             e.Usage,
+		Summary:           e.Summary,
+		Slugs:             e.Slugs,
+	}
+}
+
+// prune drops the entries of files that were not looked up since the index
+// was loaded, i.e. files that no longer exist.
+func (idx *Index) prune() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for path := range idx.entries {
+		if !idx.seen[path] {
+			delete(idx.entries, path)
+			idx.changed = true
+		}
+	}
+}
+
+// Save atomically writes the index to its file if it changed.
+func (idx *Index) Save() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.changed {
+		return nil
+	}
+
+	data, err := json.Marshal(indexFile{Version: indexVersion, Files: idx.entries})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0700); err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		return err
+	}
+	idx.changed = false
+	return nil
+}
@@ -0,0 +1,99 @@
+package claude
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanWithIndex scans dir with the index stored at indexPath and saves it.
+func scanWithIndex(t *testing.T, dir, indexPath string) ([]Project, *Index) {
+	t.Helper()
+	index, err := LoadIndex(indexPath)
+	require.NoError(t, err)
+	projects, err := ScanProjectsContext(context.Background(), dir, 4, index)
+	require.NoError(t, err)
+	require.NoError(t, index.Save())
+	return projects, index
+}
+
+func TestIndex_ResultsIdenticalWithAndWithoutCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir := filepath.Join(tmpDir, "projects")
+	indexPath := filepath.Join(tmpDir, "cccc-index")
+	writeSyntheticTree(t, dir, 10, 10)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "-p0002", "empty.jsonl"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "-p0004", "broken.jsonl"), []byte("{"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "-p0005", "nocwd.jsonl"), []byte(`{"type":"summary"}`), 0644))
+	full, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sessions", "full.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "-p0006", "full1.jsonl"), full, 0644))
+
+	uncached, err := ScanProjects(dir)
+	require.NoError(t, err)
+
+	// Cold: everything is parsed and indexed
+	cold, index := scanWithIndex(t, dir, indexPath)
+	assert.Equal(t, uncached, cold)
+	hits, misses := index.Stats()
+	assert.Equal(t, 0, hits)
+	assert.Equal(t, 104, misses)
+
+	// Warm: nothing is parsed
+	warm, index := scanWithIndex(t, dir, indexPath)
+	assert.Equal(t, uncached, warm)
+	hits, misses = index.Stats()
+	assert.Equal(t, 104, hits)
+	assert.Equal(t, 0, misses)
+}
+
+func TestIndex_ReparsesChangedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir := filepath.Join(tmpDir, "projects")
+	indexPath := filepath.Join(tmpDir, "cccc-index")
+	writeSyntheticTree(t, dir, 2, 2)
+	scanWithIndex(t, dir, indexPath)
+
+	// Rewrite a session with a different cwd, and remove another one
+	changed := filepath.Join(dir, "-p0000", "s0000-0000.jsonl")
+	require.NoError(t, os.WriteFile(changed, []byte(`{"sessionId":"s0000-0000","cwd":"/moved/elsewhere","timestamp":"2025-03-01T00:00:00Z"}`), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(changed, later, later))
+	require.NoError(t, os.Remove(filepath.Join(dir, "-p0001", "s0001-0001.jsonl")))
+
+	uncached, err := ScanProjects(dir)
+	require.NoError(t, err)
+	projects, index := scanWithIndex(t, dir, indexPath)
+
+	assert.Equal(t, uncached, projects)
+	assert.Equal(t, "/moved/elsewhere", projects[0].ActualPath)
+	hits, misses := index.Stats()
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, 1, misses)
+	assert.Equal(t, 3, index.Len(), "removed files are dropped from the index")
+}
+
+func TestLoadIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	index, err := LoadIndex(filepath.Join(tmpDir, "missing"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, index.Len())
+
+	corrupt := filepath.Join(tmpDir, "corrupt")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{"), 0600))
+	_, err = LoadIndex(corrupt)
+	assert.ErrorContains(t, err, "corrupt index")
+
+	// An index of another version is discarded
+	old := filepath.Join(tmpDir, "old")
+	require.NoError(t, os.WriteFile(old, []byte(`{"version":0,"files":{"/a.jsonl":{"size":1}}}`), 0600))
+	index, err = LoadIndex(old)
+	require.NoError(t, err)
+	assert.Equal(t, 0, index.Len())
+}
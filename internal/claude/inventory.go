@@ -0,0 +1,134 @@
+package claude
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Inventory is the result of a single scan of the projects directory. It is
+// shared by all cleaners of a run, which update it as they remove data so
+// that later cleaners see the current state without rescanning.
+type Inventory struct {
+	Projects []Project
+}
+
+// ScanInventory scans the projects directory into a new inventory; see
+// ScanProjectsContext.
+func ScanInventory(ctx context.Context, projectsDir string, workers int, index *Index) (*Inventory, error) {
+	projects, err := ScanProjectsContext(ctx, projectsDir, workers, index)
+	if err != nil {
+		return nil, err
+	}
+	return &Inventory{Projects: projects}, nil
+}
+
+// SessionIDs returns the IDs of all non-empty sessions.
+func (inv *Inventory) SessionIDs() []string {
+	var ids []string
+	for _, p := range inv.Projects {
+		ids = append(ids, p.SessionIDs...)
+	}
+	return ids
+}
+
+// PlanSlugs returns the slugs of the plans of all sessions.
+func (inv *Inventory) PlanSlugs() []string {
+	var slugs []string
+	for _, p := range inv.Projects {
+		for _, s := range p.Sessions {
+			slugs = append(slugs, s.Slugs...)
+		}
+	}
+	return slugs
+}
+
+// ProjectPaths returns the actual paths of all projects, skipping projects
+// whose path is unknown.
+func (inv *Inventory) ProjectPaths() []string {
+	var paths []string
+	for _, p := range inv.Projects {
+		if p.ActualPath != "" {
+			paths = append(paths, p.ActualPath)
+		}
+	}
+	return paths
+}
+
+// RemoveProject drops a project whose session data has been removed.
+func (inv *Inventory) RemoveProject(encodedName string) {
+	inv.Projects = slices.DeleteFunc(inv.Projects, func(p Project) bool {
+		return p.EncodedName == encodedName
+	})
+}
+
+// RemoveSession drops a session whose file has been removed, together with
+// its agent transcripts.
+func (inv *Inventory) RemoveSession(sessionID string) {
+	for i := range inv.Projects {
+		p := &inv.Projects[i]
+		p.Agents = slices.DeleteFunc(p.Agents, func(a SessionInfo) bool {
+			if a.ID != sessionID {
+				return false
+			}
+			p.FileCount--
+			p.TotalSize -= a.Size
+			return true
+		})
+
+		n := len(p.Sessions)
+		p.Sessions = slices.DeleteFunc(p.Sessions, func(s SessionInfo) bool {
+			if s.ID != sessionID {
+				return false
+			}
+			p.FileCount--
+			p.TotalSize -= s.Size
+			return true
+		})
+		if len(p.Sessions) == n {
+			continue
+		}
+
+		p.SessionIDs = slices.DeleteFunc(p.SessionIDs, func(id string) bool { return id == sessionID })
+		p.LastUsed = lastUsed(p.Sessions)
+	}
+}
+
+// RemoveAgentData drops the agent transcripts at path, or below it if it is
+// a session directory, that have been removed.
+func (inv *Inventory) RemoveAgentData(path string) {
+	prefix := path + string(filepath.Separator)
+	for i := range inv.Projects {
+		p := &inv.Projects[i]
+		p.Agents = slices.DeleteFunc(p.Agents, func(a SessionInfo) bool {
+			if a.FilePath != path && !strings.HasPrefix(a.FilePath, prefix) {
+				return false
+			}
+			p.FileCount--
+			p.TotalSize -= a.Size
+			return true
+		})
+	}
+}
+
+// RemoveEmptySession drops an empty session file that has been removed.
+func (inv *Inventory) RemoveEmptySession(path string) {
+	for i := range inv.Projects {
+		p := &inv.Projects[i]
+		n := len(p.EmptySessions)
+		p.EmptySessions = slices.DeleteFunc(p.EmptySessions, func(s string) bool { return s == path })
+		p.FileCount -= n - len(p.EmptySessions)
+	}
+}
+
+// lastUsed returns the last activity in sessions.
+func lastUsed(sessions []SessionInfo) (last time.Time) {
+	for _, s := range sessions {
+		if t := s.LastActive(); t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
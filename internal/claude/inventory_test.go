@@ -0,0 +1,94 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSyntheticTree creates a projects directory with the given number of
+// projects and sessions per project. Project i is named -p%04d and its
+// sessions s%04d-%04d; session j was last used on day j of 2025.
+func writeSyntheticTree(tb testing.TB, dir string, projects, sessions int) {
+	tb.Helper()
+	for i := 0; i < projects; i++ {
+		projectDir := filepath.Join(dir, fmt.Sprintf("-p%04d", i))
+		require.NoError(tb, os.MkdirAll(projectDir, 0755))
+		for j := 0; j < sessions; j++ {
+			id := fmt.Sprintf("s%04d-%04d", i, j)
+			ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, j).Format(time.RFC3339)
+			content := `{"type":"summary","summary":"synthetic"}` + "\n" +
+				`{"sessionId":"` + id + `","cwd":"/work/p` + fmt.Sprint(i) + `","timestamp":"` + ts + `"}` + "\n" +
+				`{"type":"assistant","message":{"content":"ok"}}` + "\n"
+			require.NoError(tb, os.WriteFile(filepath.Join(projectDir, id+".jsonl"), []byte(content), 0644))
+		}
+	}
+}
+
+func TestInventory(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSyntheticTree(t, tmpDir, 2, 3)
+	empty := filepath.Join(tmpDir, "-p0001", "empty.jsonl")
+	require.NoError(t, os.WriteFile(empty, nil, 0644))
+
+	inv, err := ScanInventory(context.Background(), tmpDir, 0, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/work/p0", "/work/p1"}, inv.ProjectPaths())
+	assert.Len(t, inv.SessionIDs(), 6)
+
+	// Removing the newest session updates the counts and the last use
+	p := &inv.Projects[0]
+	size := p.TotalSize
+	inv.RemoveSession("s0000-0002")
+	assert.Equal(t, []string{"s0000-0000", "s0000-0001"}, p.SessionIDs)
+	assert.Len(t, p.Sessions, 2)
+	assert.Equal(t, 2, p.FileCount)
+	assert.Less(t, p.TotalSize, size)
+	assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), p.LastUsed)
+
+	inv.RemoveEmptySession(empty)
+	assert.Empty(t, inv.Projects[1].EmptySessions)
+	assert.Equal(t, 3, inv.Projects[1].FileCount)
+
+	inv.RemoveProject("-p0000")
+	require.Len(t, inv.Projects, 1)
+	assert.Equal(t, "-p0001", inv.Projects[0].EncodedName)
+	assert.Len(t, inv.SessionIDs(), 3)
+}
+
+func TestInventory_RemoveAgents(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSyntheticTree(t, tmpDir, 1, 2)
+	projectDir := filepath.Join(tmpDir, "-p0000")
+	subagentsDir := filepath.Join(projectDir, "s0000-0001", "subagents")
+	require.NoError(t, os.MkdirAll(subagentsDir, 0755))
+	agent := func(sessionID string) []byte {
+		return []byte(`{"sessionId":"` + sessionID + `","cwd":"/work/p0","isSidechain":true}` + "\n")
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "agent-a1.jsonl"), agent("s0000-0000"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(subagentsDir, "agent-a2.jsonl"), agent("s0000-0001"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(subagentsDir, "agent-a3.jsonl"), agent("s0000-0001"), 0644))
+
+	inv, err := ScanInventory(context.Background(), tmpDir, 0, nil)
+	require.NoError(t, err)
+	p := &inv.Projects[0]
+	require.Len(t, p.Agents, 3)
+	assert.Equal(t, 5, p.FileCount)
+
+	// Removing a session removes its agents with it
+	inv.RemoveSession("s0000-0000")
+	require.Len(t, p.Agents, 2)
+	assert.Equal(t, 3, p.FileCount)
+
+	inv.RemoveAgentData(filepath.Join(projectDir, "s0000-0001"))
+	assert.Empty(t, p.Agents)
+	assert.Equal(t, 1, p.FileCount)
+	assert.Equal(t, []string{"s0000-0001"}, p.SessionIDs)
+}
@@ -3,6 +3,7 @@ package claude
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 )
 
 // Paths contains the standard Claude Code directory paths.
@@ -13,6 +14,17 @@ type Paths struct {
 	FileHistory string // ~/.claude/file-history
 	SessionEnv  string // ~/.claude/session-env
 	Settings    string // ~/.claude/settings.json
+	State       string // ~/.claude.json, next to the ~/.claude directory
+
+	// ManagedSettings is the system-wide managed-settings.json deployed by
+	// administrators; it takes precedence over all other settings.
+	ManagedSettings string
+
+	ShellSnapshots string // ~/.claude/shell-snapshots, the shell environment captured per session
+	Debug          string // ~/.claude/debug, debug logs
+	Statsig        string // ~/.claude/statsig, feature flag and telemetry caches
+	IDE            string // ~/.claude/ide, lock files of connected IDEs
+	Plans          string // ~/.claude/plans, the plans of plan mode
 }
 
 // DiscoverPaths returns the Claude Code paths for the current user.
@@ -34,5 +46,26 @@ func DiscoverPaths(claudeHome string) (*Paths, error) {
 		FileHistory: filepath.Join(root, "file-history"),
 		SessionEnv:  filepath.Join(root, "session-env"),
 		Settings:    filepath.Join(root, "settings.json"),
+		State:       filepath.Join(filepath.Dir(root), ".claude.json"),
+
+		ManagedSettings: managedSettingsPath(runtime.GOOS),
+
+		ShellSnapshots: filepath.Join(root, "shell-snapshots"),
+		Debug:          filepath.Join(root, "debug"),
+		Statsig:        filepath.Join(root, "statsig"),
+		IDE:            filepath.Join(root, "ide"),
+		Plans:          filepath.Join(root, "plans"),
 	}, nil
 }
+
+// managedSettingsPath returns the location of the managed settings on goos.
+func managedSettingsPath(goos string) string {
+	switch goos {
+	case "darwin":
+		return "/Library/Application Support/ClaudeCode/managed-settings.json"
+	case "windows":
+		return `C:\Program Files\ClaudeCode\managed-settings.json`
+	default:
+		return "/etc/claude-code/managed-settings.json"
+	}
+}
@@ -20,6 +20,7 @@ func TestDiscoverPaths_DefaultLocation(t *testing.T) {
 	assert.Equal(t, expectedRoot, paths.Root)
 	assert.Equal(t, filepath.Join(expectedRoot, "projects"), paths.Projects)
 	assert.Equal(t, filepath.Join(expectedRoot, "settings.json"), paths.Settings)
+	assert.Equal(t, filepath.Join(home, ".claude.json"), paths.State)
 }
 
 func TestDiscoverPaths_CustomLocation(t *testing.T) {
@@ -42,4 +43,16 @@ func TestDiscoverPaths_AllPathsPopulated(t *testing.T) {
 	assert.NotEmpty(t, paths.FileHistory, "FileHistory path should not be empty")
 	assert.NotEmpty(t, paths.SessionEnv, "SessionEnv path should not be empty")
 	assert.NotEmpty(t, paths.Settings, "Settings path should not be empty")
+	assert.NotEmpty(t, paths.State, "State path should not be empty")
+	assert.Equal(t, "/test/home/shell-snapshots", filepath.ToSlash(paths.ShellSnapshots))
+	assert.Equal(t, "/test/home/debug", filepath.ToSlash(paths.Debug))
+	assert.Equal(t, "/test/home/statsig", filepath.ToSlash(paths.Statsig))
+	assert.Equal(t, "/test/home/ide", filepath.ToSlash(paths.IDE))
+	assert.Equal(t, "/test/home/plans", filepath.ToSlash(paths.Plans))
+}
+
+func TestManagedSettingsPath(t *testing.T) {
+	assert.Equal(t, "/etc/claude-code/managed-settings.json", managedSettingsPath("linux"))
+	assert.Equal(t, "/Library/Application Support/ClaudeCode/managed-settings.json", managedSettingsPath("darwin"))
+	assert.Equal(t, `C:\Program Files\ClaudeCode\managed-settings.json`, managedSettingsPath("windows"))
 }
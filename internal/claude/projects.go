@@ -1,87 +1,260 @@
 package claude
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
 	"time"
 )
 
 // Project represents a Claude Code project with its session data.
 type Project struct {
-	EncodedName string    // Directory name: -Users-mhk-Code-ccc
-	ActualPath  string    // From cwd field: /Users/mhk/Code/ccc
-	SessionIDs  []string  // UUIDs of sessions in this project
-	TotalSize   int64     // Bytes used by session files
-	LastUsed    time.Time // Most recent session timestamp
-	FileCount   int       // Number of session files
+	EncodedName   string        // Directory name: -Users-mhk-Code-ccc
+	ActualPath    string        // From cwd field: /Users/mhk/Code/ccc
+	SessionIDs    []string      // UUIDs of sessions in this project
+	TotalSize     int64         // Bytes used by session files
+	LastUsed      time.Time     // Last activity in any session
+	FileCount     int           // Number of session files
+	Sessions      []SessionInfo // Non-empty session files
+	EmptySessions []string      // Paths of 0-byte session files
+	// Agents holds the sidechain transcripts of subagents, which are stored
+	// as agent-<id>.jsonl next to the session files or in the session's
+	// <session-id>/subagents directory. They are part of their parent
+	// session rather than sessions of their own.
+	Agents []SessionInfo
+}
+
+// AgentData returns the agent transcripts of a session: the paths of its
+// agent-<id>.jsonl files next to the session files, and its <session-id>
+// directory if that holds them.
+func (p *Project) AgentData(sessionID string) []string {
+	var paths []string
+	for _, a := range p.Agents {
+		if a.ID != sessionID || sessionID == "" {
+			continue
+		}
+		path := a.FilePath
+		if agentParent(path) != "" {
+			path = filepath.Dir(filepath.Dir(path))
+		}
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
 }
 
 // Exists checks if the project's actual path exists on disk.
 func (p *Project) Exists() bool {
-	if p.ActualPath == "" {
+	return pathExists(p.ActualPath)
+}
+
+// CorruptSessions returns the sessions whose files have invalid lines.
+func (p *Project) CorruptSessions() []SessionInfo {
+	var corrupt []SessionInfo
+	for _, s := range p.Sessions {
+		if s.IsCorrupt() {
+			corrupt = append(corrupt, s)
+		}
+	}
+	return corrupt
+}
+
+// Activity sums up the metadata of a project's sessions.
+type Activity struct {
+	UserMessages      int
+	AssistantMessages int
+	Usage             TokenUsage
+	Models            []string // In order of first use
+	Branches          []string // In order of first use
+}
+
+// Activity returns the combined metadata of the project's sessions.
+func (p *Project) Activity() Activity {
+	var a Activity
+	for _, s := range p.Sessions {
+		a.UserMessages += s.UserMessages
+		a.AssistantMessages += s.AssistantMessages
+		a.Usage.Add(s.Usage)
+		for _, m := range s.Models {
+			if !slices.Contains(a.Models, m) {
+				a.Models = append(a.Models, m)
+			}
+		}
+		if s.GitBranch != "" && !slices.Contains(a.Branches, s.GitBranch) {
+			a.Branches = append(a.Branches, s.GitBranch)
+		}
+	}
+	return a
+}
+
+// pathExists reports whether path is non-empty and exists on disk.
+func pathExists(path string) bool {
+	if path == "" {
 		return false
 	}
-	_, err := os.Stat(p.ActualPath)
+	_, err := os.Stat(path)
 	return err == nil
 }
 
 // ScanProjects scans the projects directory and returns information about each project.
 func ScanProjects(projectsDir string) ([]Project, error) {
+	return ScanProjectsContext(context.Background(), projectsDir, 0, nil)
+}
+
+// sessionJob is a session file to parse, with its slot in the scan results.
+type sessionJob struct {
+	project int
+	index   int
+	path    string
+}
+
+// ScanProjectsContext scans the projects directory like ScanProjects, parsing
+// session files with up to workers goroutines; workers <= 0 uses one per CPU.
+// Project directories are listed while earlier files are being parsed. The
+// result is the same as that of a sequential scan. If ctx is cancelled, the
+// scan stops and returns ctx's error.
+//
+// If index is non-nil, only files that changed since they were indexed are
+// parsed, and the index is updated; the caller saves it.
+func ScanProjectsContext(ctx context.Context, projectsDir string, workers int, index *Index) ([]Project, error) {
 	entries, err := os.ReadDir(projectsDir)
 	if err != nil {
 		return nil, err
 	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
 
 	var projects []Project
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+		if entry.IsDir() {
+			projects = append(projects, Project{EncodedName: entry.Name()})
 		}
+	}
+	files := make([][]string, len(projects))       // Session files per project, in directory order
+	infos := make([][]*SessionInfo, len(projects)) // Parse results, nil for files that failed
+	unreadable := make([]bool, len(projects))
 
-		projectPath := filepath.Join(projectsDir, entry.Name())
-		project := Project{
-			EncodedName: entry.Name(),
-		}
+	parse := ParseSessionFile
+	if index != nil {
+		parse = index.parse
+	}
 
-		// Scan session files in the project directory
+	jobs := make(chan sessionJob)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if info, err := parse(job.path); err == nil {
+					infos[job.project][job.index] = info
+				}
+			}
+		}()
+	}
+
+	// List the project directories and hand out their session files
+feed:
+	for i, project := range projects {
+		projectPath := filepath.Join(projectsDir, project.EncodedName)
 		sessionEntries, err := os.ReadDir(projectPath)
 		if err != nil {
+			unreadable[i] = true
 			continue
 		}
-
 		for _, sessionEntry := range sessionEntries {
 			if sessionEntry.IsDir() {
+				files[i] = append(files[i], subagentFiles(filepath.Join(projectPath, sessionEntry.Name()))...)
 				continue
 			}
 			if filepath.Ext(sessionEntry.Name()) != ".jsonl" {
 				continue
 			}
+			files[i] = append(files[i], filepath.Join(projectPath, sessionEntry.Name()))
+		}
+		infos[i] = make([]*SessionInfo, len(files[i]))
 
-			sessionPath := filepath.Join(projectPath, sessionEntry.Name())
-			info, err := ParseSessionFile(sessionPath)
-			if err != nil {
-				continue
+		for j, path := range files[i] {
+			select {
+			case jobs <- sessionJob{project: i, index: j, path: path}:
+			case <-ctx.Done():
+				break feed
 			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
 
-			project.FileCount++
-			project.TotalSize += info.Size
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if index != nil {
+		index.prune()
+	}
 
-			if !info.IsEmpty {
-				if project.ActualPath == "" {
-					// Normalize path separators for the current OS
-					project.ActualPath = filepath.FromSlash(info.CWD)
-				}
-				if info.ID != "" {
-					project.SessionIDs = append(project.SessionIDs, info.ID)
-				}
-				if info.Timestamp.After(project.LastUsed) {
-					project.LastUsed = info.Timestamp
-				}
+	var scanned []Project
+	for i, project := range projects {
+		if unreadable[i] {
+			continue
+		}
+		for _, info := range infos[i] {
+			if info != nil {
+				project.add(info)
 			}
 		}
+		scanned = append(scanned, project)
+	}
+
+	return scanned, nil
+}
+
+// subagentFiles returns the agent transcripts in the subagents directory of
+// a session directory, if there is one.
+func subagentFiles(sessionDir string) []string {
+	dir := filepath.Join(sessionDir, subagentsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && agentID(path) != "" {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// add records a parsed session file in the project.
+func (p *Project) add(info *SessionInfo) {
+	p.FileCount++
+	p.TotalSize += info.Size
+
+	if info.IsEmpty {
+		p.EmptySessions = append(p.EmptySessions, info.FilePath)
+		return
+	}
 
-		projects = append(projects, project)
+	if p.ActualPath == "" {
+		// Normalize path separators for the current OS
+		p.ActualPath = filepath.FromSlash(info.CWD)
+	}
+	if info.AgentID != "" {
+		p.Agents = append(p.Agents, *info)
+		return
 	}
 
-	return projects, nil
+	p.Sessions = append(p.Sessions, *info)
+	if info.ID != "" {
+		p.SessionIDs = append(p.SessionIDs, info.ID)
+	}
+	if last := info.LastActive(); last.After(p.LastUsed) {
+		p.LastUsed = last
+	}
 }
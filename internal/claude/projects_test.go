@@ -1,9 +1,11 @@
 package claude
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -141,3 +143,109 @@ func TestScanProjects_HandlesEmptySessionFiles(t *testing.T) {
 	require.Len(t, projects, 1)
 	assert.Empty(t, projects[0].ActualPath, "expected empty actual path for project with only empty session files")
 }
+
+func TestScanProjects_RecordsSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-Users-test-sessions")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	content := `{"sessionId":"abc","cwd":"/tmp/test","timestamp":"2025-12-06T10:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "abc.jsonl"), []byte(content), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "empty.jsonl"), nil, 0644))
+
+	projects, err := ScanProjects(tmpDir)
+	require.NoError(t, err)
+
+	require.Len(t, projects, 1)
+	require.Len(t, projects[0].Sessions, 1, "empty sessions are not recorded")
+	assert.Equal(t, []string{filepath.Join(projectDir, "empty.jsonl")}, projects[0].EmptySessions)
+	s := projects[0].Sessions[0]
+	assert.Equal(t, "abc", s.ID)
+	assert.Equal(t, filepath.Join(projectDir, "abc.jsonl"), s.FilePath)
+	assert.Equal(t, int64(len(content)), s.Size)
+}
+
+func TestScanProjects_LastUsedIsLastActivity(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-Users-testuser-Code-webapp")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sessions", "full.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "full1.jsonl"), data, 0644))
+	older := `{"sessionId":"old","cwd":"/Users/testuser/Code/webapp","gitBranch":"main","timestamp":"2025-11-30T10:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "old.jsonl"), []byte(older), 0644))
+
+	projects, err := ScanProjects(tmpDir)
+	require.NoError(t, err)
+
+	require.Len(t, projects, 1)
+	assert.Equal(t, time.Date(2025, 12, 2, 14, 2, 0, 0, time.UTC), projects[0].LastUsed)
+
+	activity := projects[0].Activity()
+	assert.Equal(t, 2, activity.UserMessages)
+	assert.Equal(t, 3, activity.AssistantMessages)
+	assert.Equal(t, int64(2110), activity.Usage.Total())
+	assert.Len(t, activity.Models, 2)
+	assert.ElementsMatch(t, []string{"main", "fix-upload"}, activity.Branches)
+}
+
+func TestScanProjectsContext_MatchesSequentialScan(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSyntheticTree(t, tmpDir, 20, 15)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "-p0003", "empty.jsonl"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "-p0007", "broken.jsonl"), []byte("{"), 0644))
+
+	sequential, err := ScanProjectsContext(context.Background(), tmpDir, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, sequential, 20)
+
+	for _, workers := range []int{2, 8, 64} {
+		parallel, err := ScanProjectsContext(context.Background(), tmpDir, workers, nil)
+		require.NoError(t, err)
+		assert.Equal(t, sequential, parallel, "workers=%d", workers)
+	}
+}
+
+func TestScanProjectsContext_Cancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSyntheticTree(t, tmpDir, 5, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	projects, err := ScanProjectsContext(ctx, tmpDir, 2, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, projects)
+}
+
+func TestScanProjects_RecordsAgents(t *testing.T) {
+	tmpDir := t.TempDir()
+	projectDir := filepath.Join(tmpDir, "-Users-test-agents")
+	subagentsDir := filepath.Join(projectDir, "abc", "subagents")
+	require.NoError(t, os.MkdirAll(subagentsDir, 0755))
+
+	session := `{"sessionId":"abc","cwd":"/tmp/test","timestamp":"2025-12-06T10:00:00Z"}`
+	agent := `{"sessionId":"abc","agentId":"a1","isSidechain":true,"cwd":"/tmp/test","timestamp":"2025-12-06T11:00:00Z"}`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "abc.jsonl"), []byte(session), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "agent-a1.jsonl"), []byte(agent), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(subagentsDir, "agent-a2.jsonl"), []byte(agent), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(subagentsDir, "notes.txt"), []byte("x"), 0644))
+
+	projects, err := ScanProjects(tmpDir)
+	require.NoError(t, err)
+
+	require.Len(t, projects, 1)
+	p := projects[0]
+	assert.Equal(t, []string{"abc"}, p.SessionIDs, "agent transcripts are not sessions")
+	require.Len(t, p.Agents, 2)
+	for _, a := range p.Agents {
+		assert.Equal(t, "abc", a.ID)
+	}
+	assert.ElementsMatch(t, []string{"a1", "a2"}, []string{p.Agents[0].AgentID, p.Agents[1].AgentID})
+	assert.Equal(t, 3, p.FileCount)
+	assert.Equal(t, int64(len(session)+2*len(agent)), p.TotalSize)
+	assert.Equal(t, time.Date(2025, 12, 6, 10, 0, 0, 0, time.UTC), p.LastUsed.UTC())
+	assert.ElementsMatch(t, []string{filepath.Join(projectDir, "agent-a1.jsonl"), filepath.Join(projectDir, "abc")}, p.AgentData("abc"))
+	assert.Empty(t, p.AgentData("other"))
+}
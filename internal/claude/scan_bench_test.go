@@ -0,0 +1,136 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// The synthetic tree used by the benchmarks: 1k projects with 50 sessions
+// each. It is created once per test binary, on first use.
+const (
+	benchProjects = 1000
+	benchSessions = 50
+)
+
+var (
+	benchOnce sync.Once
+	benchDir  string
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchDir != "" {
+		_ = os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// benchTree returns the projects directory of the synthetic tree.
+func benchTree(b *testing.B) string {
+	benchOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "cccc-bench-")
+		if err != nil {
+			b.Fatal(err)
+		}
+		benchDir = dir
+		writeSyntheticTree(b, dir, benchProjects, benchSessions)
+	})
+	if benchDir == "" {
+		b.Fatal("synthetic tree could not be created")
+	}
+	return benchDir
+}
+
+// BenchmarkScanProjects compares the sequential scan with the worker pool.
+func BenchmarkScanProjects(b *testing.B) {
+	dir := benchTree(b)
+
+	counts := []int{1, 4, runtime.GOMAXPROCS(0)}
+	slices.Sort(counts)
+	for _, workers := range slices.Compact(counts) {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := ScanProjectsContext(context.Background(), dir, workers, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkScanProjectsIndexed measures a scan in which no file changed
+// since the index was written.
+func BenchmarkScanProjectsIndexed(b *testing.B) {
+	dir := benchTree(b)
+
+	index := NewIndex(filepath.Join(b.TempDir(), "cccc-index"))
+	if _, err := ScanProjectsContext(context.Background(), dir, 0, index); err != nil {
+		b.Fatal(err)
+	}
+	if err := index.Save(); err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		index, err := LoadIndex(index.Path())
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := ScanProjectsContext(context.Background(), dir, 0, index); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCleanAllScans compares a plain "cccc clean" that scans once per
+// cleaner, as before, with a single parallel scan into a shared inventory.
+func BenchmarkCleanAllScans(b *testing.B) {
+	dir := benchTree(b)
+
+	b.Run("rescan-per-cleaner", func(b *testing.B) {
+		for b.Loop() {
+			for range 3 { // projects, orphans, config
+				if _, err := ScanProjectsContext(context.Background(), dir, 1, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("shared-inventory", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := ScanInventory(context.Background(), dir, 0, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkSearchFiles compares a literal pattern, whose lines are filtered
+// before they are decoded, with a regular expression, which decodes every
+// line.
+func BenchmarkSearchFiles(b *testing.B) {
+	dir := benchTree(b)
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.jsonl"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, pattern := range []string{"needle", `ne+dle`} {
+		b.Run(pattern, func(b *testing.B) {
+			opts := SearchOptions{Pattern: regexp.MustCompile(pattern)}
+			for b.Loop() {
+				if err := SearchFiles(context.Background(), paths, opts, func(SearchMatch) {}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles of searched transcript text.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool" // Tool calls and their results
+)
+
+// snippetContext is the number of characters shown on each side of a match.
+const snippetContext = 40
+
+// searchReaders holds the buffered readers of finished files for reuse, as
+// most session files are small.
+var searchReaders = sync.Pool{
+	New: func() any { return bufio.NewReaderSize(nil, 64<<10) },
+}
+
+// SearchOptions selects what SearchFiles looks for. Zero values disable the
+// corresponding condition.
+type SearchOptions struct {
+	Pattern      *regexp.Regexp
+	Roles        []string // RoleUser, RoleAssistant or RoleTool
+	Since, Until time.Time
+	Workers      int // Files searched at once; <= 0 uses one per CPU
+}
+
+// SearchMatch is a block of transcript text that matches a search.
+type SearchMatch struct {
+	Path      string
+	Line      int // 1-based line number in the session file
+	SessionID string
+	Timestamp time.Time
+	Role      string
+	Snippet   string // The match with some context, on a single line
+}
+
+// SearchFiles searches session files for text matching opts, reading up to
+// opts.Workers files at once. fn is called from a single goroutine with the
+// matches of each file, in the order of paths, as soon as the file and all
+// files before it are done. Files that cannot be read are skipped. If ctx is
+// cancelled, the search stops and returns ctx's error.
+func SearchFiles(ctx context.Context, paths []string, opts SearchOptions, fn func(SearchMatch)) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// Limit how far workers may run ahead of the oldest file not yet
+	// reported, so that memory use does not grow with the number of files
+	ahead := make(chan struct{}, 2*workers)
+	results := make([]chan []SearchMatch, len(paths))
+	for i := range results {
+		results[i] = make(chan []SearchMatch, 1)
+	}
+
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				matches, _ := searchSessionFile(paths[i], opts)
+				results[i] <- matches
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case ahead <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := range paths {
+		select {
+		case matches := <-results[i]:
+			for _, m := range matches {
+				fn(m)
+			}
+			<-ahead
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return ctx.Err()
+}
+
+// searchSessionFile returns the matches in a single session file. On a read
+// error, the matches found up to that point are returned with the error.
+func searchSessionFile(path string, opts SearchOptions) ([]SearchMatch, error) {
+	cleanPath := filepath.Clean(path)
+	file, err := os.Open(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	literal := jsonLiteral(opts.Pattern)
+
+	var matches []SearchMatch
+	reader := searchReaders.Get().(*bufio.Reader)
+	reader.Reset(file)
+	defer func() {
+		reader.Reset(nil)
+		searchReaders.Put(reader)
+	}()
+	for n := 1; ; n++ {
+		line, readErr := reader.ReadBytes('\n')
+
+		// Most lines do not contain a literal pattern, and are skipped
+		// without being decoded
+		if len(line) > 0 && (literal == nil || bytes.Contains(line, literal)) {
+			matches = append(matches, searchLine(line, n, path, opts)...)
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return matches, readErr
+		}
+	}
+	return matches, nil
+}
+
+// jsonLiteral returns the text matched by pattern if it is a literal string
+// that appears unchanged in JSON, or nil otherwise.
+func jsonLiteral(pattern *regexp.Regexp) []byte {
+	if pattern == nil {
+		return nil
+	}
+	prefix, complete := pattern.LiteralPrefix()
+	if !complete || prefix == "" {
+		return nil
+	}
+	for _, c := range []byte(prefix) {
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			return nil
+		}
+	}
+	return []byte(prefix)
+}
+
+// searchLine returns the matching blocks of text of a single line. Invalid
+// lines, meta messages and lines outside the date range are skipped.
+func searchLine(line []byte, n int, path string, opts SearchOptions) []SearchMatch {
+	var sl sessionLine
+	if err := json.Unmarshal(line, &sl); err != nil || sl.Message == nil || sl.IsMeta {
+		return nil
+	}
+	if !opts.Since.IsZero() && sl.Timestamp.Before(opts.Since) {
+		return nil
+	}
+	if !opts.Until.IsZero() && sl.Timestamp.After(opts.Until) {
+		return nil
+	}
+
+	var matches []SearchMatch
+	for _, t := range lineTexts(&sl) {
+		if len(opts.Roles) > 0 && !slices.Contains(opts.Roles, t.role) {
+			continue
+		}
+		snippet, ok := matchSnippet(opts.Pattern, t.text)
+		if !ok {
+			continue
+		}
+		matches = append(matches, SearchMatch{
+			Path:      path,
+			Line:      n,
+			SessionID: sl.SessionID,
+			Timestamp: sl.Timestamp,
+			Role:      t.role,
+			Snippet:   snippet,
+		})
+	}
+	return matches
+}
+
+// roleText is a block of text of a message, with the role it is searched as.
+type roleText struct {
+	role string
+	text string
+}
+
+// lineTexts returns the searchable blocks of text of a user or assistant
+// line: prompts, replies, and the inputs and results of tool calls.
+func lineTexts(sl *sessionLine) []roleText {
+	if sl.Type != "user" && sl.Type != "assistant" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(sl.Message.Content, &text); err == nil {
+		return []roleText{{sl.Type, text}}
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(sl.Message.Content, &blocks); err != nil {
+		return nil
+	}
+	var texts []roleText
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			texts = append(texts, roleText{sl.Type, b.Text})
+		case "tool_use":
+			texts = append(texts, roleText{RoleTool, toolInput(b)})
+		case "tool_result":
+			texts = append(texts, roleText{RoleTool, toolResult(b.Content)})
+		}
+	}
+	return texts
+}
+
+// toolInput returns the name and the string inputs of a tool call.
+func toolInput(b contentBlock) string {
+	keys := make([]string, 0, len(b.Input))
+	for key := range b.Input {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	parts := []string{b.Name}
+	for _, key := range keys {
+		if v, ok := b.Input[key].(string); ok {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// toolResult returns the text of a tool result, which is either a string or
+// a list of content blocks.
+func toolResult(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			texts = append(texts, b.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// matchSnippet returns the first match of pattern in text with up to
+// snippetContext characters on each side, with runs of whitespace collapsed
+// into single spaces. It reports false if text does not match.
+func matchSnippet(pattern *regexp.Regexp, text string) (string, bool) {
+	loc := pattern.FindStringIndex(text)
+	if loc == nil {
+		return "", false
+	}
+
+	// A rune takes up to 4 bytes, so the context is within these windows
+	window := 4*snippetContext + 4
+	before := []rune(text[max(0, loc[0]-window):loc[0]])
+	after := []rune(text[loc[1]:min(len(text), loc[1]+window)])
+	prefix, suffix := "", ""
+	if len(before) > snippetContext {
+		before = before[len(before)-snippetContext:]
+		prefix = "..."
+	}
+	if len(after) > snippetContext {
+		after = after[:snippetContext]
+		suffix = "..."
+	}
+	snippet := string(before) + text[loc[0]:loc[1]] + string(after)
+	return prefix + strings.Join(strings.Fields(snippet), " ") + suffix, true
+}
@@ -0,0 +1,123 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func search(t *testing.T, paths []string, opts SearchOptions) []SearchMatch {
+	t.Helper()
+	var matches []SearchMatch
+	require.NoError(t, SearchFiles(context.Background(), paths, opts, func(m SearchMatch) {
+		matches = append(matches, m)
+	}))
+	return matches
+}
+
+func TestSearchFiles(t *testing.T) {
+	path := filepath.Join("..", "..", "testdata", "sessions", "full.jsonl")
+
+	tests := []struct {
+		name    string
+		pattern string
+		roles   []string
+		want    []string // role:line
+	}{
+		{"prompt", "upload test", nil, []string{"user:3"}},
+		{"regex", `temp\s+dir`, nil, []string{"user:8"}},
+		{"tool input", "upload_test.go", nil, []string{"tool:5"}},
+		{"tool result", "package webapp", nil, []string{"tool:6"}},
+		{"role filter", "(?i)upload", []string{RoleTool}, []string{"tool:5"}},
+		{"all roles", "(?i)upload", nil, []string{"user:3", "tool:5"}},
+		{"meta messages are skipped", "Caveat", nil, nil},
+		{"summaries are skipped", "flaky", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := search(t, []string{path}, SearchOptions{Pattern: regexp.MustCompile(tt.pattern), Roles: tt.roles})
+			var got []string
+			for _, m := range matches {
+				assert.Equal(t, "full1", m.SessionID)
+				assert.Equal(t, path, m.Path)
+				got = append(got, fmt.Sprintf("%s:%d", m.Role, m.Line))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSearchFiles_DateRange(t *testing.T) {
+	path := filepath.Join("..", "..", "testdata", "sessions", "full.jsonl")
+	opts := SearchOptions{
+		Pattern: regexp.MustCompile("."),
+		Roles:   []string{RoleUser},
+		Since:   time.Date(2025, 12, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	matches := search(t, []string{path}, opts)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 8, matches[0].Line)
+	assert.Equal(t, time.Date(2025, 12, 2, 14, 0, 10, 0, time.UTC), matches[0].Timestamp)
+
+	opts.Since, opts.Until = time.Time{}, time.Date(2025, 12, 1, 23, 0, 0, 0, time.UTC)
+	matches = search(t, []string{path}, opts)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 3, matches[0].Line)
+}
+
+func TestSearchFiles_OrderAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := range 20 {
+		path := filepath.Join(dir, fmt.Sprintf("s%02d.jsonl", i))
+		line := fmt.Sprintf(`{"type":"user","sessionId":"s%02d","message":{"role":"user","content":"needle %d"}}`, i, i)
+		require.NoError(t, os.WriteFile(path, []byte("not json\n"+line+"\n"), 0644))
+		paths = append(paths, path)
+	}
+	paths = append(paths[:5], append([]string{filepath.Join(dir, "missing.jsonl")}, paths[5:]...)...)
+
+	matches := search(t, paths, SearchOptions{Pattern: regexp.MustCompile("needle"), Workers: 3})
+	require.Len(t, matches, 20)
+	for i, m := range matches {
+		assert.Equal(t, fmt.Sprintf("s%02d", i), m.SessionID)
+		assert.Equal(t, 2, m.Line)
+	}
+}
+
+func TestSearchFiles_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	path := filepath.Join("..", "..", "testdata", "sessions", "full.jsonl")
+	err := SearchFiles(ctx, []string{path}, SearchOptions{Pattern: regexp.MustCompile("x")}, func(SearchMatch) {})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestJSONLiteral(t *testing.T) {
+	assert.Equal(t, []byte("upload test"), jsonLiteral(regexp.MustCompile("upload test")))
+	assert.Nil(t, jsonLiteral(regexp.MustCompile(`upload\s+test`)))
+	assert.Nil(t, jsonLiteral(regexp.MustCompile(`say "hi"`)), "quotes are escaped in JSON")
+	assert.Nil(t, jsonLiteral(regexp.MustCompile("(?i)upload")))
+	assert.Nil(t, jsonLiteral(regexp.MustCompile("größe")), "non-ASCII may be escaped")
+}
+
+func TestMatchSnippet(t *testing.T) {
+	text := "first line\npadding padding padding padding padding padding the   needle\tand more text that goes on and on and on"
+	snippet, ok := matchSnippet(regexp.MustCompile("needle"), text)
+	require.True(t, ok)
+	assert.Equal(t, "...g padding padding padding padding the needle and more text that goes on and on and o...", snippet)
+
+	snippet, ok = matchSnippet(regexp.MustCompile("line"), "first line\nsecond")
+	require.True(t, ok)
+	assert.Equal(t, "first line second", snippet)
+
+	_, ok = matchSnippet(regexp.MustCompile("absent"), "text")
+	assert.False(t, ok)
+}
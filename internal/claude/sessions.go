@@ -2,34 +2,127 @@ package claude
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
 // SessionInfo contains metadata extracted from a session file.
 type SessionInfo struct {
-	ID        string
-	CWD       string
-	Timestamp time.Time
-	FilePath  string
-	Size      int64
-	IsEmpty   bool
+	ID            string // For agent transcripts, the ID of the parent session
+	AgentID       string // ID of the subagent, for agent transcripts
+	CWD           string
+	Timestamp     time.Time // Earliest timestamp, when the session started
+	LastTimestamp time.Time // Latest timestamp, the last activity
+	FilePath      string
+	Size          int64
+	IsEmpty       bool
+	BadLines      int   // Lines that are not valid JSON
+	ValidSize     int64 // Offset just past the last valid line
+
+	UserMessages      int // Prompts, not counting tool results and meta messages
+	AssistantMessages int
+	ToolUses          int      // Tool calls of assistant messages
+	Version           string   // Claude Code version of the last line that has one
+	GitBranch         string   // Git branch of the last line that has one
+	Models            []string // Models used by assistant messages, in order of first use
+	Usage             TokenUsage
+	Summary           string // The last summary line
+	// Slugs name the plans of the session, ~/.claude/plans/<slug>.md, in
+	// order of first use.
+	Slugs []string
+}
+
+// TokenUsage holds token totals of assistant messages.
+type TokenUsage struct {
+	Input         int64 `json:"input,omitempty"`
+	Output        int64 `json:"output,omitempty"`
+	CacheCreation int64 `json:"cacheCreation,omitempty"`
+	CacheRead     int64 `json:"cacheRead,omitempty"`
+}
+
+// Add adds the totals of u to the receiver.
+func (t *TokenUsage) Add(u TokenUsage) {
+	t.Input += u.Input
+	t.Output += u.Output
+	t.CacheCreation += u.CacheCreation
+	t.CacheRead += u.CacheRead
+}
+
+// Total returns the sum of all token counts.
+func (t TokenUsage) Total() int64 {
+	return t.Input + t.Output + t.CacheCreation + t.CacheRead
+}
+
+// LastActive returns the time of the last activity in the session, falling
+// back to its start for sessions without a later timestamp.
+func (s *SessionInfo) LastActive() time.Time {
+	if s.LastTimestamp.After(s.Timestamp) {
+		return s.LastTimestamp
+	}
+	return s.Timestamp
+}
+
+// Messages returns the number of user and assistant messages.
+func (s *SessionInfo) Messages() int {
+	return s.UserMessages + s.AssistantMessages
+}
+
+// IsCorrupt reports whether the session file has lines that are not valid JSON.
+func (s *SessionInfo) IsCorrupt() bool {
+	return s.BadLines > 0
+}
+
+// IsTruncated reports whether the session file ends in invalid data, e.g. a
+// line that was only half written when Claude Code crashed.
+func (s *SessionInfo) IsTruncated() bool {
+	return s.ValidSize < s.Size && s.IsCorrupt()
 }
 
 // sessionLine represents a single line from a session JSONL file.
 type sessionLine struct {
+	Type      string    `json:"type"`
 	SessionID string    `json:"sessionId"`
 	CWD       string    `json:"cwd"`
 	Timestamp time.Time `json:"timestamp"`
+	Version   string    `json:"version"`
+	GitBranch string    `json:"gitBranch"`
+	IsMeta    bool      `json:"isMeta"`
+	Summary   string    `json:"summary"`
+	Slug      string    `json:"slug"`
+	Message   *struct {
+		ID      string          `json:"id"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+		Usage   *struct {
+			Input         int64 `json:"input_tokens"`
+			Output        int64 `json:"output_tokens"`
+			CacheCreation int64 `json:"cache_creation_input_tokens"`
+			CacheRead     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
 }
 
+// syntheticModel is the model of assistant messages that Claude Code writes
+// itself, such as API error notices.
+const syntheticModel = "<synthetic>"
+
 // ErrNoCWD is returned when no cwd field can be found in session files.
 var ErrNoCWD = errors.New("no cwd field found in session files")
 
-// ParseSessionFile reads a session JSONL file and extracts metadata.
+// ParseSessionFile reads a session JSONL file and extracts metadata. The
+// whole file is read once: the ID and cwd come from the first line that has
+// a cwd, the rest is collected from all lines. Lines that are not valid JSON
+// are skipped and counted in BadLines. A file without a cwd is still
+// returned, with the ID taken from its file name, if it is corrupt, so that
+// its session is not mistaken for a deleted one, or if it holds a summary, as
+// the summary-only files that Claude Code leaves behind do.
 func ParseSessionFile(path string) (*SessionInfo, error) {
 	stat, err := os.Stat(path)
 	if err != nil {
@@ -39,6 +132,7 @@ func ParseSessionFile(path string) (*SessionInfo, error) {
 	info := &SessionInfo{
 		FilePath: path,
 		Size:     stat.Size(),
+		AgentID:  agentID(path),
 	}
 
 	if stat.Size() == 0 {
@@ -53,29 +147,185 @@ func ParseSessionFile(path string) (*SessionInfo, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
+	// Read whole lines, however long, so that every bad line is counted
+	reader := bufio.NewReader(file)
+	var offset int64
+	found := false
+	// An assistant message is written as one line per content block, all
+	// with the same message ID and usage, so they are counted once
+	usage := make(map[string]TokenUsage)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		offset += int64(len(line))
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var sl sessionLine
+			if err := json.Unmarshal(line, &sl); err != nil {
+				info.BadLines++
+			} else {
+				info.ValidSize = offset
+				if !found && sl.CWD != "" {
+					info.ID = sl.SessionID
+					info.CWD = sl.CWD
+					found = true
+				}
+				info.addLine(&sl, usage)
+			}
 		}
 
-		var sl sessionLine
-		if err := json.Unmarshal(line, &sl); err != nil {
-			return nil, err
+		if readErr == io.EOF {
+			break
 		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+	for _, u := range usage {
+		info.Usage.Add(u)
+	}
 
-		if sl.CWD != "" {
-			info.ID = sl.SessionID
-			info.CWD = sl.CWD
-			info.Timestamp = sl.Timestamp
-			return info, nil
+	if !found {
+		if !info.IsCorrupt() && info.Summary == "" {
+			return nil, ErrNoCWD
+		}
+		info.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if info.AgentID != "" {
+			info.ID = agentParent(path)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	return info, nil
+}
+
+// agentID returns the ID of the subagent whose sidechain transcript is
+// stored at path, agent-<id>.jsonl, or "" if path is a session transcript.
+func agentID(path string) string {
+	name := filepath.Base(path)
+	if !strings.HasPrefix(name, "agent-") || filepath.Ext(name) != ".jsonl" {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, "agent-"), ".jsonl")
+}
+
+// agentParent returns the ID of the parent session of the agent transcript
+// at path if it is stored in <session-id>/subagents, or "" if it is stored
+// next to the session transcripts, where only its lines name the parent.
+func agentParent(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) != subagentsDir {
+		return ""
 	}
+	return filepath.Base(filepath.Dir(dir))
+}
+
+// subagentsDir is the directory below <session-id> that holds the agent
+// transcripts of a session in newer versions of Claude Code.
+const subagentsDir = "subagents"
 
-	return nil, ErrNoCWD
+// addLine records the metadata of a valid line. The usage of assistant
+// messages with an ID is collected in usage, to be added up at the end.
+func (s *SessionInfo) addLine(sl *sessionLine, usage map[string]TokenUsage) {
+	if !sl.Timestamp.IsZero() {
+		if s.Timestamp.IsZero() || sl.Timestamp.Before(s.Timestamp) {
+			s.Timestamp = sl.Timestamp
+		}
+		if sl.Timestamp.After(s.LastTimestamp) {
+			s.LastTimestamp = sl.Timestamp
+		}
+	}
+	if sl.Version != "" {
+		s.Version = sl.Version
+	}
+	if sl.GitBranch != "" {
+		s.GitBranch = sl.GitBranch
+	}
+	if sl.Slug != "" && !slices.Contains(s.Slugs, sl.Slug) {
+		s.Slugs = append(s.Slugs, sl.Slug)
+	}
+
+	switch sl.Type {
+	case "summary":
+		if sl.Summary != "" {
+			s.Summary = sl.Summary
+		}
+	case "user":
+		if !sl.IsMeta && sl.Message != nil && isPrompt(sl.Message.Content) {
+			s.UserMessages++
+		}
+	case "assistant":
+		m := sl.Message
+		if m == nil {
+			return
+		}
+		if m.Model != "" && m.Model != syntheticModel && !slices.Contains(s.Models, m.Model) {
+			s.Models = append(s.Models, m.Model)
+		}
+		s.ToolUses += toolUses(m.Content)
+
+		var u TokenUsage
+		if m.Usage != nil {
+			u = TokenUsage{Input: m.Usage.Input, Output: m.Usage.Output, CacheCreation: m.Usage.CacheCreation, CacheRead: m.Usage.CacheRead}
+		}
+		if m.ID == "" {
+			s.AssistantMessages++
+			s.Usage.Add(u)
+			return
+		}
+		if _, seen := usage[m.ID]; !seen {
+			s.AssistantMessages++
+			usage[m.ID] = u
+		} else if m.Usage != nil {
+			// Later lines of a message carry the final totals
+			usage[m.ID] = u
+		}
+	}
+}
+
+// toolUses returns the number of tool calls in the content of an assistant
+// message. Each line of a message holds different content blocks, so they
+// can be added up across lines.
+func toolUses(content json.RawMessage) int {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 || content[0] != '[' {
+		return 0
+	}
+
+	var blocks []struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return 0
+	}
+	n := 0
+	for _, b := range blocks {
+		if b.Type == "tool_use" {
+			n++
+		}
+	}
+	return n
+}
+
+// isPrompt reports whether the content of a user message was written by the
+// user, rather than being only the results of tool calls.
+func isPrompt(content json.RawMessage) bool {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return false
+	}
+	if content[0] == '"' {
+		return true
+	}
+
+	var blocks []struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return false
+	}
+	for _, b := range blocks {
+		if b.Type != "tool_result" {
+			return true
+		}
+	}
+	return false
 }
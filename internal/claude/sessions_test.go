@@ -3,7 +3,9 @@ package claude
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,8 +39,57 @@ func TestParseSessionFile_EmptyFile(t *testing.T) {
 func TestParseSessionFile_MalformedJSON(t *testing.T) {
 	path := testdataPath(t, "malformed.jsonl")
 
-	_, err := ParseSessionFile(path)
-	assert.Error(t, err, "expected error for malformed JSON")
+	// A file without a single valid line is reported as corrupt rather than
+	// skipped, so that its session does not look deleted
+	info, err := ParseSessionFile(path)
+	require.NoError(t, err)
+	assert.True(t, info.IsCorrupt())
+	assert.Equal(t, 2, info.BadLines)
+	assert.Equal(t, "malformed", info.ID)
+	assert.Empty(t, info.CWD)
+	assert.Equal(t, int64(0), info.ValidSize)
+}
+
+func TestParseSessionFile_TruncatedLastLine(t *testing.T) {
+	path := testdataPath(t, "truncated.jsonl")
+
+	info, err := ParseSessionFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "trunc1", info.ID)
+	assert.Equal(t, "/Users/testuser/Code/crashed", info.CWD)
+	assert.Equal(t, 1, info.BadLines)
+	assert.True(t, info.IsTruncated())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "}\n", string(data[info.ValidSize-2:info.ValidSize]), "valid data ends after the last complete line")
+}
+
+func TestParseSessionFile_BadLineInTheMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	content := `{"sessionId":"s","cwd":"/p","timestamp":"2025-12-06T10:00:00Z"}` + "\n" +
+		"garbage\n" +
+		`{"type":"assistant"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	info, err := ParseSessionFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, info.BadLines)
+	assert.False(t, info.IsTruncated(), "the file ends in a valid line")
+	assert.Equal(t, info.Size, info.ValidSize)
+}
+
+func TestParseSessionFile_LongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	long := `{"type":"tool_result","content":"` + strings.Repeat("x", 1<<20) + `"}`
+	content := long + "\n" + `{"sessionId":"s","cwd":"/p"}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	info, err := ParseSessionFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "/p", info.CWD)
+	assert.Zero(t, info.BadLines)
 }
 
 func TestParseSessionFile_MissingCWDField(t *testing.T) {
@@ -48,6 +99,19 @@ func TestParseSessionFile_MissingCWDField(t *testing.T) {
 	assert.Error(t, err, "expected error for missing cwd field")
 }
 
+func TestParseSessionFile_SummaryOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "0b1c2d3e.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"summary","summary":"Fix bug","leafUuid":"x"}`+"\n"), 0644))
+
+	// Summary lines have no cwd, but the file is still a session of its project
+	info, err := ParseSessionFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "0b1c2d3e", info.ID)
+	assert.Equal(t, "Fix bug", info.Summary)
+	assert.Empty(t, info.CWD)
+	assert.False(t, info.IsCorrupt())
+}
+
 func TestParseSessionFile_NonExistentFile(t *testing.T) {
 	path := testdataPath(t, "does_not_exist.jsonl")
 
@@ -66,3 +130,56 @@ func TestParseSessionFile_ReturnsFileSize(t *testing.T) {
 
 	assert.Equal(t, stat.Size(), info.Size)
 }
+
+func TestParseSessionFile_FullMetadata(t *testing.T) {
+	path := testdataPath(t, "full.jsonl")
+
+	info, err := ParseSessionFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "full1", info.ID)
+	assert.Equal(t, "/Users/testuser/Code/webapp", info.CWD)
+	assert.Equal(t, time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC), info.Timestamp)
+	assert.Equal(t, time.Date(2025, 12, 2, 14, 2, 0, 0, time.UTC), info.LastTimestamp)
+	assert.Equal(t, info.LastTimestamp, info.LastActive())
+
+	// Tool results and meta messages are not prompts, and the two lines of
+	// msg_1 are a single message
+	assert.Equal(t, 2, info.UserMessages)
+	assert.Equal(t, 3, info.AssistantMessages)
+	assert.Equal(t, 1, info.ToolUses)
+
+	assert.Equal(t, "2.0.62", info.Version)
+	assert.Equal(t, "fix-upload", info.GitBranch)
+	assert.Equal(t, []string{"claude-sonnet-4-5-20250929", "claude-opus-4-1-20250805"}, info.Models)
+	assert.Equal(t, TokenUsage{Input: 30, Output: 80, CacheCreation: 1000, CacheRead: 1000}, info.Usage)
+	assert.Equal(t, "Fix flaky upload test", info.Summary)
+	assert.Equal(t, []string{"fuzzy-wandering-otter"}, info.Slugs)
+	assert.Zero(t, info.BadLines)
+}
+
+func TestSessionInfo_LastActiveFallsBackToStart(t *testing.T) {
+	start := time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC)
+	info := SessionInfo{Timestamp: start}
+
+	assert.Equal(t, start, info.LastActive())
+}
+
+func TestParseSessionFile_Agent(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "parent", "subagents")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	path := filepath.Join(dir, "agent-a1.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"sessionId":"parent","cwd":"/p","isSidechain":true}`+"\n"), 0644))
+	info, err := ParseSessionFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "parent", info.ID)
+	assert.Equal(t, "a1", info.AgentID)
+
+	// A corrupt transcript still belongs to the session it is stored under
+	require.NoError(t, os.WriteFile(path, []byte("{not json\n"), 0644))
+	info, err = ParseSessionFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "parent", info.ID)
+	assert.Equal(t, "a1", info.AgentID)
+}
@@ -0,0 +1,70 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// State is the global Claude Code state file, ~/.claude.json. Only the
+// per-project entries are modelled; the file holds much more and must only be
+// changed through EditFile.
+type State struct {
+	Path     string
+	Projects []StateProject
+}
+
+// StateProject is a single entry of the "projects" map in the state file.
+// Claude Code adds each prompt to the front of the entry's "history" array
+// and drops the entries beyond the 100th, so the array runs from the newest
+// prompt to the oldest.
+type StateProject struct {
+	Path         string   // Absolute project path, the key in "projects"
+	History      int      // Number of prompt history entries, stored newest first
+	AllowedTools int      // Number of allowed tools
+	MCPServers   []string // Names of project-scoped MCP servers
+	Size         int64    // Bytes used by the entry in the state file
+}
+
+// Exists checks if the project's path exists on disk.
+func (p *StateProject) Exists() bool {
+	return pathExists(p.Path)
+}
+
+// LoadState loads the state file at path.
+// Returns an empty State if the file doesn't exist.
+func LoadState(path string) (*State, error) {
+	state := &State{Path: path}
+
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return state, nil
+	}
+
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range doc.Keys("projects") {
+		entry := StateProject{
+			Path:         key,
+			History:      doc.Len("projects", key, "history"),
+			AllowedTools: doc.Len("projects", key, "allowedTools"),
+			MCPServers:   doc.Keys("projects", key, "mcpServers"),
+		}
+		if raw, ok := doc.Raw("projects", key); ok {
+			entry.Size = int64(len(raw))
+		}
+		state.Projects = append(state.Projects, entry)
+	}
+
+	return state, nil
+}
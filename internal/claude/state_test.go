@@ -0,0 +1,65 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadState(t *testing.T) {
+	tmpDir := t.TempDir()
+	existing := filepath.ToSlash(filepath.Join(tmpDir, "existing"))
+	require.NoError(t, os.MkdirAll(existing, 0755))
+
+	content := `{
+  "numStartups": 42,
+  "projects": {
+    "` + existing + `": {
+      "allowedTools": ["Bash(ls:*)"],
+      "history": [{"display": "hi"}, {"display": "there"}],
+      "mcpServers": {"db": {"command": "db-mcp"}, "web": {"command": "web-mcp"}},
+      "hasTrustDialogAccepted": true
+    },
+    "/gone/project": {
+      "allowedTools": [],
+      "history": []
+    }
+  }
+}`
+	statePath := filepath.Join(tmpDir, ".claude.json")
+	require.NoError(t, os.WriteFile(statePath, []byte(content), 0600))
+
+	state, err := LoadState(statePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, statePath, state.Path)
+	require.Len(t, state.Projects, 2)
+
+	p := state.Projects[0]
+	assert.Equal(t, existing, p.Path)
+	assert.Equal(t, 2, p.History)
+	assert.Equal(t, 1, p.AllowedTools)
+	assert.Equal(t, []string{"db", "web"}, p.MCPServers)
+	assert.Greater(t, p.Size, int64(0))
+	assert.True(t, p.Exists())
+
+	assert.Equal(t, "/gone/project", state.Projects[1].Path)
+	assert.False(t, state.Projects[1].Exists())
+}
+
+func TestLoadState_Missing(t *testing.T) {
+	state, err := LoadState(filepath.Join(t.TempDir(), ".claude.json"))
+	require.NoError(t, err)
+	assert.Empty(t, state.Projects)
+}
+
+func TestLoadState_Invalid(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), ".claude.json")
+	require.NoError(t, os.WriteFile(statePath, []byte(`{"projects":`), 0600))
+
+	_, err := LoadState(statePath)
+	assert.Error(t, err)
+}
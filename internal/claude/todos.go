@@ -0,0 +1,29 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Todo is an item of a todo list that Claude Code keeps per session and agent.
+type Todo struct {
+	Content string `json:"content"`
+	Status  string `json:"status"` // "pending", "in_progress" or "completed"
+}
+
+// LoadTodos reads a todo file from ~/.claude/todos.
+func LoadTodos(path string) ([]Todo, error) {
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []Todo
+	if err := json.Unmarshal(data, &todos); err != nil {
+		return nil, fmt.Errorf("invalid todo file %s: %w", path, err)
+	}
+	return todos, nil
+}
@@ -0,0 +1,136 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is a prompt or reply in a session transcript.
+type Message struct {
+	Timestamp time.Time
+	Role      string   // "user" or "assistant"
+	Text      string   // The text blocks, separated by blank lines
+	Tools     []string // The tool calls, e.g. "Read /path/to/file"
+}
+
+// contentBlock is a block of the content of a transcript message.
+type contentBlock struct {
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+	Name    string          `json:"name"`
+	Input   map[string]any  `json:"input"`
+	Content json.RawMessage `json:"content"` // Of a tool result
+}
+
+// toolArguments lists the inputs that best describe a tool call, in order
+// of preference.
+var toolArguments = []string{"file_path", "notebook_path", "command", "pattern", "path", "url", "query", "description"}
+
+// ReadTranscript reads the prompts and replies of a session file. Tool
+// results, meta messages and invalid lines are skipped, and the lines that
+// make up one assistant message are merged.
+func ReadTranscript(path string) ([]Message, error) {
+	cleanPath := filepath.Clean(path)
+	file, err := os.Open(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var messages []Message
+	lastID := ""
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+
+		var sl sessionLine
+		if line = bytes.TrimSpace(line); len(line) > 0 && json.Unmarshal(line, &sl) == nil {
+			if msg, id, ok := transcriptMessage(&sl); ok {
+				if id != "" && id == lastID {
+					merge(&messages[len(messages)-1], msg)
+				} else {
+					messages = append(messages, msg)
+				}
+				lastID = id
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+
+	return messages, nil
+}
+
+// transcriptMessage converts a line into a message along with the ID of the
+// assistant message it belongs to. It reports false for lines that are not
+// part of the transcript.
+func transcriptMessage(sl *sessionLine) (Message, string, bool) {
+	if sl.Message == nil || sl.IsMeta || (sl.Type != "user" && sl.Type != "assistant") {
+		return Message{}, "", false
+	}
+	if sl.Type == "user" && !isPrompt(sl.Message.Content) {
+		return Message{}, "", false
+	}
+
+	msg := Message{Timestamp: sl.Timestamp, Role: sl.Type}
+
+	var text string
+	if err := json.Unmarshal(sl.Message.Content, &text); err == nil {
+		msg.Text = strings.TrimSpace(text)
+		return msg, sl.Message.ID, true
+	}
+
+	var blocks []contentBlock
+	if err := json.Unmarshal(sl.Message.Content, &blocks); err != nil {
+		return Message{}, "", false
+	}
+	var texts []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			if t := strings.TrimSpace(b.Text); t != "" {
+				texts = append(texts, t)
+			}
+		case "tool_use":
+			msg.Tools = append(msg.Tools, describeTool(b))
+		}
+	}
+	msg.Text = strings.Join(texts, "\n\n")
+	if msg.Text == "" && len(msg.Tools) == 0 {
+		return Message{}, "", false
+	}
+	return msg, sl.Message.ID, true
+}
+
+// merge appends the text and tool calls of next to msg.
+func merge(msg *Message, next Message) {
+	if next.Text != "" {
+		if msg.Text != "" {
+			msg.Text += "\n\n"
+		}
+		msg.Text += next.Text
+	}
+	msg.Tools = append(msg.Tools, next.Tools...)
+}
+
+// describeTool describes a tool call by its name and most telling input.
+func describeTool(b contentBlock) string {
+	for _, key := range toolArguments {
+		if v, ok := b.Input[key].(string); ok && v != "" {
+			return fmt.Sprintf("%s %s", b.Name, v)
+		}
+	}
+	return b.Name
+}
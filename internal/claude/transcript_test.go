@@ -0,0 +1,62 @@
+package claude
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTranscript(t *testing.T) {
+	messages, err := ReadTranscript(testdataPath(t, "full.jsonl"))
+	require.NoError(t, err)
+
+	require.Len(t, messages, 5)
+	assert.Equal(t, Message{
+		Timestamp: time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC),
+		Role:      "user",
+		Text:      "The upload test fails randomly, can you look?",
+	}, messages[0])
+
+	// The text and tool call lines of msg_1 are merged, the tool result is skipped
+	assert.Equal(t, "assistant", messages[1].Role)
+	assert.Equal(t, "Let me look at the test.", messages[1].Text)
+	assert.Equal(t, []string{"Read /Users/testuser/Code/webapp/upload_test.go"}, messages[1].Tools)
+
+	// The meta message is skipped
+	assert.Equal(t, "Now use a temp dir instead.", messages[2].Text)
+	assert.Equal(t, "Done.", messages[3].Text)
+}
+
+func TestReadTranscript_SkipsInvalidLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	content := `{"type":"user","message":{"role":"user","content":"hello"}}` + "\n" +
+		"garbage\n" +
+		`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"hi"}]}}` + "\n" +
+		`{"type":"assistant","message":{"role":"assis`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	messages, err := ReadTranscript(path)
+	require.NoError(t, err)
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, "hello", messages[0].Text)
+	assert.Equal(t, "hi", messages[1].Text)
+}
+
+func TestLoadTodos(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s-agent-s.json")
+	content := `[{"content":"Write test","status":"completed","activeForm":"Writing test"},{"content":"Fix bug","status":"pending","activeForm":"Fixing bug"}]`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	todos, err := LoadTodos(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Todo{{Content: "Write test", Status: "completed"}, {Content: "Fix bug", Status: "pending"}}, todos)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	_, err = LoadTodos(path)
+	assert.ErrorContains(t, err, "invalid todo file")
+}
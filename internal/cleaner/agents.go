@@ -0,0 +1,119 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+)
+
+// findOrphanAgents finds the agent transcripts whose parent session no
+// longer exists. Transcripts stored in <session-id>/subagents are reported
+// as their session directory. Agents whose parent is unknown are kept.
+func findOrphanAgents(inv *claude.Inventory, validIDs map[string]struct{}) []OrphanResult {
+	var orphans []OrphanResult
+
+	for i := range inv.Projects {
+		p := &inv.Projects[i]
+		seen := make(map[string]bool)
+		for _, a := range p.Agents {
+			if a.ID == "" || seen[a.ID] {
+				continue
+			}
+			seen[a.ID] = true
+			if _, exists := validIDs[a.ID]; exists {
+				continue
+			}
+			for _, path := range p.AgentData(a.ID) {
+				size, err := dirSize(path)
+				if err != nil {
+					continue
+				}
+				orphans = append(orphans, OrphanResult{
+					Type:      OrphanTypeAgent,
+					Path:      path,
+					SizeSaved: size,
+				})
+			}
+		}
+	}
+
+	return orphans
+}
+
+// findOrphanAgentTodos finds the todos of subagents, named
+// {sessionID}-agent-{agentID}.json, whose agent transcript no longer exists
+// while their session does. Sessions without any agent transcript are left
+// alone, since older versions of Claude Code kept subagents inside the
+// session transcript.
+func findOrphanAgentTodos(todosDir string, inv *claude.Inventory, validIDs map[string]struct{}) ([]OrphanResult, error) {
+	var orphans []OrphanResult
+
+	agents := make(map[string]map[string]struct{}) // Session ID to the IDs of its agents
+	for _, p := range inv.Projects {
+		for _, a := range p.Agents {
+			if agents[a.ID] == nil {
+				agents[a.ID] = make(map[string]struct{})
+			}
+			agents[a.ID][a.AgentID] = struct{}{}
+		}
+	}
+
+	entries, err := os.ReadDir(todosDir)
+	if os.IsNotExist(err) {
+		return orphans, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		sessionID := extractSessionIDFromTodoFilename(entry.Name())
+		agentID := extractAgentIDFromTodoFilename(entry.Name())
+		if sessionID == "" || agentID == sessionID {
+			continue
+		}
+		if _, exists := validIDs[sessionID]; !exists {
+			continue // An orphan todo already
+		}
+		sessionAgents, hasAgents := agents[sessionID]
+		if !hasAgents {
+			continue
+		}
+		if _, exists := sessionAgents[agentID]; exists {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		orphans = append(orphans, OrphanResult{
+			Type:      OrphanTypeAgentTodo,
+			Path:      filepath.Join(todosDir, entry.Name()),
+			SizeSaved: info.Size(),
+		})
+	}
+
+	return orphans, nil
+}
+
+// extractAgentIDFromTodoFilename extracts the agent ID from a todo filename.
+// Format: {sessionID}-agent-{agentID}.json
+func extractAgentIDFromTodoFilename(filename string) string {
+	if !strings.HasSuffix(filename, ".json") {
+		return ""
+	}
+
+	name := strings.TrimSuffix(filename, ".json")
+	idx := strings.Index(name, "-agent-")
+	if idx == -1 {
+		return ""
+	}
+
+	return name[idx+len("-agent-"):]
+}
@@ -0,0 +1,117 @@
+package cleaner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeAgent writes an agent transcript of the given parent session.
+func writeAgent(t *testing.T, path, sessionID string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	content := `{"sessionId":"` + sessionID + `","cwd":"/test","isSidechain":true}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestFindInventoryOrphans_Agents(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+
+	projectDir := filepath.Join(paths.Projects, "-test-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	session := `{"sessionId":"live","cwd":"/test"}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "live.jsonl"), []byte(session), 0644))
+
+	writeAgent(t, filepath.Join(projectDir, "agent-a1.jsonl"), "live")
+	writeAgent(t, filepath.Join(projectDir, "live", "subagents", "agent-a2.jsonl"), "live")
+	gone := filepath.Join(projectDir, "agent-a3.jsonl")
+	writeAgent(t, gone, "gone")
+	goneDir := filepath.Join(projectDir, "gone2")
+	writeAgent(t, filepath.Join(goneDir, "subagents", "agent-a4.jsonl"), "gone2")
+
+	inv, err := claude.ScanInventory(context.Background(), paths.Projects, 0, nil)
+	require.NoError(t, err)
+
+	orphans, err := FindInventoryOrphans(paths, inv, OrphanRules{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{gone, goneDir}, orphanPaths(orphans, OrphanTypeAgent))
+	for _, o := range orphans {
+		assert.NotZero(t, o.SizeSaved, o.Path)
+	}
+
+	// Agents are left alone by FindOrphans, which does not parse sessions
+	orphans, err = FindOrphans(paths, inv.SessionIDs(), inv.PlanSlugs(), OrphanRules{})
+	require.NoError(t, err)
+	assert.Empty(t, orphanPaths(orphans, OrphanTypeAgent))
+}
+
+func TestFindInventoryOrphans_AgentTodos(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+
+	projectDir := filepath.Join(paths.Projects, "-test-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	for _, id := range []string{"with-agents", "inline"} {
+		session := `{"sessionId":"` + id + `","cwd":"/test"}` + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, id+".jsonl"), []byte(session), 0644))
+	}
+	writeAgent(t, filepath.Join(projectDir, "with-agents", "subagents", "agent-a1.jsonl"), "with-agents")
+
+	require.NoError(t, os.MkdirAll(paths.Todos, 0755))
+	todo := func(name string) string {
+		path := filepath.Join(paths.Todos, name)
+		require.NoError(t, os.WriteFile(path, []byte("[]"), 0644))
+		return path
+	}
+	todo("with-agents-agent-with-agents.json") // The session's own todos
+	todo("with-agents-agent-a1.json")
+	stale := todo("with-agents-agent-a2.json")
+	todo("inline-agent-a3.json") // No agent transcripts to compare with
+	orphanTodo := todo("deleted-agent-a4.json")
+
+	inv, err := claude.ScanInventory(context.Background(), paths.Projects, 0, nil)
+	require.NoError(t, err)
+
+	orphans, err := FindInventoryOrphans(paths, inv, OrphanRules{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{stale}, orphanPaths(orphans, OrphanTypeAgentTodo))
+	assert.Equal(t, []string{orphanTodo}, orphanPaths(orphans, OrphanTypeTodo))
+}
+
+func TestFindExpiredSessions_AgentData(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+
+	projectDir := filepath.Join(paths.Projects, "-p")
+	agent := filepath.Join(projectDir, "agent-a1.jsonl")
+	writeAgent(t, agent, "a")
+	writeAgent(t, filepath.Join(projectDir, "a", "subagents", "agent-a2.jsonl"), "a")
+	info, err := os.Stat(agent)
+	require.NoError(t, err)
+
+	p := retentionProject(t, 400)
+	p.Agents = []claude.SessionInfo{
+		{ID: "a", AgentID: "a1", FilePath: agent},
+		{ID: "a", AgentID: "a2", FilePath: filepath.Join(projectDir, "a", "subagents", "agent-a2.jsonl")},
+	}
+	results := FindExpiredSessions(paths, []claude.Project{p}, RetentionPolicy{OlderThan: time.Hour}, retentionNow)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{agent, filepath.Join(projectDir, "a")}, results[0].Linked)
+	assert.Equal(t, 100+2*info.Size(), results[0].SizeSaved)
+	assert.Contains(t, BuildSessionPreview(results).Changes[0].Description, "with agents")
+}
+
+func TestExtractAgentIDFromTodoFilename(t *testing.T) {
+	assert.Equal(t, "a1", extractAgentIDFromTodoFilename("sess-agent-a1.json"))
+	assert.Equal(t, "sess", extractAgentIDFromTodoFilename("sess-agent-sess.json"))
+	assert.Empty(t, extractAgentIDFromTodoFilename("sess.json"))
+	assert.Empty(t, extractAgentIDFromTodoFilename("sess-agent-a1.txt"))
+}
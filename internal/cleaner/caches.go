@@ -0,0 +1,202 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultLogRetention is the age after which shell snapshots, debug logs and
+// statsig caches are orphans, unless the policy sets another.
+const DefaultLogRetention = 30 * 24 * time.Hour
+
+// OrphanRules holds the rules for data that is not named after a session.
+type OrphanRules struct {
+	// MaxAge is the age after which shell snapshots, debug logs and statsig
+	// caches are orphans. Zero leaves them alone.
+	MaxAge time.Duration
+	// Now is the time ages are measured from; zero means the current time.
+	Now time.Time
+	// Trivial classifies the sessions that are orphans because they hold
+	// nothing worth keeping; nil leaves all sessions with content alone.
+	Trivial *TrivialRules
+}
+
+// findOldFiles finds the regular files directly in dir that were last
+// modified more than maxAge before now. Symbolic links, such as the "latest"
+// link of the debug logs, are left alone.
+func findOldFiles(dir string, typ OrphanType, maxAge time.Duration, now time.Time) ([]OrphanResult, error) {
+	var orphans []OrphanResult
+
+	if dir == "" || maxAge <= 0 {
+		return orphans, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return orphans, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if now.Sub(info.ModTime()) > maxAge {
+			orphans = append(orphans, OrphanResult{
+				Type:      typ,
+				Path:      filepath.Join(dir, entry.Name()),
+				SizeSaved: info.Size(),
+			})
+		}
+	}
+
+	return orphans, nil
+}
+
+// findOldStatsig finds statsig caches older than maxAge. Files are named
+// "statsig.<kind>.<hash>", and the newest file of each kind is kept however
+// old it is, since it may be the one in use, such as the stable ID.
+func findOldStatsig(dir string, maxAge time.Duration, now time.Time) ([]OrphanResult, error) {
+	old, err := findOldFiles(dir, OrphanTypeStatsig, maxAge, now)
+	if err != nil || len(old) == 0 {
+		return old, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	newest := make(map[string]string) // Kind to the name of its newest file
+	newestTime := make(map[string]time.Time)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		kind := statsigKind(entry.Name())
+		if info.ModTime().After(newestTime[kind]) || newest[kind] == "" {
+			newest[kind] = entry.Name()
+			newestTime[kind] = info.ModTime()
+		}
+	}
+
+	var orphans []OrphanResult
+	for _, o := range old {
+		name := filepath.Base(o.Path)
+		if newest[statsigKind(name)] != name {
+			orphans = append(orphans, o)
+		}
+	}
+	return orphans, nil
+}
+
+// statsigKind returns the name of a statsig file without its hash, e.g.
+// "statsig.cached.evaluations" for "statsig.cached.evaluations.4f1c2a".
+func statsigKind(name string) string {
+	if i := strings.LastIndex(name, "."); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// ideLock is the part of an IDE lock file that tells whether the IDE is
+// still running.
+type ideLock struct {
+	PID              int  `json:"pid"`
+	RunningInWindows bool `json:"runningInWindows"`
+}
+
+// findStaleIDELocks finds the lock files that connected IDEs write to
+// ~/.claude/ide/<port>.lock whose process is no longer running. Locks that
+// cannot be read, and those of Windows processes seen from WSL, are kept.
+func findStaleIDELocks(dir string) ([]OrphanResult, error) {
+	var orphans []OrphanResult
+
+	if dir == "" {
+		return orphans, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return orphans, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || filepath.Ext(entry.Name()) != ".lock" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path) // #nosec G304 -- path is a directory entry of dir
+		if err != nil {
+			continue
+		}
+		var lock ideLock
+		if err := json.Unmarshal(data, &lock); err != nil || lock.PID <= 0 {
+			continue
+		}
+		if lock.RunningInWindows && runtime.GOOS != "windows" {
+			continue
+		}
+		if processAlive(lock.PID) {
+			continue
+		}
+		orphans = append(orphans, OrphanResult{
+			Type:      OrphanTypeIDELock,
+			Path:      path,
+			SizeSaved: int64(len(data)),
+		})
+	}
+
+	return orphans, nil
+}
+
+// findOrphanPlans finds plan files, ~/.claude/plans/<slug>.md, whose slug no
+// session refers to.
+func findOrphanPlans(dir string, slugs map[string]struct{}) ([]OrphanResult, error) {
+	var orphans []OrphanResult
+
+	if dir == "" {
+		return orphans, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return orphans, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		if _, exists := slugs[strings.TrimSuffix(entry.Name(), ".md")]; exists {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		orphans = append(orphans, OrphanResult{
+			Type:      OrphanTypePlan,
+			Path:      filepath.Join(dir, entry.Name()),
+			SizeSaved: info.Size(),
+		})
+	}
+
+	return orphans, nil
+}
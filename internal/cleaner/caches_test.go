@@ -0,0 +1,137 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeAged writes a file last modified age before now.
+func writeAged(t *testing.T, path, content string, now time.Time, age time.Duration) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	modTime := now.Add(-age)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// orphanPaths returns the paths of the orphans of the given type.
+func orphanPaths(orphans []OrphanResult, typ OrphanType) []string {
+	var paths []string
+	for _, o := range orphans {
+		if o.Type == typ {
+			paths = append(paths, o.Path)
+		}
+	}
+	return paths
+}
+
+func TestFindOrphans_OldLogsAndCaches(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+	now := time.Now()
+	day := 24 * time.Hour
+
+	oldSnapshot := filepath.Join(paths.ShellSnapshots, "snapshot-zsh-1.sh")
+	writeAged(t, oldSnapshot, "export A=1", now, 40*day)
+	writeAged(t, filepath.Join(paths.ShellSnapshots, "snapshot-zsh-2.sh"), "export A=1", now, day)
+
+	oldLog := filepath.Join(paths.Debug, "s1.txt")
+	writeAged(t, oldLog, "debug", now, 40*day)
+	writeAged(t, filepath.Join(paths.Debug, "s2.txt"), "debug", now, day)
+	if runtime.GOOS != "windows" {
+		require.NoError(t, os.Symlink(oldLog, filepath.Join(paths.Debug, "latest")))
+	}
+
+	// The newest file of each statsig kind is kept, however old
+	oldEvaluations := filepath.Join(paths.Statsig, "statsig.cached.evaluations.aaa")
+	writeAged(t, oldEvaluations, "{}", now, 50*day)
+	writeAged(t, filepath.Join(paths.Statsig, "statsig.cached.evaluations.bbb"), "{}", now, 40*day)
+	writeAged(t, filepath.Join(paths.Statsig, "statsig.stable_id.ccc"), `"id"`, now, 400*day)
+
+	orphans, err := FindOrphans(paths, nil, nil, OrphanRules{MaxAge: 30 * day, Now: now})
+	require.NoError(t, err)
+	assert.Equal(t, []string{oldSnapshot}, orphanPaths(orphans, OrphanTypeShellSnapshot))
+	assert.Equal(t, []string{oldLog}, orphanPaths(orphans, OrphanTypeDebugLog), "links are left alone")
+	assert.Equal(t, []string{oldEvaluations}, orphanPaths(orphans, OrphanTypeStatsig))
+	for _, o := range orphans {
+		assert.NotZero(t, o.SizeSaved, o.Path)
+	}
+
+	// Without a maximum age, nothing is too old
+	orphans, err = FindOrphans(paths, nil, nil, OrphanRules{Now: now})
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+}
+
+func TestFindOrphans_StaleIDELocks(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(paths.IDE, 0755))
+
+	write := func(name, content string) string {
+		path := filepath.Join(paths.IDE, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	write("1000.lock", fmt.Sprintf(`{"pid":%d,"ideName":"Visual Studio Code"}`, os.Getpid()))
+	stale := write("1001.lock", fmt.Sprintf(`{"pid":%d,"ideName":"Visual Studio Code"}`, math.MaxInt32))
+	write("1002.lock", "{")
+	write("1003.lock", `{"ideName":"no pid"}`)
+	write("notes.txt", "not a lock")
+	if runtime.GOOS != "windows" {
+		write("1004.lock", fmt.Sprintf(`{"pid":%d,"runningInWindows":true}`, math.MaxInt32))
+	}
+
+	orphans, err := FindOrphans(paths, nil, nil, OrphanRules{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{stale}, orphanPaths(orphans, OrphanTypeIDELock))
+}
+
+func TestFindInventoryOrphans_Plans(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+
+	projectDir := filepath.Join(paths.Projects, "-test-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	session := `{"sessionId":"sess1","cwd":"/test","slug":"bright-sailing-fox"}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "sess1.jsonl"), []byte(session), 0644))
+
+	require.NoError(t, os.MkdirAll(paths.Plans, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Plans, "bright-sailing-fox.md"), []byte("# Plan"), 0644))
+	unused := filepath.Join(paths.Plans, "quiet-falling-leaf.md")
+	require.NoError(t, os.WriteFile(unused, []byte("# Old plan"), 0644))
+
+	inv, err := claude.ScanInventory(context.Background(), paths.Projects, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bright-sailing-fox"}, inv.PlanSlugs())
+
+	orphans, err := FindInventoryOrphans(paths, inv, OrphanRules{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{unused}, orphanPaths(orphans, OrphanTypePlan))
+}
+
+func TestBuildOrphanPreview_NewTypes(t *testing.T) {
+	preview := BuildOrphanPreview([]OrphanResult{
+		{Type: OrphanTypeShellSnapshot, Path: "/a"},
+		{Type: OrphanTypeDebugLog, Path: "/b"},
+		{Type: OrphanTypeStatsig, Path: "/c"},
+		{Type: OrphanTypeIDELock, Path: "/d"},
+		{Type: OrphanTypePlan, Path: "/e"},
+		{Type: OrphanTypeAgent, Path: "/f"},
+		{Type: OrphanTypeAgentTodo, Path: "/g"},
+	})
+	require.Len(t, preview.Changes, 7)
+	for _, c := range preview.Changes {
+		assert.NotEmpty(t, c.Description, c.Path)
+	}
+}
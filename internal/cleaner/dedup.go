@@ -1,13 +1,13 @@
 package cleaner
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/permissions"
 	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
 )
 
@@ -18,6 +18,34 @@ type DedupResult struct {
 	DuplicateDeny  []string
 	DuplicateAsk   []string
 	SuggestDelete  bool // True if local becomes empty after dedup
+
+	// Sources attributes the duplicates to the layers supplying them, in
+	// order of precedence.
+	Sources []DedupSource
+	// Subsumed explains the duplicates that are not repeated verbatim but
+	// covered by a broader rule.
+	Subsumed []Subsumption
+}
+
+// Subsumption records that an entry of a local config is covered by a broader
+// rule of a higher layer, or of the local config itself.
+type Subsumption struct {
+	List  string // "allow", "deny" or "ask"
+	Rule  string
+	By    string
+	Layer string
+	Path  string
+}
+
+// DedupSource lists the duplicate entries of a local config that one
+// settings layer supplies. An entry found in several layers is attributed to
+// the one with the highest precedence.
+type DedupSource struct {
+	Layer string
+	Path  string
+	Allow []string
+	Deny  []string
+	Ask   []string
 }
 
 // HasDuplicates returns true if any duplicate entries were found.
@@ -87,25 +115,186 @@ func FindLocalConfigsFromProjects(projectPaths []string, excludePath string) []s
 	return configs
 }
 
+// ProjectSettingsPath returns the shared project settings next to the local
+// config at localPath.
+func ProjectSettingsPath(localPath string) string {
+	return filepath.Join(filepath.Dir(localPath), "settings.json")
+}
+
 // DeduplicateConfig compares local settings against global settings
 // and identifies duplicate entries.
 func DeduplicateConfig(localPath string, global, local *claude.Settings) *DedupResult {
+	return DeduplicateLayers(localPath, []claude.SettingsLayer{{Name: claude.LayerUser, Settings: global}}, local)
+}
+
+// DeduplicateLayers compares local settings against the layers above them,
+// given in order of precedence, and identifies the entries they already
+// supply.
+func DeduplicateLayers(localPath string, layers []claude.SettingsLayer, local *claude.Settings) *DedupResult {
 	result := &DedupResult{
 		LocalPath: localPath,
 	}
 
-	// Find duplicates in each permission list
-	result.DuplicateAllow = findDuplicates(local.Permissions.Allow, global.Permissions.Allow)
-	result.DuplicateDeny = findDuplicates(local.Permissions.Deny, global.Permissions.Deny)
-	result.DuplicateAsk = findDuplicates(local.Permissions.Ask, global.Permissions.Ask)
+	// merged accumulates the layers seen so far, so that each duplicate is
+	// attributed to the first layer that has it
+	merged := &claude.Settings{}
+	for _, l := range layers {
+		if l.Settings == nil {
+			continue
+		}
+
+		rest := local.Diff(merged)
+		source := DedupSource{
+			Layer: l.Name,
+			Path:  l.Path,
+			Allow: findDuplicates(rest.Permissions.Allow, l.Settings.Permissions.Allow),
+			Deny:  findDuplicates(rest.Permissions.Deny, l.Settings.Permissions.Deny),
+			Ask:   findDuplicates(rest.Permissions.Ask, l.Settings.Permissions.Ask),
+		}
+		if len(source.Allow)+len(source.Deny)+len(source.Ask) > 0 {
+			result.Sources = append(result.Sources, source)
+			result.DuplicateAllow = append(result.DuplicateAllow, source.Allow...)
+			result.DuplicateDeny = append(result.DuplicateDeny, source.Deny...)
+			result.DuplicateAsk = append(result.DuplicateAsk, source.Ask...)
+		}
+
+		merged.Permissions.Allow = append(merged.Permissions.Allow, l.Settings.Permissions.Allow...)
+		merged.Permissions.Deny = append(merged.Permissions.Deny, l.Settings.Permissions.Deny...)
+		merged.Permissions.Ask = append(merged.Permissions.Ask, l.Settings.Permissions.Ask...)
+	}
+
+	// Then the entries that a broader rule covers
+	for _, list := range []struct {
+		key        string
+		duplicates *[]string
+	}{
+		{"allow", &result.DuplicateAllow},
+		{"deny", &result.DuplicateDeny},
+		{"ask", &result.DuplicateAsk},
+	} {
+		subsumed := findSubsumed(localPath, list.key, permissionList(local, list.key), *list.duplicates, layers)
+		for _, s := range subsumed {
+			*list.duplicates = append(*list.duplicates, s.Rule)
+		}
+		result.Subsumed = append(result.Subsumed, subsumed...)
+	}
 
 	// Check if local would become empty after removing duplicates
-	uniqueSettings := local.Diff(global)
+	uniqueSettings := local.Diff(&claude.Settings{Permissions: claude.Permissions{
+		Allow: result.DuplicateAllow,
+		Deny:  result.DuplicateDeny,
+		Ask:   result.DuplicateAsk,
+	}})
 	result.SuggestDelete = uniqueSettings.IsEmpty()
 
 	return result
 }
 
+// findSubsumed returns the entries of the local permission list key that are
+// covered by a broader rule, skipping those already removed as duplicates. A
+// rule of a higher layer is preferred over one of the local list itself, and
+// of two local rules that cover each other the first is kept. Rules relative
+// to a settings file are only compared with those of the same directory.
+func findSubsumed(localPath, key string, local, removed []string, layers []claude.SettingsLayer) []Subsumption {
+	isRemoved := make(map[string]bool, len(removed))
+	for _, v := range removed {
+		isRemoved[v] = true
+	}
+	var rules []permissions.Rule
+	for _, v := range local {
+		if r, err := permissions.Parse(v); err == nil && !isRemoved[v] {
+			rules = append(rules, r)
+		}
+	}
+
+	dir := filepath.Dir(localPath)
+	var subsumed []Subsumption
+	covered := make(map[string]bool)
+
+	// Across layers
+	for _, r := range rules {
+	search:
+		for _, l := range layers {
+			if l.Settings == nil || r.IsRelative() && filepath.Dir(l.Path) != dir {
+				continue
+			}
+			for _, v := range permissionList(l.Settings, key) {
+				broad, err := permissions.Parse(v)
+				if err != nil || broad.Raw == r.Raw || !broad.Covers(r) {
+					continue
+				}
+				if !covered[r.Raw] {
+					subsumed = append(subsumed, Subsumption{List: key, Rule: r.Raw, By: broad.Raw, Layer: l.Name, Path: l.Path})
+					covered[r.Raw] = true
+				}
+				break search
+			}
+		}
+	}
+
+	// Within the local list
+	within := coveredWithin(rules, covered)
+	for i, r := range rules {
+		j, ok := within[i]
+		if !ok || covered[r.Raw] {
+			continue
+		}
+		subsumed = append(subsumed, Subsumption{List: key, Rule: r.Raw, By: rules[j].Raw, Layer: claude.LayerLocal, Path: localPath})
+		covered[r.Raw] = true
+	}
+
+	return subsumed
+}
+
+// coveredWithin maps the index of each rule that another one of rules covers
+// to the index of a covering rule that stays. Of two rules that cover each
+// other, the first stays. Rules in skip are not considered.
+func coveredWithin(rules []permissions.Rule, skip map[string]bool) map[int]int {
+	redundant := func(i int) bool {
+		r := rules[i]
+		for j, broad := range rules {
+			if broad.Raw != r.Raw && broad.Covers(r) && (j < i || !r.Covers(broad)) {
+				return true
+			}
+		}
+		return false
+	}
+	isRedundant := make(map[string]bool)
+	for i, r := range rules {
+		if !skip[r.Raw] && redundant(i) {
+			isRedundant[r.Raw] = true
+		}
+	}
+
+	covered := make(map[int]int)
+	for i, r := range rules {
+		if !isRedundant[r.Raw] {
+			continue
+		}
+		// Name a broader rule that stays, if there is one
+		for j, broad := range rules {
+			if broad.Raw != r.Raw && !skip[broad.Raw] && !isRedundant[broad.Raw] && broad.Covers(r) {
+				covered[i] = j
+				break
+			}
+		}
+	}
+	return covered
+}
+
+// permissionList returns the permission list key of s.
+func permissionList(s *claude.Settings, key string) []string {
+	switch key {
+	case "allow":
+		return s.Permissions.Allow
+	case "deny":
+		return s.Permissions.Deny
+	case "ask":
+		return s.Permissions.Ask
+	}
+	return nil
+}
+
 // findDuplicates returns entries in local that also exist in global.
 func findDuplicates(local, global []string) []string {
 	if len(local) == 0 || len(global) == 0 {
@@ -127,9 +316,15 @@ func findDuplicates(local, global []string) []string {
 	return duplicates
 }
 
-// ApplyDedup applies the deduplication result to the local config file.
-// If dryRun is true, returns without making changes.
-func ApplyDedup(result *DedupResult, dryRun bool) error {
+// ApplyDedup applies the deduplication result to the local config file in a
+// single atomic rewrite that leaves the rest of the file byte-identical.
+// Claude Code adds entries to the file when a tool use is always allowed; if
+// that happens concurrently, the edit is re-applied to the new content. A file
+// suggested for deletion is re-read first and only deleted if it is still
+// empty without the duplicates, otherwise it is edited. If q is given, the
+// original file is kept in it so the change can be undone. If dryRun is true,
+// returns without making changes.
+func ApplyDedup(result *DedupResult, q Quarantine, dryRun bool) error {
 	if dryRun {
 		return nil
 	}
@@ -139,50 +334,70 @@ func ApplyDedup(result *DedupResult, dryRun bool) error {
 		return nil
 	}
 
-	// If suggest delete, remove the file
 	if result.SuggestDelete {
-		return os.Remove(result.LocalPath)
-	}
-
-	// Otherwise, update the file by removing duplicates
-	settings, err := claude.LoadSettings(result.LocalPath)
-	if err != nil {
-		return err
+		empty, err := emptyAfterDedup(result)
+		if err != nil {
+			return err
+		}
+		if empty {
+			return removePath(q, result.LocalPath)
+		}
 	}
 
-	// Remove duplicates from each list
-	settings.Permissions.Allow = removeEntries(settings.Permissions.Allow, result.DuplicateAllow)
-	settings.Permissions.Deny = removeEntries(settings.Permissions.Deny, result.DuplicateDeny)
-	settings.Permissions.Ask = removeEntries(settings.Permissions.Ask, result.DuplicateAsk)
+	preserved := false
+	_, err := claude.EditFile(result.LocalPath, func(doc *claude.Document) error {
+		removed, err := removeDuplicates(doc, result)
+		if err != nil || removed == 0 || preserved {
+			return err
+		}
+		// Keep the content the edit starts from, once
+		preserved = true
+		return preservePath(q, result.LocalPath)
+	})
+	return err
+}
 
-	// Write updated settings back
-	data, err := json.MarshalIndent(settings, "", "  ")
-	if err != nil {
-		return err
+// removeDuplicates removes the duplicate entries of result from doc and
+// returns how many it removed.
+func removeDuplicates(doc *claude.Document, result *DedupResult) (int, error) {
+	lists := []struct {
+		key     string
+		entries []string
+	}{
+		{"allow", result.DuplicateAllow},
+		{"deny", result.DuplicateDeny},
+		{"ask", result.DuplicateAsk},
 	}
-
-	return os.WriteFile(result.LocalPath, data, 0600)
+	total := 0
+	for _, l := range lists {
+		n, err := doc.RemoveStrings([]string{"permissions", l.key}, l.entries)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
 }
 
-// removeEntries returns a new slice with specified entries removed.
-func removeEntries(slice, toRemove []string) []string {
-	if len(slice) == 0 {
-		return nil
+// emptyAfterDedup reports whether the local config file, as it is now, holds
+// nothing but the duplicate entries of result.
+func emptyAfterDedup(result *DedupResult) (bool, error) {
+	data, err := os.ReadFile(filepath.Clean(result.LocalPath)) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return false, err
 	}
-
-	removeSet := make(map[string]struct{}, len(toRemove))
-	for _, v := range toRemove {
-		removeSet[v] = struct{}{}
+	doc, err := claude.ParseDocument(data)
+	if err != nil {
+		return false, err
 	}
-
-	var result []string
-	for _, v := range slice {
-		if _, exists := removeSet[v]; !exists {
-			result = append(result, v)
-		}
+	if _, err := removeDuplicates(doc, result); err != nil {
+		return false, err
 	}
-
-	return result
+	rest, err := claude.ParseSettings(doc.Bytes())
+	if err != nil {
+		return false, err
+	}
+	return rest.IsEmpty(), nil
 }
 
 // BuildDedupPreview creates a preview of configs to be deduplicated.
@@ -223,8 +438,9 @@ func formatDuplicateDescription(r DedupResult) string {
 	return fmt.Sprintf("%d duplicate entries to remove", total)
 }
 
-// BuildDedupPreviewVerbose creates a verbose preview of configs to be deduplicated.
-func BuildDedupPreviewVerbose(results []DedupResult, globalPath string) *ui.Preview {
+// BuildDedupPreviewVerbose creates a verbose preview of configs to be
+// deduplicated, naming the layer that supplies each duplicate.
+func BuildDedupPreviewVerbose(results []DedupResult) *ui.Preview {
 	preview := &ui.Preview{
 		Title: "Config Deduplication",
 	}
@@ -235,10 +451,10 @@ func BuildDedupPreviewVerbose(results []DedupResult, globalPath string) *ui.Prev
 
 		if r.SuggestDelete {
 			action = ui.ActionDelete
-			description = formatVerboseDescription(r, globalPath, true)
+			description = formatVerboseDescription(r, true)
 		} else {
 			action = ui.ActionModify
-			description = formatVerboseDescription(r, globalPath, false)
+			description = formatVerboseDescription(r, false)
 		}
 
 		preview.Changes = append(preview.Changes, ui.Change{
@@ -252,28 +468,40 @@ func BuildDedupPreviewVerbose(results []DedupResult, globalPath string) *ui.Prev
 	return preview
 }
 
-// formatVerboseDescription creates a verbose description listing all duplicates.
-func formatVerboseDescription(r DedupResult, globalPath string, willDelete bool) string {
+// formatVerboseDescription creates a verbose description listing all
+// duplicates by the layer supplying them.
+func formatVerboseDescription(r DedupResult, willDelete bool) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("Duplicates of %s:\n", globalPath))
-
-	if len(r.DuplicateAllow) > 0 {
-		sb.WriteString("     allow: ")
-		sb.WriteString(strings.Join(r.DuplicateAllow, ", "))
-		sb.WriteString("\n")
-	}
+	for _, src := range r.Sources {
+		if src.Path != "" {
+			sb.WriteString(fmt.Sprintf("Duplicates of %s settings (%s):\n", src.Layer, src.Path))
+		} else {
+			sb.WriteString(fmt.Sprintf("Duplicates of %s settings:\n", src.Layer))
+		}
 
-	if len(r.DuplicateDeny) > 0 {
-		sb.WriteString("     deny: ")
-		sb.WriteString(strings.Join(r.DuplicateDeny, ", "))
-		sb.WriteString("\n")
+		lists := []struct {
+			key     string
+			entries []string
+		}{
+			{"allow", src.Allow},
+			{"deny", src.Deny},
+			{"ask", src.Ask},
+		}
+		for _, l := range lists {
+			if len(l.entries) > 0 {
+				sb.WriteString("     " + l.key + ": ")
+				sb.WriteString(strings.Join(l.entries, ", "))
+				sb.WriteString("\n")
+			}
+		}
 	}
 
-	if len(r.DuplicateAsk) > 0 {
-		sb.WriteString("     ask: ")
-		sb.WriteString(strings.Join(r.DuplicateAsk, ", "))
-		sb.WriteString("\n")
+	if len(r.Subsumed) > 0 {
+		sb.WriteString("Covered by broader rules:\n")
+		for _, s := range r.Subsumed {
+			sb.WriteString(fmt.Sprintf("     %s: %s by %s (%s settings)\n", s.List, s.Rule, s.By, s.Layer))
+		}
 	}
 
 	if willDelete {
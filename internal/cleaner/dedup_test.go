@@ -94,6 +94,27 @@ func TestDeduplicateConfig_AllDuplicate(t *testing.T) {
 	assert.True(t, result.SuggestDelete)
 }
 
+func TestDeduplicateConfig_AllDuplicateWithOtherKeys(t *testing.T) {
+	global := &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(git:*)"},
+		},
+	}
+
+	local := &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(git:*)"},
+		},
+		Other: []string{"env", "hooks"},
+	}
+
+	result := DeduplicateConfig("/path/to/local/settings.json", global, local)
+
+	// env and hooks are still there, so the file must be kept
+	assert.Equal(t, []string{"Bash(git:*)"}, result.DuplicateAllow)
+	assert.False(t, result.SuggestDelete)
+}
+
 func TestDeduplicateConfig_NoDuplicates(t *testing.T) {
 	global := &claude.Settings{
 		Permissions: claude.Permissions{
@@ -184,7 +205,7 @@ func TestApplyDedup_DryRun(t *testing.T) {
 		SuggestDelete:  false,
 	}
 
-	err := ApplyDedup(result, true)
+	err := ApplyDedup(result, nil, true)
 	require.NoError(t, err)
 
 	// File should be unchanged in dry run
@@ -205,7 +226,7 @@ func TestApplyDedup_RemoveDuplicates(t *testing.T) {
 		SuggestDelete:  false,
 	}
 
-	err := ApplyDedup(result, false)
+	err := ApplyDedup(result, nil, false)
 	require.NoError(t, err)
 
 	// File should have Bash(git:*) removed from allow
@@ -225,13 +246,43 @@ func TestApplyDedup_DeleteFile(t *testing.T) {
 		SuggestDelete: true,
 	}
 
-	err := ApplyDedup(result, false)
+	err := ApplyDedup(result, nil, false)
 	require.NoError(t, err)
 
 	// File should be deleted
 	assert.NoFileExists(t, settingsPath)
 }
 
+func TestApplyDedup_DeleteFileOnlyIfStillEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+	require.NoError(t, os.WriteFile(settingsPath, []byte(`{"permissions":{"allow":["Bash(git:*)"]}}`), 0644))
+
+	result := &DedupResult{
+		LocalPath:      settingsPath,
+		DuplicateAllow: []string{"Bash(git:*)"},
+		SuggestDelete:  true,
+	}
+
+	// An entry added after the analysis keeps the file
+	require.NoError(t, os.WriteFile(settingsPath, []byte(`{"permissions":{"allow":["Bash(git:*)","Bash(make:*)"]}}`), 0644))
+	require.NoError(t, ApplyDedup(result, nil, false))
+
+	data, err := os.ReadFile(settingsPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"permissions":{"allow":["Bash(make:*)"]}}`, string(data))
+
+	// So does another key
+	require.NoError(t, os.WriteFile(settingsPath, []byte(`{"permissions":{"allow":["Bash(git:*)"]},"env":{"A":"1"}}`), 0644))
+	require.NoError(t, ApplyDedup(result, nil, false))
+	assert.FileExists(t, settingsPath)
+
+	// Duplicates only
+	require.NoError(t, os.WriteFile(settingsPath, []byte(`{"permissions":{"allow":["Bash(git:*)"]}}`), 0644))
+	require.NoError(t, ApplyDedup(result, nil, false))
+	assert.NoFileExists(t, settingsPath)
+}
+
 func TestApplyDedup_NonexistentFile(t *testing.T) {
 	result := &DedupResult{
 		LocalPath:     "/nonexistent/settings.json",
@@ -239,7 +290,7 @@ func TestApplyDedup_NonexistentFile(t *testing.T) {
 	}
 
 	// Should not error for nonexistent file
-	err := ApplyDedup(result, false)
+	err := ApplyDedup(result, nil, false)
 	require.NoError(t, err)
 }
 
@@ -397,15 +448,14 @@ func TestApplyDedup_RemoveLastEntry(t *testing.T) {
 		SuggestDelete:  false,
 	}
 
-	err := ApplyDedup(result, false)
+	err := ApplyDedup(result, nil, false)
 	require.NoError(t, err)
 
 	// Read raw file content to verify valid JSON structure
 	rawData, err := os.ReadFile(settingsPath)
 	require.NoError(t, err)
 
-	// The JSON should be valid (Go's json.MarshalIndent handles this)
-	// Verify it can be parsed back
+	// The JSON should be valid; verify it can be parsed back
 	settings, err := claude.LoadSettings(settingsPath)
 	require.NoError(t, err, "JSON should be valid after removing last entry")
 
@@ -431,7 +481,7 @@ func TestApplyDedup_RemoveOnlyEntry(t *testing.T) {
 		SuggestDelete:  false,
 	}
 
-	err := ApplyDedup(result, false)
+	err := ApplyDedup(result, nil, false)
 	require.NoError(t, err)
 
 	// Verify the JSON is still valid
@@ -443,32 +493,257 @@ func TestApplyDedup_RemoveOnlyEntry(t *testing.T) {
 	assert.Equal(t, []string{"Bash(rm:*)"}, settings.Permissions.Deny)
 }
 
+func TestApplyDedup_PreservesEverythingElse(t *testing.T) {
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "settings.local.json")
+
+	content := `{
+    "model": "opus",
+    "permissions": {
+        "allow": [
+            "Bash(git:*)",
+            "Bash(make:*)",
+            "Read(**)"
+        ],
+        "deny": [],
+        "defaultMode": "acceptEdits",
+        "additionalDirectories": ["../shared"]
+    },
+    "env": {"FOO": "bar"},
+    "hooks": {"PreToolUse": [{"matcher": "Bash", "hooks": []}]},
+    "enableAllProjectMcpServers": true,
+    "x-team-note": "keep me"
+}
+`
+	require.NoError(t, os.WriteFile(settingsPath, []byte(content), 0644))
+
+	result := &DedupResult{
+		LocalPath:      settingsPath,
+		DuplicateAllow: []string{"Bash(git:*)", "Read(**)"},
+	}
+
+	err := ApplyDedup(result, nil, false)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(settingsPath)
+	require.NoError(t, err)
+
+	expected := `{
+    "model": "opus",
+    "permissions": {
+        "allow": [
+            "Bash(make:*)"
+        ],
+        "deny": [],
+        "defaultMode": "acceptEdits",
+        "additionalDirectories": ["../shared"]
+    },
+    "env": {"FOO": "bar"},
+    "hooks": {"PreToolUse": [{"matcher": "Bash", "hooks": []}]},
+    "enableAllProjectMcpServers": true,
+    "x-team-note": "keep me"
+}
+`
+	assert.Equal(t, expected, string(data))
+}
+
 func TestBuildDedupPreview_Verbose(t *testing.T) {
-	globalPath := "/home/user/.claude/settings.json"
 	results := []DedupResult{
 		{
-			LocalPath:      "/project1/.claude/settings.json",
+			LocalPath:      "/project1/.claude/settings.local.json",
 			DuplicateAllow: []string{"Bash(git:*)"},
 			DuplicateDeny:  []string{"Bash(rm:*)"},
 			SuggestDelete:  false,
+			Sources: []DedupSource{
+				{Layer: claude.LayerManaged, Path: "/etc/claude-code/managed-settings.json", Deny: []string{"Bash(rm:*)"}},
+				{Layer: claude.LayerUser, Path: "/home/user/.claude/settings.json", Allow: []string{"Bash(git:*)"}},
+			},
 		},
 		{
-			LocalPath:      "/project2/.claude/settings.json",
+			LocalPath:      "/project2/.claude/settings.local.json",
 			DuplicateAllow: []string{"Read(**)"},
 			SuggestDelete:  true,
+			Sources: []DedupSource{
+				{Layer: claude.LayerProject, Path: "/project2/.claude/settings.json", Allow: []string{"Read(**)"}},
+			},
 		},
 	}
 
-	preview := BuildDedupPreviewVerbose(results, globalPath)
+	preview := BuildDedupPreviewVerbose(results)
 
 	assert.Equal(t, "Config Deduplication", preview.Title)
 	assert.Len(t, preview.Changes, 2)
 
-	// First change should have verbose description with duplicates listed
-	assert.Contains(t, preview.Changes[0].Description, "Bash(git:*)")
-	assert.Contains(t, preview.Changes[0].Description, "Bash(rm:*)")
+	// First change should list the duplicates by the layer supplying them
+	assert.Equal(t, "Duplicates of managed settings (/etc/claude-code/managed-settings.json):\n"+
+		"     deny: Bash(rm:*)\n"+
+		"Duplicates of user settings (/home/user/.claude/settings.json):\n"+
+		"     allow: Bash(git:*)\n", preview.Changes[0].Description)
 
 	// Second change should indicate deletion
 	assert.Equal(t, ui.ActionDelete, preview.Changes[1].Action)
+	assert.Contains(t, preview.Changes[1].Description, "Duplicates of project settings (/project2/.claude/settings.json):")
 	assert.Contains(t, preview.Changes[1].Description, "Read(**)")
 }
+
+func TestDeduplicateLayers_AttributesHighestLayer(t *testing.T) {
+	layers := []claude.SettingsLayer{
+		{Name: claude.LayerManaged, Path: "/etc/claude-code/managed-settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Deny: []string{"Bash(rm:*)"}},
+		}},
+		{Name: claude.LayerUser, Path: "/home/user/.claude/settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Allow: []string{"Bash(git:*)"}, Deny: []string{"Bash(rm:*)"}},
+		}},
+		{Name: claude.LayerProject, Path: "/project/.claude/settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Allow: []string{"Bash(git:*)", "Bash(make:*)"}},
+		}},
+	}
+	local := &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(make:*)", "Bash(git:*)", "Bash(npm:*)"},
+			Deny:  []string{"Bash(rm:*)"},
+		},
+	}
+
+	result := DeduplicateLayers("/project/.claude/settings.local.json", layers, local)
+
+	assert.Equal(t, []DedupSource{
+		{Layer: claude.LayerManaged, Path: "/etc/claude-code/managed-settings.json", Deny: []string{"Bash(rm:*)"}},
+		{Layer: claude.LayerUser, Path: "/home/user/.claude/settings.json", Allow: []string{"Bash(git:*)"}},
+		{Layer: claude.LayerProject, Path: "/project/.claude/settings.json", Allow: []string{"Bash(make:*)"}},
+	}, result.Sources)
+	assert.ElementsMatch(t, []string{"Bash(git:*)", "Bash(make:*)"}, result.DuplicateAllow)
+	assert.Equal(t, []string{"Bash(rm:*)"}, result.DuplicateDeny)
+	assert.False(t, result.SuggestDelete, "Bash(npm:*) is unique to the local config")
+}
+
+func TestDeduplicateLayers_SuggestDeleteAcrossLayers(t *testing.T) {
+	layers := []claude.SettingsLayer{
+		{Name: claude.LayerManaged, Settings: &claude.Settings{
+			Permissions: claude.Permissions{Deny: []string{"Bash(curl:*)"}},
+		}},
+		{Name: claude.LayerUser, Settings: &claude.Settings{}},
+		{Name: claude.LayerProject, Settings: &claude.Settings{
+			Permissions: claude.Permissions{Allow: []string{"Bash(go test:*)"}},
+		}},
+	}
+	local := &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(go test:*)"},
+			Deny:  []string{"Bash(curl:*)"},
+		},
+	}
+
+	result := DeduplicateLayers("/project/.claude/settings.local.json", layers, local)
+
+	assert.Len(t, result.Sources, 2)
+	assert.True(t, result.SuggestDelete)
+}
+
+func TestDeduplicateConfig_UserLayer(t *testing.T) {
+	global := &claude.Settings{Permissions: claude.Permissions{Allow: []string{"Read(**)"}}}
+	local := &claude.Settings{Permissions: claude.Permissions{Allow: []string{"Read(**)"}}}
+
+	result := DeduplicateConfig("/project/.claude/settings.local.json", global, local)
+
+	require.Len(t, result.Sources, 1)
+	assert.Equal(t, claude.LayerUser, result.Sources[0].Layer)
+}
+
+func TestProjectSettingsPath(t *testing.T) {
+	local := filepath.Join("project", ".claude", "settings.local.json")
+	assert.Equal(t, filepath.Join("project", ".claude", "settings.json"), ProjectSettingsPath(local))
+}
+
+func TestDeduplicateLayers_SubsumedByHigherLayer(t *testing.T) {
+	layers := []claude.SettingsLayer{
+		{Name: claude.LayerUser, Path: "/home/user/.claude/settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{
+				Allow: []string{"Bash(git:*)", "mcp__github", "Read(/docs/**)"},
+				Deny:  []string{"Read(~/.ssh/**)"},
+			},
+		}},
+	}
+	local := &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(git status:*)", "mcp__github__create_issue", "Bash(make:*)", "Read(/docs/api.md)"},
+			Deny:  []string{"Read(~/.ssh/id_ed25519)"},
+		},
+	}
+
+	result := DeduplicateLayers("/project/.claude/settings.local.json", layers, local)
+
+	assert.Empty(t, result.Sources)
+	assert.Equal(t, []string{"Bash(git status:*)", "mcp__github__create_issue"}, result.DuplicateAllow,
+		"the relative Read rule of another directory is not compared")
+	assert.Equal(t, []string{"Read(~/.ssh/id_ed25519)"}, result.DuplicateDeny)
+	assert.Equal(t, []Subsumption{
+		{List: "allow", Rule: "Bash(git status:*)", By: "Bash(git:*)", Layer: claude.LayerUser, Path: "/home/user/.claude/settings.json"},
+		{List: "allow", Rule: "mcp__github__create_issue", By: "mcp__github", Layer: claude.LayerUser, Path: "/home/user/.claude/settings.json"},
+		{List: "deny", Rule: "Read(~/.ssh/id_ed25519)", By: "Read(~/.ssh/**)", Layer: claude.LayerUser, Path: "/home/user/.claude/settings.json"},
+	}, result.Subsumed)
+	assert.False(t, result.SuggestDelete)
+}
+
+func TestDeduplicateLayers_SubsumedWithinFile(t *testing.T) {
+	local := &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(npm run test:*)", "Bash(npm:*)", "Bash(npm run:*)", "Bash", "Bash(*)"},
+		},
+	}
+
+	result := DeduplicateLayers("/project/.claude/settings.local.json", nil, local)
+
+	// "Bash" covers every other command rule
+	assert.ElementsMatch(t, []string{"Bash(npm run test:*)", "Bash(npm:*)", "Bash(npm run:*)", "Bash(*)"}, result.DuplicateAllow)
+	for _, s := range result.Subsumed {
+		assert.Equal(t, "Bash", s.By, s.Rule)
+		assert.Equal(t, claude.LayerLocal, s.Layer)
+	}
+	assert.False(t, result.SuggestDelete)
+}
+
+func TestDeduplicateLayers_SubsumedRelativeSameDirectory(t *testing.T) {
+	layers := []claude.SettingsLayer{
+		{Name: claude.LayerProject, Path: "/project/.claude/settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Allow: []string{"Edit(./src/**)"}},
+		}},
+	}
+	local := &claude.Settings{Permissions: claude.Permissions{Allow: []string{"Edit(./src/main.go)"}}}
+
+	result := DeduplicateLayers("/project/.claude/settings.local.json", layers, local)
+
+	require.Len(t, result.Subsumed, 1)
+	assert.Equal(t, "Edit(./src/**)", result.Subsumed[0].By)
+	assert.True(t, result.SuggestDelete)
+}
+
+func TestDeduplicateLayers_ExactBeforeSubsumed(t *testing.T) {
+	layers := []claude.SettingsLayer{
+		{Name: claude.LayerUser, Settings: &claude.Settings{
+			Permissions: claude.Permissions{Allow: []string{"Bash(git:*)", "Bash(git log:*)"}},
+		}},
+	}
+	local := &claude.Settings{Permissions: claude.Permissions{Allow: []string{"Bash(git log:*)", "not a (rule"}}}
+
+	result := DeduplicateLayers("/project/.claude/settings.local.json", layers, local)
+
+	assert.Equal(t, []string{"Bash(git log:*)"}, result.DuplicateAllow)
+	assert.Empty(t, result.Subsumed)
+	assert.False(t, result.SuggestDelete)
+}
+
+func TestBuildDedupPreview_VerboseSubsumed(t *testing.T) {
+	results := []DedupResult{{
+		LocalPath:      "/project/.claude/settings.local.json",
+		DuplicateAllow: []string{"Bash(git status:*)"},
+		Subsumed: []Subsumption{
+			{List: "allow", Rule: "Bash(git status:*)", By: "Bash(git:*)", Layer: claude.LayerUser},
+		},
+	}}
+
+	preview := BuildDedupPreviewVerbose(results)
+
+	assert.Equal(t, "Covered by broader rules:\n"+
+		"     allow: Bash(git status:*) by Bash(git:*) (user settings)\n", preview.Changes[0].Description)
+}
@@ -0,0 +1,204 @@
+package cleaner
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// Reasons why a file-history snapshot is prunable.
+const (
+	SnapshotProjectDeleted = "project deleted"
+	SnapshotFileDeleted    = "file no longer exists"
+	SnapshotOldVersion     = "older version"
+)
+
+// FileHistoryRules select the file-history snapshots of valid sessions that
+// are removed.
+type FileHistoryRules struct {
+	// KeepVersions is the number of versions kept of each file, newest
+	// first; zero keeps all of them.
+	KeepVersions int
+}
+
+// TrackedFile is a file that a session edited, with its snapshots in
+// file-history/<session-id>, newest first.
+type TrackedFile struct {
+	Project   claude.Project
+	SessionID string
+	Source    string // The file the snapshots were taken of; empty if the session does not name it
+	Snapshots []Snapshot
+}
+
+// Snapshot is a file-history snapshot of a tracked file.
+type Snapshot struct {
+	Path    string
+	Version int
+	Size    int64
+	Reason  string // Why the snapshot can be removed; empty if it is kept
+}
+
+// Size returns the total size of the snapshots of the file.
+func (f *TrackedFile) Size() int64 {
+	var total int64
+	for _, s := range f.Snapshots {
+		total += s.Size
+	}
+	return total
+}
+
+// Prunable returns the snapshots of the file that can be removed.
+func (f *TrackedFile) Prunable() []Snapshot {
+	var prunable []Snapshot
+	for _, s := range f.Snapshots {
+		if s.Reason != "" {
+			prunable = append(prunable, s)
+		}
+	}
+	return prunable
+}
+
+// AnalyzeFileHistory maps the file-history snapshots of the sessions of
+// projects back to the files they were taken of, using the snapshot lines of
+// the session transcripts. Snapshots are prunable if their project was
+// deleted, if the file they were taken of no longer exists, or if they are
+// older than the newest rules.KeepVersions versions of the file. Projects
+// whose path is unavailable, such as on a volume that is not mounted, are
+// skipped, since it cannot be told which of their files still exist.
+func AnalyzeFileHistory(paths *claude.Paths, projects []claude.Project, table *mounts.Table, rules FileHistoryRules) ([]TrackedFile, error) {
+	var files []TrackedFile
+
+	for _, p := range projects {
+		projectDeleted := false
+		if p.ActualPath != "" {
+			switch status, _ := table.Check(p.ActualPath); status {
+			case mounts.StatusPresent:
+			case mounts.StatusDeleted:
+				projectDeleted = true
+			default:
+				continue
+			}
+		}
+
+		for _, s := range p.Sessions {
+			if s.ID == "" {
+				continue
+			}
+			dir := filepath.Join(paths.FileHistory, s.ID)
+			entries, err := os.ReadDir(dir)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			backups, err := claude.ReadFileBackups(s.FilePath, s.CWD)
+			if err != nil {
+				return nil, err
+			}
+
+			byHash := make(map[string]*TrackedFile)
+			var order []string
+			for _, entry := range entries {
+				if !entry.Type().IsRegular() {
+					continue
+				}
+				hash, version, ok := claude.ParseBackupName(entry.Name())
+				if !ok {
+					continue
+				}
+				info, err := entry.Info()
+				if err != nil {
+					continue
+				}
+				f := byHash[hash]
+				if f == nil {
+					f = &TrackedFile{Project: p, SessionID: s.ID}
+					byHash[hash] = f
+					order = append(order, hash)
+				}
+				if b, ok := backups[entry.Name()]; ok {
+					f.Source = b.Source
+				}
+				f.Snapshots = append(f.Snapshots, Snapshot{
+					Path:    filepath.Join(dir, entry.Name()),
+					Version: version,
+					Size:    info.Size(),
+				})
+			}
+
+			for _, hash := range order {
+				f := byHash[hash]
+				slices.SortFunc(f.Snapshots, func(a, b Snapshot) int { return cmp.Compare(b.Version, a.Version) })
+
+				fileDeleted := false
+				if !projectDeleted && f.Source != "" {
+					_, err := os.Lstat(f.Source)
+					fileDeleted = os.IsNotExist(err)
+				}
+				for i := range f.Snapshots {
+					switch {
+					case projectDeleted:
+						f.Snapshots[i].Reason = SnapshotProjectDeleted
+					case fileDeleted:
+						f.Snapshots[i].Reason = SnapshotFileDeleted
+					case rules.KeepVersions > 0 && i >= rules.KeepVersions:
+						f.Snapshots[i].Reason = SnapshotOldVersion
+					}
+				}
+				files = append(files, *f)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// CleanSnapshot removes a file-history snapshot, moving it into q if one is
+// given. If dryRun is true, returns without making changes.
+func CleanSnapshot(s Snapshot, q Quarantine, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if _, err := os.Lstat(s.Path); os.IsNotExist(err) {
+		return nil
+	}
+	return removePath(q, s.Path)
+}
+
+// BuildFileHistoryPreview creates a preview of the prunable snapshots of
+// files. Files that keep snapshots are listed as kept with their number.
+func BuildFileHistoryPreview(files []TrackedFile) *ui.Preview {
+	preview := &ui.Preview{
+		Title: "File History Cleanup",
+	}
+
+	for _, f := range files {
+		source := cmp.Or(f.Source, "(unknown file)")
+		kept := len(f.Snapshots)
+		for _, s := range f.Prunable() {
+			kept--
+			preview.Changes = append(preview.Changes, ui.Change{
+				Action:      ui.ActionDelete,
+				Path:        s.Path,
+				Description: fmt.Sprintf("%s v%d, %s", source, s.Version, s.Reason),
+				Size:        s.Size,
+			})
+		}
+		if kept > 0 && kept < len(f.Snapshots) {
+			preview.Kept = append(preview.Kept, ui.Change{
+				Path:        source,
+				Description: fmt.Sprintf("newest %d of %d versions kept", kept, len(f.Snapshots)),
+			})
+		}
+	}
+
+	return preview
+}
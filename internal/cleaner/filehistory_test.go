@@ -0,0 +1,122 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileHistorySession writes a session of a project at projectPath whose
+// snapshot line maps the backups to the given source files, and the backups
+// themselves.
+func fileHistorySession(t *testing.T, paths *claude.Paths, id, projectPath string, backups map[string]string) claude.Project {
+	t.Helper()
+
+	tracked := ""
+	for backup, source := range backups {
+		if tracked != "" {
+			tracked += ","
+		}
+		tracked += `"` + filepath.ToSlash(source) + `":{"backupFileName":"` + backup + `","version":1}`
+		path := filepath.Join(paths.FileHistory, id, backup)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(backup), 0644))
+	}
+
+	sessionPath := filepath.Join(t.TempDir(), id+".jsonl")
+	line := `{"type":"file-history-snapshot","snapshot":{"trackedFileBackups":{` + tracked + `}}}` + "\n"
+	require.NoError(t, os.WriteFile(sessionPath, []byte(line), 0644))
+
+	return claude.Project{
+		EncodedName: "-" + id,
+		ActualPath:  projectPath,
+		Sessions:    []claude.SessionInfo{{ID: id, FilePath: sessionPath, CWD: projectPath}},
+	}
+}
+
+// reasons maps the snapshot files of files to their reasons.
+func reasons(files []TrackedFile) map[string]string {
+	m := make(map[string]string)
+	for _, f := range files {
+		for _, s := range f.Snapshots {
+			m[filepath.Base(s.Path)] = s.Reason
+		}
+	}
+	return m
+}
+
+func TestAnalyzeFileHistory(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+
+	project := t.TempDir()
+	kept := filepath.Join(project, "main.go")
+	require.NoError(t, os.WriteFile(kept, []byte("package main"), 0644))
+	deleted := filepath.Join(project, "old.go")
+
+	live := fileHistorySession(t, paths, "live", project, map[string]string{
+		"aaa@v1": kept, "aaa@v2": kept, "aaa@v3": kept,
+		"bbb@v1": deleted,
+	})
+	// A snapshot the transcript does not name is kept, unless it is too old
+	require.NoError(t, os.WriteFile(filepath.Join(paths.FileHistory, "live", "ccc@v1"), []byte("x"), 0644))
+	gone := fileHistorySession(t, paths, "gone", filepath.Join(project, "deleted-project"), map[string]string{
+		"ddd@v1": "/elsewhere/file.go",
+	})
+	projects := []claude.Project{live, gone}
+
+	files, err := AnalyzeFileHistory(paths, projects, &mounts.Table{}, FileHistoryRules{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"aaa@v1": "",
+		"aaa@v2": "",
+		"aaa@v3": "",
+		"bbb@v1": SnapshotFileDeleted,
+		"ccc@v1": "",
+		"ddd@v1": SnapshotProjectDeleted,
+	}, reasons(files))
+
+	files, err = AnalyzeFileHistory(paths, projects, &mounts.Table{}, FileHistoryRules{KeepVersions: 2})
+	require.NoError(t, err)
+	assert.Equal(t, SnapshotOldVersion, reasons(files)["aaa@v1"])
+	assert.Empty(t, reasons(files)["aaa@v3"])
+
+	var main TrackedFile
+	for _, f := range files {
+		if f.Source == kept {
+			main = f
+		}
+	}
+	require.Len(t, main.Snapshots, 3)
+	assert.Equal(t, 3, main.Snapshots[0].Version, "newest first")
+	assert.Equal(t, int64(18), main.Size())
+	require.Len(t, main.Prunable(), 1)
+
+	preview := BuildFileHistoryPreview(files)
+	assert.Len(t, preview.Changes, 3)
+	assert.Contains(t, preview.Changes[0].Description, "v1, older version")
+
+	// Removing a snapshot leaves the others alone
+	require.NoError(t, CleanSnapshot(main.Prunable()[0], nil, false))
+	assert.NoFileExists(t, main.Snapshots[2].Path)
+	assert.FileExists(t, main.Snapshots[1].Path)
+}
+
+func TestAnalyzeFileHistory_SkipsUnavailableProjects(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+
+	mnt := t.TempDir()
+	p := fileHistorySession(t, paths, "usb", filepath.Join(mnt, "usb", "project"), map[string]string{
+		"aaa@v1": filepath.Join(mnt, "usb", "project", "main.go"),
+	})
+
+	files, err := AnalyzeFileHistory(paths, []claude.Project{p}, &mounts.Table{Bases: []string{mnt}}, FileHistoryRules{})
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
@@ -0,0 +1,168 @@
+package cleaner
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/permissions"
+)
+
+// Problems reported by LintSettings.
+const (
+	LintConflict  = "conflict"  // The same rule is in two lists, the stronger one wins
+	LintDead      = "dead"      // A broader rule of a stronger list shadows the rule
+	LintDuplicate = "duplicate" // The rule is repeated or covered within its list
+	LintInvalid   = "invalid"   // The rule cannot be parsed
+)
+
+// permissionLists are the permission lists from the strongest to the
+// weakest: Claude Code checks deny rules first, then ask, then allow,
+// whatever the layer they come from.
+var permissionLists = []string{"deny", "ask", "allow"}
+
+// LintFinding is a problem with a rule of a settings file.
+type LintFinding struct {
+	Problem string
+	Layer   string
+	Path    string
+	List    string
+	Rule    string
+
+	// The other rule involved, if any
+	By      string
+	ByList  string
+	ByLayer string
+	ByPath  string
+
+	Err error // Why an invalid rule cannot be parsed
+}
+
+// Description explains the finding.
+func (f LintFinding) Description() string {
+	switch f.Problem {
+	case LintConflict:
+		return fmt.Sprintf("%s %s is also in %s of %s settings; %s wins", f.List, f.Rule, f.ByList, f.ByLayer, f.ByList)
+	case LintDead:
+		return fmt.Sprintf("%s %s is shadowed by %s %s of %s settings", f.List, f.Rule, f.ByList, f.By, f.ByLayer)
+	case LintDuplicate:
+		if f.By == f.Rule {
+			return fmt.Sprintf("%s %s is repeated", f.List, f.Rule)
+		}
+		return fmt.Sprintf("%s %s is covered by %s", f.List, f.Rule, f.By)
+	case LintInvalid:
+		return fmt.Sprintf("%s %s: %v", f.List, f.Rule, f.Err)
+	}
+	return f.Problem
+}
+
+// layerRule is a parsed rule of a settings layer.
+type layerRule struct {
+	rule  permissions.Rule
+	layer claude.SettingsLayer
+	list  string
+}
+
+// LintSettings analyzes the rule set that layers, given in order of
+// precedence, add up to. It reports rules in two lists, rules that a broader
+// rule of a stronger list shadows and so never take effect, and rules
+// repeated or covered within their list. Rules relative to a settings file
+// are only compared with those of the same directory.
+func LintSettings(layers []claude.SettingsLayer) []LintFinding {
+	var findings []LintFinding
+	var all []layerRule
+	for _, l := range layers {
+		if l.Settings == nil {
+			continue
+		}
+		for _, list := range permissionLists {
+			var rules []permissions.Rule
+			for _, v := range permissionList(l.Settings, list) {
+				r, err := permissions.Parse(v)
+				if err != nil {
+					findings = append(findings, LintFinding{Problem: LintInvalid, Layer: l.Name, Path: l.Path, List: list, Rule: v, Err: err})
+					continue
+				}
+				rules = append(rules, r)
+				all = append(all, layerRule{r, l, list})
+			}
+			findings = append(findings, lintDuplicates(l, list, rules)...)
+		}
+	}
+
+	// Rules shadowed by a stronger list, reported once if repeated
+	reported := make(map[layerRule]bool)
+	for _, weak := range all {
+		if reported[weak] {
+			continue
+		}
+		if f, ok := lintShadowed(weak, all); ok {
+			findings = append(findings, f)
+			reported[weak] = true
+		}
+	}
+	return findings
+}
+
+// lintDuplicates reports the rules of a list that are repeated or covered by
+// another rule of the list.
+func lintDuplicates(l claude.SettingsLayer, list string, rules []permissions.Rule) []LintFinding {
+	var findings []LintFinding
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if seen[r.Raw] {
+			findings = append(findings, LintFinding{Problem: LintDuplicate, Layer: l.Name, Path: l.Path, List: list, Rule: r.Raw,
+				By: r.Raw, ByList: list, ByLayer: l.Name, ByPath: l.Path})
+		}
+		seen[r.Raw] = true
+	}
+
+	covered := coveredWithin(rules, nil)
+	reported := make(map[string]bool)
+	for i, r := range rules {
+		j, ok := covered[i]
+		if !ok || reported[r.Raw] {
+			continue
+		}
+		findings = append(findings, LintFinding{Problem: LintDuplicate, Layer: l.Name, Path: l.Path, List: list, Rule: r.Raw,
+			By: rules[j].Raw, ByList: list, ByLayer: l.Name, ByPath: l.Path})
+		reported[r.Raw] = true
+	}
+	return findings
+}
+
+// lintShadowed reports whether a rule of a stronger list than weak's, in any
+// layer, is the same as weak or covers it. The same rule is preferred over a
+// broader one.
+func lintShadowed(weak layerRule, all []layerRule) (LintFinding, bool) {
+	sameBase := func(strong layerRule) bool {
+		return !weak.rule.IsRelative() && !strong.rule.IsRelative() ||
+			filepath.Dir(strong.layer.Path) == filepath.Dir(weak.layer.Path)
+	}
+
+	for _, list := range permissionLists[:slices.Index(permissionLists, weak.list)] {
+		for _, problem := range []string{LintConflict, LintDead} {
+			for _, strong := range all {
+				if strong.list != list || !sameBase(strong) {
+					continue
+				}
+				if problem == LintConflict && strong.rule.Raw != weak.rule.Raw || !strong.rule.Covers(weak.rule) {
+					continue
+				}
+				return LintFinding{
+					Problem: problem,
+					Layer:   weak.layer.Name,
+					Path:    weak.layer.Path,
+					List:    weak.list,
+					Rule:    weak.rule.Raw,
+					By:      strong.rule.Raw,
+					ByList:  strong.list,
+					ByLayer: strong.layer.Name,
+					ByPath:  strong.layer.Path,
+				}, true
+			}
+		}
+	}
+	return LintFinding{}, false
+}
@@ -0,0 +1,71 @@
+package cleaner
+
+import (
+	"testing"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintSettings(t *testing.T) {
+	user := claude.SettingsLayer{Name: claude.LayerUser, Path: "/home/u/.claude/settings.json", Settings: &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(npm:*)", "Bash(npm test)"},
+			Deny:  []string{"Bash(git push:*)", "Bash(rm:*)"},
+		},
+	}}
+	local := claude.SettingsLayer{Name: claude.LayerLocal, Path: "/p/.claude/settings.local.json", Settings: &claude.Settings{
+		Permissions: claude.Permissions{
+			Allow: []string{"Bash(rm:*)", "Bash(git push --force:*)", "Bash(make)", "Bash(make)", "Bash(go"},
+			Ask:   []string{"Bash(make)"},
+		},
+	}}
+
+	findings := LintSettings([]claude.SettingsLayer{user, local})
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Problem+" "+f.Path+" "+f.Description())
+	}
+	assert.ElementsMatch(t, []string{
+		"duplicate /home/u/.claude/settings.json allow Bash(npm test) is covered by Bash(npm:*)",
+		"invalid /p/.claude/settings.local.json allow Bash(go: invalid rule \"Bash(go\": missing closing parenthesis",
+		"duplicate /p/.claude/settings.local.json allow Bash(make) is repeated",
+		"conflict /p/.claude/settings.local.json allow Bash(rm:*) is also in deny of user settings; deny wins",
+		"dead /p/.claude/settings.local.json allow Bash(git push --force:*) is shadowed by deny Bash(git push:*) of user settings",
+		"conflict /p/.claude/settings.local.json allow Bash(make) is also in ask of local settings; ask wins",
+	}, got)
+}
+
+func TestLintSettings_Clean(t *testing.T) {
+	layers := []claude.SettingsLayer{
+		{Name: claude.LayerUser, Settings: &claude.Settings{
+			Permissions: claude.Permissions{Allow: []string{"Bash(git:*)"}, Deny: []string{"Bash(git push:*)"}},
+		}},
+		{Name: claude.LayerManaged, Settings: nil},
+	}
+
+	assert.Empty(t, LintSettings(layers), "a broad allow with a narrower deny is intended")
+}
+
+func TestLintSettings_RelativeRulesOfOtherDirectories(t *testing.T) {
+	layers := []claude.SettingsLayer{
+		{Name: claude.LayerUser, Path: "/home/u/.claude/settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Deny: []string{"Read(./secrets/**)"}},
+		}},
+		{Name: claude.LayerProject, Path: "/p/.claude/settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Deny: []string{"Edit(./build/**)"}},
+		}},
+		{Name: claude.LayerLocal, Path: "/p/.claude/settings.local.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Allow: []string{"Read(./secrets/key)", "Edit(./build/out)"}},
+		}},
+	}
+
+	findings := LintSettings(layers)
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, LintDead, findings[0].Problem)
+	assert.Equal(t, "Edit(./build/out)", findings[0].Rule)
+	assert.Equal(t, "/p/.claude/settings.json", findings[0].ByPath)
+}
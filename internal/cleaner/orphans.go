@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
 	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
@@ -13,35 +14,97 @@ import (
 type OrphanType string
 
 const (
-	OrphanTypeEmptySession OrphanType = "empty_session"
-	OrphanTypeTodo         OrphanType = "todo"
-	OrphanTypeFileHistory  OrphanType = "file_history"
-	OrphanTypeSessionEnv   OrphanType = "session_env"
+	OrphanTypeEmptySession   OrphanType = "empty_session"
+	OrphanTypeTodo           OrphanType = "todo"
+	OrphanTypeFileHistory    OrphanType = "file_history"
+	OrphanTypeSessionEnv     OrphanType = "session_env"
+	OrphanTypeShellSnapshot  OrphanType = "shell_snapshot"
+	OrphanTypeDebugLog       OrphanType = "debug_log"
+	OrphanTypeStatsig        OrphanType = "statsig"
+	OrphanTypeIDELock        OrphanType = "ide_lock"
+	OrphanTypePlan           OrphanType = "plan"
+	OrphanTypeAgent          OrphanType = "agent"
+	OrphanTypeAgentTodo      OrphanType = "agent_todo"
+	OrphanTypeTrivialSession OrphanType = "trivial_session"
 )
 
 // OrphanResult represents an orphan item found during scanning.
 type OrphanResult struct {
 	Type      OrphanType
 	Path      string
-	SizeSaved int64
+	SizeSaved int64 // Including the linked data
+
+	// For trivial sessions
+	SessionID string
+	Reason    string   // Why the session is trivial
+	Linked    []string // Todos, file-history, session-env and agent transcripts of the session
 }
 
 // FindOrphans scans the Claude directories for orphan data.
-// validSessionIDs is a list of session IDs that are still valid.
-func FindOrphans(paths *claude.Paths, validSessionIDs []string) ([]OrphanResult, error) {
-	validIDs := make(map[string]struct{}, len(validSessionIDs))
-	for _, id := range validSessionIDs {
+// validSessionIDs is a list of session IDs that are still valid, and
+// planSlugs lists the plans those sessions refer to. Agent transcripts and
+// the todos of their agents are only checked by FindInventoryOrphans.
+func FindOrphans(paths *claude.Paths, validSessionIDs, planSlugs []string, rules OrphanRules) ([]OrphanResult, error) {
+	// Find empty session files
+	emptyOrphans, err := findEmptySessions(paths.Projects)
+	if err != nil {
+		return nil, err
+	}
+
+	return findOrphanData(paths, validSessionIDs, planSlugs, rules, emptyOrphans)
+}
+
+// FindInventoryOrphans finds orphan data like FindOrphans, but takes the
+// empty session files, valid session IDs and plan slugs from inv instead of
+// scanning the projects directory again. It also finds the agent transcripts
+// of deleted sessions, the todos of agents whose transcript is gone and, if
+// rules.Trivial is set, trivial sessions.
+func FindInventoryOrphans(paths *claude.Paths, inv *claude.Inventory, rules OrphanRules) ([]OrphanResult, error) {
+	var emptyOrphans []OrphanResult
+	for _, p := range inv.Projects {
+		for _, path := range p.EmptySessions {
+			emptyOrphans = append(emptyOrphans, OrphanResult{
+				Type: OrphanTypeEmptySession,
+				Path: path,
+			})
+		}
+	}
+
+	validIDs := make(map[string]struct{})
+	for _, id := range inv.SessionIDs() {
 		validIDs[id] = struct{}{}
 	}
+	emptyOrphans = append(emptyOrphans, findOrphanAgents(inv, validIDs)...)
 
-	var orphans []OrphanResult
+	orphans, err := findOrphanData(paths, inv.SessionIDs(), inv.PlanSlugs(), rules, emptyOrphans)
+	if err != nil {
+		return nil, err
+	}
 
-	// Find empty session files
-	emptyOrphans, err := findEmptySessions(paths.Projects)
+	agentTodoOrphans, err := findOrphanAgentTodos(paths.Todos, inv, validIDs)
 	if err != nil {
 		return nil, err
 	}
-	orphans = append(orphans, emptyOrphans...)
+	orphans = append(orphans, agentTodoOrphans...)
+
+	if rules.Trivial != nil {
+		now := rules.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		orphans = append(orphans, findTrivialSessions(paths, inv, *rules.Trivial, now)...)
+	}
+	return orphans, nil
+}
+
+// findOrphanData adds the orphan todos, file-history, session-env
+// directories and plans, the old shell snapshots, debug logs and statsig
+// caches, and the stale IDE locks to the given empty session orphans.
+func findOrphanData(paths *claude.Paths, validSessionIDs, planSlugs []string, rules OrphanRules, orphans []OrphanResult) ([]OrphanResult, error) {
+	validIDs := make(map[string]struct{}, len(validSessionIDs))
+	for _, id := range validSessionIDs {
+		validIDs[id] = struct{}{}
+	}
 
 	// Find orphan todos
 	todoOrphans, err := findOrphanTodos(paths.Todos, validIDs)
@@ -64,6 +127,48 @@ func FindOrphans(paths *claude.Paths, validSessionIDs []string) ([]OrphanResult,
 	}
 	orphans = append(orphans, envOrphans...)
 
+	// Find plans no session refers to
+	slugs := make(map[string]struct{}, len(planSlugs))
+	for _, slug := range planSlugs {
+		slugs[slug] = struct{}{}
+	}
+	planOrphans, err := findOrphanPlans(paths.Plans, slugs)
+	if err != nil {
+		return nil, err
+	}
+	orphans = append(orphans, planOrphans...)
+
+	// Find old shell snapshots, debug logs and statsig caches
+	now := rules.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, dir := range []struct {
+		path string
+		typ  OrphanType
+	}{
+		{paths.ShellSnapshots, OrphanTypeShellSnapshot},
+		{paths.Debug, OrphanTypeDebugLog},
+	} {
+		old, err := findOldFiles(dir.path, dir.typ, rules.MaxAge, now)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, old...)
+	}
+	statsigOrphans, err := findOldStatsig(paths.Statsig, rules.MaxAge, now)
+	if err != nil {
+		return nil, err
+	}
+	orphans = append(orphans, statsigOrphans...)
+
+	// Find lock files of IDEs that are no longer running
+	lockOrphans, err := findStaleIDELocks(paths.IDE)
+	if err != nil {
+		return nil, err
+	}
+	orphans = append(orphans, lockOrphans...)
+
 	return orphans, nil
 }
 
@@ -276,9 +381,10 @@ func dirSize(path string) (int64, error) {
 	return size, err
 }
 
-// CleanOrphans removes the orphan items.
+// CleanOrphans removes the orphan items and their linked data, moving them
+// into q if one is given.
 // If dryRun is true, returns what would be deleted without making changes.
-func CleanOrphans(orphans []OrphanResult, dryRun bool) ([]OrphanResult, error) {
+func CleanOrphans(orphans []OrphanResult, q Quarantine, dryRun bool) ([]OrphanResult, error) {
 	results := make([]OrphanResult, len(orphans))
 	copy(results, orphans)
 
@@ -290,7 +396,7 @@ func CleanOrphans(orphans []OrphanResult, dryRun bool) ([]OrphanResult, error) {
 		path := results[i].Path
 
 		// Check if path exists
-		info, err := os.Stat(path)
+		_, err := os.Stat(path)
 		if os.IsNotExist(err) {
 			results[i].SizeSaved = 0
 			continue
@@ -300,12 +406,14 @@ func CleanOrphans(orphans []OrphanResult, dryRun bool) ([]OrphanResult, error) {
 		}
 
 		// Remove file or directory
-		if info.IsDir() {
-			if err := os.RemoveAll(path); err != nil {
-				return results, err
+		if err := removePath(q, path); err != nil {
+			return results, err
+		}
+		for _, linked := range results[i].Linked {
+			if _, err := os.Lstat(linked); os.IsNotExist(err) {
+				continue
 			}
-		} else {
-			if err := os.Remove(path); err != nil {
+			if err := removePath(q, linked); err != nil {
 				return results, err
 			}
 		}
@@ -331,6 +439,25 @@ func BuildOrphanPreview(orphans []OrphanResult) *ui.Preview {
 			description = "Orphan file history"
 		case OrphanTypeSessionEnv:
 			description = "Empty session env"
+		case OrphanTypeShellSnapshot:
+			description = "Old shell snapshot"
+		case OrphanTypeDebugLog:
+			description = "Old debug log"
+		case OrphanTypeStatsig:
+			description = "Old statsig cache"
+		case OrphanTypeIDELock:
+			description = "Lock file of an IDE that is no longer running"
+		case OrphanTypePlan:
+			description = "Plan of no existing session"
+		case OrphanTypeAgent:
+			description = "Agent transcript of a deleted session"
+		case OrphanTypeAgentTodo:
+			description = "Todo of an agent whose transcript is gone"
+		case OrphanTypeTrivialSession:
+			description = "Trivial session: " + o.Reason
+			if len(o.Linked) > 0 {
+				description += "; with " + formatLinked(o.Linked)
+			}
 		}
 
 		preview.Changes = append(preview.Changes, ui.Change{
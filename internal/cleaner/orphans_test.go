@@ -1,6 +1,7 @@
 package cleaner
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -32,7 +33,7 @@ func TestFindOrphans_EmptySessionFiles(t *testing.T) {
 	validSession := filepath.Join(projectDir, "valid.jsonl")
 	require.NoError(t, os.WriteFile(validSession, []byte(`{"sessionId":"sess1","cwd":"/test"}`), 0644))
 
-	orphans, err := FindOrphans(paths, []string{"sess1"})
+	orphans, err := FindOrphans(paths, []string{"sess1"}, nil, OrphanRules{})
 	require.NoError(t, err)
 
 	// Should find the empty session file
@@ -46,6 +47,37 @@ func TestFindOrphans_EmptySessionFiles(t *testing.T) {
 	assert.Equal(t, emptySession, emptySessionOrphans[0].Path)
 }
 
+func TestFindInventoryOrphans_MatchesFindOrphans(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths := &claude.Paths{
+		Root:        tmpDir,
+		Projects:    filepath.Join(tmpDir, "projects"),
+		Todos:       filepath.Join(tmpDir, "todos"),
+		FileHistory: filepath.Join(tmpDir, "file-history"),
+		SessionEnv:  filepath.Join(tmpDir, "session-env"),
+	}
+
+	projectDir := filepath.Join(paths.Projects, "-test-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "empty.jsonl"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "valid.jsonl"), []byte(`{"sessionId":"sess1","cwd":"/test"}`), 0644))
+	require.NoError(t, os.MkdirAll(paths.Todos, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Todos, "sess1-agent-a.json"), []byte("[]"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Todos, "gone-agent-a.json"), []byte("[]"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.FileHistory, "gone"), 0755))
+
+	inv, err := claude.ScanInventory(context.Background(), paths.Projects, 0, nil)
+	require.NoError(t, err)
+
+	fromInventory, err := FindInventoryOrphans(paths, inv, OrphanRules{})
+	require.NoError(t, err)
+	scanned, err := FindOrphans(paths, inv.SessionIDs(), inv.PlanSlugs(), OrphanRules{})
+	require.NoError(t, err)
+
+	assert.Len(t, fromInventory, 3)
+	assert.Equal(t, scanned, fromInventory)
+}
+
 func TestFindOrphans_OrphanTodos(t *testing.T) {
 	tmpDir := t.TempDir()
 	paths := &claude.Paths{
@@ -66,7 +98,7 @@ func TestFindOrphans_OrphanTodos(t *testing.T) {
 	orphanTodo := filepath.Join(paths.Todos, "orphan-sess-agent-xyz.json")
 	require.NoError(t, os.WriteFile(orphanTodo, []byte(`{}`), 0644))
 
-	orphans, err := FindOrphans(paths, []string{"sess1"})
+	orphans, err := FindOrphans(paths, []string{"sess1"}, nil, OrphanRules{})
 	require.NoError(t, err)
 
 	// Should find the orphan todo
@@ -102,7 +134,7 @@ func TestFindOrphans_OrphanFileHistory(t *testing.T) {
 	require.NoError(t, os.MkdirAll(orphanHistory, 0755))
 	require.NoError(t, os.WriteFile(filepath.Join(orphanHistory, "file.txt"), []byte("content"), 0644))
 
-	orphans, err := FindOrphans(paths, []string{"sess1"})
+	orphans, err := FindOrphans(paths, []string{"sess1"}, nil, OrphanRules{})
 	require.NoError(t, err)
 
 	// Should find the orphan file-history
@@ -137,7 +169,7 @@ func TestFindOrphans_EmptySessionEnv(t *testing.T) {
 	emptyEnv := filepath.Join(paths.SessionEnv, "sess2")
 	require.NoError(t, os.MkdirAll(emptyEnv, 0755))
 
-	orphans, err := FindOrphans(paths, []string{"sess1", "sess2"})
+	orphans, err := FindOrphans(paths, []string{"sess1", "sess2"}, nil, OrphanRules{})
 	require.NoError(t, err)
 
 	// Should find the empty session-env
@@ -167,7 +199,7 @@ func TestFindOrphans_NoOrphans(t *testing.T) {
 	require.NoError(t, os.MkdirAll(paths.FileHistory, 0755))
 	require.NoError(t, os.MkdirAll(paths.SessionEnv, 0755))
 
-	orphans, err := FindOrphans(paths, []string{"sess1"})
+	orphans, err := FindOrphans(paths, []string{"sess1"}, nil, OrphanRules{})
 	require.NoError(t, err)
 	assert.Empty(t, orphans)
 }
@@ -183,7 +215,7 @@ func TestFindOrphans_MissingDirectories(t *testing.T) {
 	}
 
 	// Don't create any directories - should handle gracefully
-	orphans, err := FindOrphans(paths, []string{"sess1"})
+	orphans, err := FindOrphans(paths, []string{"sess1"}, nil, OrphanRules{})
 	require.NoError(t, err)
 	assert.Empty(t, orphans)
 }
@@ -201,7 +233,7 @@ func TestCleanOrphans_DryRun(t *testing.T) {
 		},
 	}
 
-	results, err := CleanOrphans(orphans, true)
+	results, err := CleanOrphans(orphans, nil, true)
 	require.NoError(t, err)
 
 	// Dry run should not delete
@@ -235,7 +267,7 @@ func TestCleanOrphans_ActualDelete(t *testing.T) {
 		},
 	}
 
-	results, err := CleanOrphans(orphans, false)
+	results, err := CleanOrphans(orphans, nil, false)
 	require.NoError(t, err)
 
 	// Should have deleted both
@@ -254,7 +286,7 @@ func TestCleanOrphans_NonexistentPath(t *testing.T) {
 	}
 
 	// Should not error for nonexistent paths
-	results, err := CleanOrphans(orphans, false)
+	results, err := CleanOrphans(orphans, nil, false)
 	require.NoError(t, err)
 	assert.Len(t, results, 1)
 	assert.Equal(t, int64(0), results[0].SizeSaved)
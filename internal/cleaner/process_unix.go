@@ -0,0 +1,15 @@
+//go:build !windows
+
+package cleaner
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the given PID exists. A
+// process of another user, which may not be signaled, counts as alive.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
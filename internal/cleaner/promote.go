@@ -0,0 +1,305 @@
+package cleaner
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/permissions"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// Promotion is a permission rule that enough local configs have in common to
+// move it into the user settings.
+type Promotion struct {
+	List    string // "allow", "deny" or "ask"
+	Rule    string
+	Configs []string // The local configs that have the rule
+}
+
+// FindPromotions counts the local configs that have each rule and returns the
+// rules found in at least minConfigs of them, the most common first, to be
+// promoted into the user layer of global, the managed and user settings.
+// Rules that the same list of global already covers are left to
+// deduplication. Rules relative to a settings file are skipped, as they would
+// mean something else in the user settings, and so are rules that cannot be
+// parsed.
+//
+// A rule that a stronger list of global or of another promoted rule shadows,
+// or that another promoted rule of its list covers, would be dead or
+// redundant in the user settings and be reported by LintSettings. Such rules
+// are not promoted but returned as findings that explain why.
+func FindPromotions(locals map[string]*claude.Settings, global []claude.SettingsLayer, minConfigs int) ([]Promotion, []LintFinding) {
+	type key struct{ list, rule string }
+	configs := make(map[key][]string)
+
+	for _, path := range slices.Sorted(maps.Keys(locals)) {
+		for _, list := range permissionLists {
+			for _, v := range permissionList(locals[path], list) {
+				k := key{list, v}
+				if !slices.Contains(configs[k], path) {
+					configs[k] = append(configs[k], path)
+				}
+			}
+		}
+	}
+
+	var candidates []Promotion
+	for k, paths := range configs {
+		if len(paths) < max(minConfigs, 1) || coveredByLayers(k.rule, k.list, global) {
+			continue
+		}
+		if r, err := permissions.Parse(k.rule); err != nil || r.IsRelative() {
+			continue
+		}
+		candidates = append(candidates, Promotion{List: k.list, Rule: k.rule, Configs: paths})
+	}
+
+	slices.SortFunc(candidates, func(a, b Promotion) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Configs), len(a.Configs)),
+			cmp.Compare(slices.Index(permissionLists, a.List), slices.Index(permissionLists, b.List)),
+			strings.Compare(a.Rule, b.Rule),
+		)
+	})
+	return lintPromotions(candidates, global)
+}
+
+// coveredByLayers reports whether a rule of list in one of layers is the same
+// as rule or covers it.
+func coveredByLayers(rule, list string, layers []claude.SettingsLayer) bool {
+	for _, l := range layers {
+		if l.Settings != nil && coveredBy(rule, permissionList(l.Settings, list)) {
+			return true
+		}
+	}
+	return false
+}
+
+// coveredBy reports whether one of rules is the same as rule or covers it.
+func coveredBy(rule string, rules []string) bool {
+	r, err := permissions.Parse(rule)
+	for _, v := range rules {
+		if v == rule {
+			return true
+		}
+		if broad, perr := permissions.Parse(v); err == nil && perr == nil && broad.Covers(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintPromotions splits candidates into the rules to promote and findings for
+// those that would be shadowed or covered in the user settings, checked as
+// LintSettings checks them.
+func lintPromotions(candidates []Promotion, global []claude.SettingsLayer) ([]Promotion, []LintFinding) {
+	user := claude.SettingsLayer{Name: claude.LayerUser}
+	var all []layerRule
+	for _, l := range global {
+		if l.Name == claude.LayerUser {
+			user = claude.SettingsLayer{Name: l.Name, Path: l.Path}
+		}
+		if l.Settings == nil {
+			continue
+		}
+		for _, list := range permissionLists {
+			for _, v := range permissionList(l.Settings, list) {
+				if r, err := permissions.Parse(v); err == nil {
+					all = append(all, layerRule{r, l, list})
+				}
+			}
+		}
+	}
+
+	promoted := make([]layerRule, len(candidates))
+	for i, p := range candidates {
+		r, _ := permissions.Parse(p.Rule)
+		promoted[i] = layerRule{r, user, p.List}
+	}
+	all = append(all, promoted...)
+
+	dropped := make(map[int]LintFinding)
+	for i, weak := range promoted {
+		if f, ok := lintShadowed(weak, all); ok {
+			dropped[i] = f
+		}
+	}
+	for _, list := range permissionLists {
+		var indexes []int
+		var rules []permissions.Rule
+		for i, p := range promoted {
+			if _, ok := dropped[i]; !ok && p.list == list {
+				indexes = append(indexes, i)
+				rules = append(rules, p.rule)
+			}
+		}
+		for _, f := range lintDuplicates(user, list, rules) {
+			for _, i := range indexes {
+				if promoted[i].rule.Raw == f.Rule {
+					dropped[i] = f
+				}
+			}
+		}
+	}
+
+	var promotions []Promotion
+	var findings []LintFinding
+	for i, p := range candidates {
+		if f, ok := dropped[i]; ok {
+			findings = append(findings, f)
+			continue
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, findings
+}
+
+// PlanPromotion returns the changes to the local configs that strip the
+// promoted rules from them, as deduplication results that ApplyDedup applies.
+// A config that holds nothing else is suggested for deletion; ApplyDedup
+// re-reads it and only deletes it if that still holds.
+func PlanPromotion(promotions []Promotion, locals map[string]*claude.Settings) []DedupResult {
+	byPath := make(map[string]*DedupResult)
+	var results []*DedupResult
+	for _, p := range promotions {
+		for _, path := range p.Configs {
+			r, ok := byPath[path]
+			if !ok {
+				r = &DedupResult{LocalPath: path}
+				byPath[path] = r
+				results = append(results, r)
+			}
+			switch p.List {
+			case "allow":
+				r.DuplicateAllow = append(r.DuplicateAllow, p.Rule)
+			case "deny":
+				r.DuplicateDeny = append(r.DuplicateDeny, p.Rule)
+			case "ask":
+				r.DuplicateAsk = append(r.DuplicateAsk, p.Rule)
+			}
+		}
+	}
+
+	planned := make([]DedupResult, 0, len(results))
+	for _, r := range results {
+		rest := locals[r.LocalPath].Diff(&claude.Settings{Permissions: claude.Permissions{
+			Allow: r.DuplicateAllow,
+			Deny:  r.DuplicateDeny,
+			Ask:   r.DuplicateAsk,
+		}})
+		r.SuggestDelete = rest.IsEmpty()
+		planned = append(planned, *r)
+	}
+	slices.SortFunc(planned, func(a, b DedupResult) int { return strings.Compare(a.LocalPath, b.LocalPath) })
+	return planned
+}
+
+// ApplyPromotion adds the promoted rules to the user settings at path in a
+// single atomic rewrite, like ApplyDedup removes them from local configs. If
+// the file does not exist, it is created. If q is given, the original file is
+// kept in it, or a created file recorded, so the change can be undone.
+func ApplyPromotion(path string, promotions []Promotion, q Quarantine) error {
+	edit := func(doc *claude.Document) error {
+		for _, list := range permissionLists {
+			var rules []string
+			for _, p := range promotions {
+				if p.List == list {
+					rules = append(rules, p.Rule)
+				}
+			}
+			if _, err := doc.AppendStrings([]string{"permissions", list}, rules); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		doc, err := claude.ParseDocument([]byte("{\n}\n"))
+		if err != nil {
+			return err
+		}
+		if err := edit(doc); err != nil {
+			return err
+		}
+		err = claude.CreateFile(path, doc.Bytes())
+		if err == nil {
+			if err := createdPath(q, path); err != nil {
+				// Leave no file behind that restoring the run would not remove
+				_ = os.Remove(path)
+				return err
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		// Created in the meantime, add to it
+	}
+
+	preserved := false
+	_, err := claude.EditFile(path, func(doc *claude.Document) error {
+		if !preserved {
+			// Keep the content the edit starts from, once
+			if err := preservePath(q, path); err != nil {
+				return err
+			}
+			preserved = true
+		}
+		return edit(doc)
+	})
+	return err
+}
+
+// BuildPromotionPreview creates a preview of the rules added to the user
+// settings at userPath and of the local configs they are stripped from. The
+// rules held back by FindPromotions are listed as kept.
+func BuildPromotionPreview(userPath string, promotions []Promotion, locals []DedupResult, held []LintFinding) *ui.Preview {
+	preview := &ui.Preview{
+		Title: "Config Promotion",
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Add to the user settings, applying to all projects:")
+	for _, p := range promotions {
+		sb.WriteString(fmt.Sprintf("\n     %s: %s (in %d local configs)", p.List, p.Rule, len(p.Configs)))
+	}
+	preview.Changes = append(preview.Changes, ui.Change{
+		Action:      ui.ActionModify,
+		Path:        userPath,
+		Description: sb.String(),
+	})
+
+	for _, r := range locals {
+		action := ui.ActionModify
+		description := fmt.Sprintf("%d promoted entries to remove", r.TotalDuplicates())
+		if r.TotalDuplicates() == 1 {
+			description = "1 promoted entry to remove"
+		}
+		if r.SuggestDelete {
+			action = ui.ActionDelete
+			description = "Empty after promotion, will be deleted"
+		}
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      action,
+			Path:        r.LocalPath,
+			Description: description,
+		})
+	}
+
+	for _, f := range held {
+		preview.Kept = append(preview.Kept, ui.Change{
+			Path:        userPath,
+			Description: "Not promoted, " + f.Description(),
+		})
+	}
+
+	return preview
+}
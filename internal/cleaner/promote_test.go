@@ -0,0 +1,204 @@
+package cleaner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func allowSettings(rules ...string) *claude.Settings {
+	return &claude.Settings{Permissions: claude.Permissions{Allow: rules}}
+}
+
+func TestFindPromotions(t *testing.T) {
+	locals := map[string]*claude.Settings{
+		"/a/.claude/settings.local.json": allowSettings("Bash(git status)", "Bash(make:*)", "Read(./src/**)"),
+		"/b/.claude/settings.local.json": allowSettings("Bash(git status)", "Bash(make:*)", "Read(./src/**)", "Bash(npm test)"),
+		"/c/.claude/settings.local.json": {Permissions: claude.Permissions{
+			Allow: []string{"Bash(git status)", "Bash(git status)", "Read(./src/**)", "Bash(npm test)"},
+			Deny:  []string{"Bash(rm -rf:*)"},
+		}},
+		"/d/.claude/settings.local.json": {Permissions: claude.Permissions{
+			Allow: []string{"Bash(make:*)", "Bash(npm test)"},
+			Deny:  []string{"Bash(rm -rf:*)"},
+		}},
+	}
+	global := []claude.SettingsLayer{{Name: claude.LayerUser, Path: "/home/.claude/settings.json", Settings: allowSettings("Bash(npm:*)")}}
+
+	promotions, held := FindPromotions(locals, global, 2)
+
+	assert.Equal(t, []Promotion{
+		{List: "allow", Rule: "Bash(git status)", Configs: []string{
+			"/a/.claude/settings.local.json", "/b/.claude/settings.local.json", "/c/.claude/settings.local.json",
+		}},
+		{List: "allow", Rule: "Bash(make:*)", Configs: []string{
+			"/a/.claude/settings.local.json", "/b/.claude/settings.local.json", "/d/.claude/settings.local.json",
+		}},
+		{List: "deny", Rule: "Bash(rm -rf:*)", Configs: []string{
+			"/c/.claude/settings.local.json", "/d/.claude/settings.local.json",
+		}},
+	}, promotions, "relative rules and rules the user settings cover are skipped")
+	assert.Empty(t, held)
+
+	promotions, _ = FindPromotions(locals, global, 3)
+	assert.Len(t, promotions, 2)
+	promotions, _ = FindPromotions(locals, global, 5)
+	assert.Empty(t, promotions)
+}
+
+func TestFindPromotions_HoldsBackShadowedRules(t *testing.T) {
+	locals := map[string]*claude.Settings{
+		"/a/settings.local.json": {Permissions: claude.Permissions{
+			Allow: []string{"Bash(make:*)", "Bash(git push origin:*)", "Bash(go test:*)", "Bash(go test ./...)"},
+			Deny:  []string{"Bash(go test -race:*)"},
+		}},
+		"/b/settings.local.json": {Permissions: claude.Permissions{
+			Allow: []string{"Bash(make:*)", "Bash(git push origin:*)", "Bash(go test:*)", "Bash(go test ./...)", "Bash(ls)"},
+			Deny:  []string{"Bash(go test -race:*)"},
+		}},
+	}
+	global := []claude.SettingsLayer{
+		{Name: claude.LayerManaged, Path: "/etc/claude-code/managed-settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Deny: []string{"Bash(git push:*)"}},
+		}},
+		{Name: claude.LayerUser, Path: "/home/.claude/settings.json", Settings: &claude.Settings{
+			Permissions: claude.Permissions{Ask: []string{"Bash(make:*)"}},
+		}},
+	}
+
+	promotions, held := FindPromotions(locals, global, 2)
+
+	var promoted []string
+	for _, p := range promotions {
+		promoted = append(promoted, p.List+" "+p.Rule)
+	}
+	assert.Equal(t, []string{"deny Bash(go test -race:*)", "allow Bash(go test:*)"}, promoted)
+
+	var reasons []string
+	for _, f := range held {
+		assert.Equal(t, "/home/.claude/settings.json", f.Path)
+		reasons = append(reasons, f.Problem+": "+f.Description())
+	}
+	assert.ElementsMatch(t, []string{
+		"dead: allow Bash(git push origin:*) is shadowed by deny Bash(git push:*) of managed settings",
+		"conflict: allow Bash(make:*) is also in ask of user settings; ask wins",
+		"duplicate: allow Bash(go test ./...) is covered by Bash(go test:*)",
+	}, reasons)
+}
+
+func TestPlanPromotion(t *testing.T) {
+	locals := map[string]*claude.Settings{
+		"/b/settings.local.json": allowSettings("Bash(make:*)"),
+		"/a/settings.local.json": allowSettings("Bash(make:*)", "Bash(go test:*)"),
+	}
+	promotions := []Promotion{
+		{List: "allow", Rule: "Bash(make:*)", Configs: []string{"/a/settings.local.json", "/b/settings.local.json"}},
+	}
+
+	results := PlanPromotion(promotions, locals)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "/a/settings.local.json", results[0].LocalPath)
+	assert.Equal(t, []string{"Bash(make:*)"}, results[0].DuplicateAllow)
+	assert.False(t, results[0].SuggestDelete)
+	assert.Equal(t, "/b/settings.local.json", results[1].LocalPath)
+	assert.True(t, results[1].SuggestDelete)
+}
+
+func TestApplyPromotion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	original := "{\n  \"model\": \"opus\",\n  \"permissions\": {\n    \"allow\": [\n      \"Bash(ls)\"\n    ]\n  }\n}\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0600))
+	q := &fakeQuarantine{}
+
+	err := ApplyPromotion(path, []Promotion{
+		{List: "allow", Rule: "Bash(make:*)"},
+		{List: "deny", Rule: "Bash(rm -rf:*)"},
+	}, q)
+	require.NoError(t, err)
+
+	settings, err := claude.LoadSettings(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bash(ls)", "Bash(make:*)"}, settings.Permissions.Allow)
+	assert.Equal(t, []string{"Bash(rm -rf:*)"}, settings.Permissions.Deny)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "  \"model\": \"opus\",\n")
+	assert.Equal(t, []string{path}, q.preserved)
+}
+
+func TestApplyPromotion_CreatesUserSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+
+	q := &fakeQuarantine{}
+
+	err := ApplyPromotion(path, []Promotion{{List: "allow", Rule: "Bash(make:*)"}}, q)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, q.created, "restoring the run removes the file")
+	assert.Empty(t, q.preserved)
+
+	settings, err := claude.LoadSettings(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bash(make:*)"}, settings.Permissions.Allow)
+}
+
+func TestApplyPromotion_RemovesUnrecordedUserSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+
+	q := &fakeQuarantine{createErr: errors.New("trash is full")}
+
+	err := ApplyPromotion(path, []Promotion{{List: "allow", Rule: "Bash(make:*)"}}, q)
+	require.Error(t, err)
+	assert.NoFileExists(t, path, "a file the run cannot restore is not left behind")
+}
+
+func TestPlanPromotion_ReadsLocalsAgainWhenApplied(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "settings.local.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"permissions":{"allow":["Bash(make:*)"]}}`), 0600))
+	local, err := claude.LoadSettings(path)
+	require.NoError(t, err)
+
+	results := PlanPromotion([]Promotion{{List: "allow", Rule: "Bash(make:*)", Configs: []string{path}}},
+		map[string]*claude.Settings{path: local})
+	require.Len(t, results, 1)
+	require.True(t, results[0].SuggestDelete)
+
+	// Claude Code allows another tool before the plan is applied
+	require.NoError(t, os.WriteFile(path, []byte(`{"permissions":{"allow":["Bash(make:*)","Bash(ls)"]}}`), 0600))
+	require.NoError(t, ApplyDedup(&results[0], nil, false))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"permissions":{"allow":["Bash(ls)"]}}`, string(data))
+}
+
+func TestBuildPromotionPreview(t *testing.T) {
+	promotions := []Promotion{
+		{List: "allow", Rule: "Bash(make:*)", Configs: []string{"/a/settings.local.json", "/b/settings.local.json"}},
+	}
+	results := []DedupResult{
+		{LocalPath: "/a/settings.local.json", DuplicateAllow: []string{"Bash(make:*)"}},
+		{LocalPath: "/b/settings.local.json", DuplicateAllow: []string{"Bash(make:*)"}, SuggestDelete: true},
+	}
+
+	held := []LintFinding{{Problem: LintConflict, List: "allow", Rule: "Bash(make:*)", ByList: "ask", ByLayer: "user"}}
+
+	preview := BuildPromotionPreview("/home/settings.json", promotions, results, held)
+
+	assert.Equal(t, "Config Promotion", preview.Title)
+	require.Len(t, preview.Changes, 3)
+	assert.Equal(t, ui.ActionModify, preview.Changes[0].Action)
+	assert.Equal(t, "/home/settings.json", preview.Changes[0].Path)
+	assert.Contains(t, preview.Changes[0].Description, "allow: Bash(make:*) (in 2 local configs)")
+	assert.Equal(t, "1 promoted entry to remove", preview.Changes[1].Description)
+	assert.Equal(t, ui.ActionDelete, preview.Changes[2].Action)
+	require.Len(t, preview.Kept, 1)
+	assert.Equal(t, "Not promoted, allow Bash(make:*) is also in ask of user settings; ask wins", preview.Kept[0].Description)
+}
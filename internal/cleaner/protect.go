@@ -0,0 +1,124 @@
+package cleaner
+
+import (
+	"path/filepath"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// Protector decides which projects and files the cleaners must leave alone,
+// e.g. because a policy excludes them.
+type Protector interface {
+	// Protects returns the reason why path must be left untouched, or "" if
+	// it may be cleaned.
+	Protects(path string) string
+}
+
+// Protectors combines several protectors. A path is protected if any of them
+// protects it; the reason of the first one is used.
+type Protectors []Protector
+
+// Protects implements Protector.
+func (ps Protectors) Protects(path string) string {
+	for _, p := range ps {
+		if reason := protects(p, path); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// protects returns the reason p protects path; a nil Protector protects nothing.
+func protects(p Protector, path string) string {
+	if p == nil || path == "" {
+		return ""
+	}
+	return p.Protects(path)
+}
+
+// ProtectProjects splits projects into those that may be cleaned and the
+// kept preview entries, with reasons, of those that are protected.
+func ProtectProjects(projects []claude.Project, p Protector) ([]claude.Project, []ui.Change) {
+	var allowed []claude.Project
+	var kept []ui.Change
+	for _, project := range projects {
+		if reason := protects(p, project.ActualPath); reason != "" {
+			kept = append(kept, ui.Change{
+				Path:        project.ActualPath,
+				Description: reason,
+				Size:        project.TotalSize,
+			})
+			continue
+		}
+		allowed = append(allowed, project)
+	}
+	return allowed, kept
+}
+
+// ProtectOrphans removes protected items from orphans. Session files and
+// agent transcripts are checked against the path of the project they
+// belong to, everything else against its own path.
+func ProtectOrphans(orphans []OrphanResult, projects []claude.Project, p Protector) ([]OrphanResult, []ui.Change) {
+	projectPaths := make(map[string]string, len(projects))
+	for _, project := range projects {
+		projectPaths[project.EncodedName] = project.ActualPath
+	}
+
+	var allowed []OrphanResult
+	var kept []ui.Change
+	for _, o := range orphans {
+		path := o.Path
+		switch o.Type {
+		case OrphanTypeEmptySession, OrphanTypeTrivialSession, OrphanTypeAgent:
+			if projectPath := projectPaths[filepath.Base(filepath.Dir(o.Path))]; projectPath != "" {
+				path = projectPath
+			}
+		}
+		if reason := protects(p, path); reason != "" {
+			kept = append(kept, ui.Change{
+				Path:        o.Path,
+				Description: reason,
+				Size:        o.SizeSaved,
+			})
+			continue
+		}
+		allowed = append(allowed, o)
+	}
+	return allowed, kept
+}
+
+// ProtectPaths splits paths into those that may be cleaned and the kept
+// preview entries of those that are protected.
+func ProtectPaths(paths []string, p Protector) ([]string, []ui.Change) {
+	var allowed []string
+	var kept []ui.Change
+	for _, path := range paths {
+		if reason := protects(p, path); reason != "" {
+			kept = append(kept, ui.Change{Path: path, Description: reason})
+			continue
+		}
+		allowed = append(allowed, path)
+	}
+	return allowed, kept
+}
+
+// ProtectStateEntries removes protected entries from state and returns their
+// kept preview entries.
+func ProtectStateEntries(state *claude.State, p Protector) []ui.Change {
+	var allowed []claude.StateProject
+	var kept []ui.Change
+	for _, entry := range state.Projects {
+		if reason := protects(p, entry.Path); reason != "" {
+			kept = append(kept, ui.Change{
+				Path:        entry.Path,
+				Description: reason,
+				Size:        entry.Size,
+			})
+			continue
+		}
+		allowed = append(allowed, entry)
+	}
+	state.Projects = allowed
+	return kept
+}
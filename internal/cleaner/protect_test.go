@@ -0,0 +1,89 @@
+package cleaner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prefixProtector protects every path below prefix.
+type prefixProtector string
+
+func (p prefixProtector) Protects(path string) string {
+	if strings.HasPrefix(path, string(p)) {
+		return "protected by test"
+	}
+	return ""
+}
+
+func TestProtectProjects(t *testing.T) {
+	projects := []claude.Project{
+		{EncodedName: "-keep-a", ActualPath: "/keep/a", TotalSize: 10},
+		{EncodedName: "-other", ActualPath: "/other"},
+	}
+
+	allowed, kept := ProtectProjects(projects, prefixProtector("/keep"))
+
+	require.Len(t, allowed, 1)
+	assert.Equal(t, "/other", allowed[0].ActualPath)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "/keep/a", kept[0].Path)
+	assert.Equal(t, "protected by test", kept[0].Description)
+	assert.Equal(t, int64(10), kept[0].Size)
+}
+
+func TestProtectProjects_NilProtector(t *testing.T) {
+	projects := []claude.Project{{ActualPath: "/keep/a"}}
+
+	allowed, kept := ProtectProjects(projects, nil)
+
+	assert.Equal(t, projects, allowed)
+	assert.Empty(t, kept)
+}
+
+func TestProtectOrphans_EmptySessionUsesProjectPath(t *testing.T) {
+	projects := []claude.Project{{EncodedName: "-keep-a", ActualPath: "/keep/a"}}
+	orphans := []OrphanResult{
+		{Type: OrphanTypeEmptySession, Path: filepath.Join("/c", "projects", "-keep-a", "s.jsonl")},
+		{Type: OrphanTypeEmptySession, Path: filepath.Join("/c", "projects", "-other", "s.jsonl")},
+		{Type: OrphanTypeTodo, Path: "/keep/todo.json"},
+		{Type: OrphanTypeAgent, Path: filepath.Join("/c", "projects", "-keep-a", "gone")},
+	}
+
+	allowed, kept := ProtectOrphans(orphans, projects, prefixProtector("/keep"))
+
+	require.Len(t, allowed, 1)
+	assert.Equal(t, orphans[1].Path, allowed[0].Path)
+	assert.Len(t, kept, 3)
+}
+
+func TestProtectPaths(t *testing.T) {
+	allowed, kept := ProtectPaths([]string{"/keep/a", "/b"}, prefixProtector("/keep"))
+
+	assert.Equal(t, []string{"/b"}, allowed)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "/keep/a", kept[0].Path)
+}
+
+func TestProtectStateEntries(t *testing.T) {
+	state := &claude.State{Projects: []claude.StateProject{{Path: "/keep/a"}, {Path: "/b"}}}
+
+	kept := ProtectStateEntries(state, prefixProtector("/keep"))
+
+	require.Len(t, state.Projects, 1)
+	assert.Equal(t, "/b", state.Projects[0].Path)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "/keep/a", kept[0].Path)
+}
+
+func TestProtectors(t *testing.T) {
+	ps := Protectors{nil, prefixProtector("/keep"), prefixProtector("/keep/a")}
+
+	assert.Equal(t, "protected by test", ps.Protects("/keep/a/b"))
+	assert.Empty(t, ps.Protects("/other"))
+	assert.Empty(t, Protectors(nil).Protects("/keep"))
+}
@@ -0,0 +1,39 @@
+package cleaner
+
+import "os"
+
+// Quarantine receives data that a cleaner removes or rewrites so the change
+// can be undone later. A nil Quarantine deletes permanently.
+type Quarantine interface {
+	// Remove moves path out of the way.
+	Remove(path string) error
+	// Preserve keeps a copy of path before it is rewritten in place.
+	Preserve(path string) error
+	// Created records that path has just been created, so that undoing the
+	// change removes it.
+	Created(path string) error
+}
+
+// removePath removes a file or directory through q, or permanently if q is nil.
+func removePath(q Quarantine, path string) error {
+	if q == nil {
+		return os.RemoveAll(path)
+	}
+	return q.Remove(path)
+}
+
+// preservePath keeps a copy of path through q before it is rewritten.
+func preservePath(q Quarantine, path string) error {
+	if q == nil {
+		return nil
+	}
+	return q.Preserve(path)
+}
+
+// createdPath records through q that path has been created.
+func createdPath(q Quarantine, path string) error {
+	if q == nil {
+		return nil
+	}
+	return q.Created(path)
+}
@@ -0,0 +1,75 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuarantine records the paths handed to it instead of keeping copies.
+type fakeQuarantine struct {
+	removed   []string
+	preserved []string
+	created   []string
+	createErr error
+}
+
+func (q *fakeQuarantine) Remove(path string) error {
+	q.removed = append(q.removed, path)
+	return os.RemoveAll(path)
+}
+
+func (q *fakeQuarantine) Preserve(path string) error {
+	q.preserved = append(q.preserved, path)
+	return nil
+}
+
+func (q *fakeQuarantine) Created(path string) error {
+	if q.createErr != nil {
+		return q.createErr
+	}
+	q.created = append(q.created, path)
+	return nil
+}
+
+func TestCleanOrphans_UsesQuarantine(t *testing.T) {
+	tmpDir := t.TempDir()
+	orphanFile := filepath.Join(tmpDir, "orphan.json")
+	require.NoError(t, os.WriteFile(orphanFile, []byte(`{}`), 0644))
+
+	q := &fakeQuarantine{}
+	_, err := CleanOrphans([]OrphanResult{{Type: OrphanTypeTodo, Path: orphanFile}}, q, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{orphanFile}, q.removed)
+}
+
+func TestApplyDedup_PreservesOriginalInQuarantine(t *testing.T) {
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "settings.local.json")
+	content := `{"permissions":{"allow":["Bash(git:*)","Bash(npm:*)"]}}`
+	require.NoError(t, os.WriteFile(settingsPath, []byte(content), 0644))
+
+	q := &fakeQuarantine{}
+	err := ApplyDedup(&DedupResult{LocalPath: settingsPath, DuplicateAllow: []string{"Bash(git:*)"}}, q, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{settingsPath}, q.preserved)
+	assert.Empty(t, q.removed)
+}
+
+func TestApplyDedup_DeleteUsesQuarantine(t *testing.T) {
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "settings.local.json")
+	require.NoError(t, os.WriteFile(settingsPath, []byte(`{"permissions":{}}`), 0644))
+
+	q := &fakeQuarantine{}
+	err := ApplyDedup(&DedupResult{LocalPath: settingsPath, SuggestDelete: true}, q, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{settingsPath}, q.removed)
+	assert.NoFileExists(t, settingsPath)
+}
@@ -0,0 +1,88 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// RepairResult represents a session file that ends in invalid data and can
+// be repaired by truncating it after its last valid line.
+type RepairResult struct {
+	Project claude.Project
+	Session claude.SessionInfo
+}
+
+// Truncated returns the number of bytes the repair removes.
+func (r RepairResult) Truncated() int64 {
+	return r.Session.Size - r.Session.ValidSize
+}
+
+// FindRepairableSessions returns the sessions whose file ends in invalid
+// data. Files without any valid line are left alone, since truncating them
+// would leave nothing. Invalid lines before the last valid one are skipped
+// by the parser and not repaired.
+func FindRepairableSessions(projects []claude.Project) []RepairResult {
+	var results []RepairResult
+	for _, p := range projects {
+		for _, s := range p.Sessions {
+			if s.IsTruncated() && s.ValidSize > 0 {
+				results = append(results, RepairResult{Project: p, Session: s})
+			}
+		}
+	}
+	return results
+}
+
+// RepairSession truncates a session file after its last valid line. The
+// original file is kept in q first so that the repair can be undone. The
+// file must not have changed size since it was scanned, which would mean
+// Claude Code is still writing it. If dryRun is true, returns without making
+// changes.
+func RepairSession(result RepairResult, q Quarantine, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+
+	cleanPath := filepath.Clean(result.Session.FilePath)
+	stat, err := os.Stat(cleanPath)
+	if err != nil {
+		return err
+	}
+	if stat.Size() != result.Session.Size {
+		return fmt.Errorf("%s changed since it was scanned", result.Session.FilePath)
+	}
+
+	if err := preservePath(q, cleanPath); err != nil {
+		return err
+	}
+
+	return os.Truncate(cleanPath, result.Session.ValidSize)
+}
+
+// BuildRepairPreview creates a preview of the session files to be repaired.
+func BuildRepairPreview(results []RepairResult) *ui.Preview {
+	preview := &ui.Preview{
+		Title: "Session Repair",
+	}
+
+	for _, r := range results {
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      ui.ActionModify,
+			Path:        r.Session.FilePath,
+			Description: r.FormatAuditDetails(),
+			Size:        r.Truncated(),
+		})
+	}
+
+	return preview
+}
+
+// FormatAuditDetails returns a description of the repair for the audit log.
+func (r RepairResult) FormatAuditDetails() string {
+	return fmt.Sprintf("truncate %s of invalid data after the last valid line (%d invalid lines, project %s)",
+		ui.FormatSize(r.Truncated()), r.Session.BadLines, r.Project.ActualPath)
+}
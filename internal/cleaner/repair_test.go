@@ -0,0 +1,92 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validLine = `{"sessionId":"s","cwd":"/p","timestamp":"2025-12-06T10:00:00Z"}` + "\n"
+
+// crashedSession writes a session file whose last line was cut off and
+// returns its parsed info.
+func crashedSession(t *testing.T) claude.SessionInfo {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(validLine+`{"type":"assis`), 0644))
+
+	info, err := claude.ParseSessionFile(path)
+	require.NoError(t, err)
+	return *info
+}
+
+func TestFindRepairableSessions(t *testing.T) {
+	crashed := crashedSession(t)
+	p := claude.Project{
+		EncodedName: "-p",
+		Sessions: []claude.SessionInfo{
+			crashed,
+			{ID: "ok", Size: 10, ValidSize: 10},
+			{ID: "middle", Size: 10, ValidSize: 10, BadLines: 1},
+			{ID: "garbage", Size: 10, BadLines: 2},
+		},
+	}
+
+	results := FindRepairableSessions([]claude.Project{p})
+
+	require.Len(t, results, 1)
+	assert.Equal(t, crashed.FilePath, results[0].Session.FilePath)
+	assert.Equal(t, int64(len(`{"type":"assis`)), results[0].Truncated())
+}
+
+func TestRepairSession(t *testing.T) {
+	crashed := crashedSession(t)
+
+	q := &fakeQuarantine{}
+	require.NoError(t, RepairSession(RepairResult{Session: crashed}, q, false))
+
+	data, err := os.ReadFile(crashed.FilePath)
+	require.NoError(t, err)
+	assert.Equal(t, validLine, string(data))
+	assert.Equal(t, []string{crashed.FilePath}, q.preserved, "the original is kept before truncating")
+}
+
+func TestRepairSession_DryRun(t *testing.T) {
+	crashed := crashedSession(t)
+
+	require.NoError(t, RepairSession(RepairResult{Session: crashed}, nil, true))
+
+	stat, err := os.Stat(crashed.FilePath)
+	require.NoError(t, err)
+	assert.Equal(t, crashed.Size, stat.Size())
+}
+
+func TestRepairSession_ChangedSinceScan(t *testing.T) {
+	crashed := crashedSession(t)
+
+	// Claude Code appended to the file after it was scanned
+	f, err := os.OpenFile(crashed.FilePath, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("tant\"}\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q := &fakeQuarantine{}
+	err = RepairSession(RepairResult{Session: crashed}, q, false)
+	assert.ErrorContains(t, err, "changed since it was scanned")
+	assert.Empty(t, q.preserved)
+}
+
+func TestBuildRepairPreview(t *testing.T) {
+	crashed := crashedSession(t)
+
+	preview := BuildRepairPreview([]RepairResult{{Project: claude.Project{ActualPath: "/p"}, Session: crashed}})
+
+	require.Len(t, preview.Changes, 1)
+	assert.Equal(t, ui.ActionModify, preview.Changes[0].Action)
+	assert.Contains(t, preview.Changes[0].Description, "1 invalid lines")
+}
@@ -0,0 +1,277 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// RetentionPolicy selects session transcripts to remove from projects that
+// still exist. Zero values disable the corresponding rule.
+type RetentionPolicy struct {
+	OlderThan      time.Duration // Remove sessions last active longer ago than this
+	KeepLast       int           // Never remove the newest N sessions of a project
+	MaxProjectSize int64         // Remove the oldest sessions until the project fits
+}
+
+// IsZero returns true if the policy would not select any session.
+func (p RetentionPolicy) IsZero() bool {
+	return p.OlderThan == 0 && p.MaxProjectSize == 0
+}
+
+// SessionResult represents a session selected by a retention policy,
+// together with the data linked to it.
+type SessionResult struct {
+	Project   claude.Project
+	Session   claude.SessionInfo
+	Reason    string   // Why the session was selected
+	Linked    []string // Todos, file-history, session-env and agent transcripts of the session
+	SizeSaved int64    // Size of the session file and its linked data
+}
+
+// FindExpiredSessions applies the retention policy to the sessions of each
+// existing project. Stale projects are left to FindStaleProjects. Sessions
+// without a timestamp are never selected, since their age is unknown.
+func FindExpiredSessions(paths *claude.Paths, projects []claude.Project, policy RetentionPolicy, now time.Time) []SessionResult {
+	var results []SessionResult
+	if policy.IsZero() {
+		return results
+	}
+
+	for _, p := range projects {
+		if !p.Exists() {
+			continue
+		}
+
+		// Newest first, so that the sessions protected by KeepLast come first
+		sessions := make([]claude.SessionInfo, 0, len(p.Sessions))
+		for _, s := range p.Sessions {
+			if !s.Timestamp.IsZero() {
+				sessions = append(sessions, s)
+			}
+		}
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return sessions[i].LastActive().After(sessions[j].LastActive())
+		})
+
+		if policy.KeepLast >= len(sessions) {
+			continue
+		}
+		candidates := sessions[max(policy.KeepLast, 0):]
+
+		reasons := make(map[string]string)
+		remaining := p.TotalSize
+
+		if policy.OlderThan > 0 {
+			for _, s := range candidates {
+				if now.Sub(s.LastActive()) > policy.OlderThan {
+					reasons[s.FilePath] = fmt.Sprintf("older than %s", formatAge(policy.OlderThan))
+					remaining -= s.Size
+				}
+			}
+		}
+
+		if policy.MaxProjectSize > 0 {
+			for i := len(candidates) - 1; i >= 0 && remaining > policy.MaxProjectSize; i-- {
+				s := candidates[i]
+				if _, selected := reasons[s.FilePath]; selected {
+					continue
+				}
+				reasons[s.FilePath] = fmt.Sprintf("project exceeds %s", ui.FormatSize(policy.MaxProjectSize))
+				remaining -= s.Size
+			}
+		}
+
+		// Report oldest first
+		for i := len(candidates) - 1; i >= 0; i-- {
+			s := candidates[i]
+			reason, selected := reasons[s.FilePath]
+			if !selected {
+				continue
+			}
+
+			result := SessionResult{
+				Project:   p,
+				Session:   s,
+				Reason:    reason,
+				SizeSaved: s.Size,
+			}
+			for _, linked := range append(findLinkedData(paths, s.ID), p.AgentData(s.ID)...) {
+				size, err := dirSize(linked)
+				if err != nil {
+					continue
+				}
+				result.Linked = append(result.Linked, linked)
+				result.SizeSaved += size
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// findLinkedData returns the existing todos, file-history and session-env
+// entries that belong to a session.
+func findLinkedData(paths *claude.Paths, sessionID string) []string {
+	if sessionID == "" {
+		return nil
+	}
+
+	var linked []string
+
+	if entries, err := os.ReadDir(paths.Todos); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && extractSessionIDFromTodoFilename(entry.Name()) == sessionID {
+				linked = append(linked, filepath.Join(paths.Todos, entry.Name()))
+			}
+		}
+	}
+
+	for _, dir := range []string{paths.FileHistory, paths.SessionEnv} {
+		path := filepath.Join(dir, sessionID)
+		if _, err := os.Stat(path); err == nil {
+			linked = append(linked, path)
+		}
+	}
+
+	return linked
+}
+
+// LinkedData maps session IDs to their todos, file-history and session-env,
+// reading each directory once.
+func LinkedData(paths *claude.Paths) map[string][]string {
+	linked := make(map[string][]string)
+
+	if entries, err := os.ReadDir(paths.Todos); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if id := extractSessionIDFromTodoFilename(entry.Name()); id != "" {
+				linked[id] = append(linked[id], filepath.Join(paths.Todos, entry.Name()))
+			}
+		}
+	}
+
+	for _, dir := range []string{paths.FileHistory, paths.SessionEnv} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			for _, entry := range entries {
+				linked[entry.Name()] = append(linked[entry.Name()], filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	return linked
+}
+
+// LinkedSize returns the total size of linked data, skipping paths that
+// cannot be read.
+func LinkedSize(linked []string) int64 {
+	var total int64
+	for _, path := range linked {
+		if size, err := dirSize(path); err == nil {
+			total += size
+		}
+	}
+	return total
+}
+
+// CleanSession removes a session file and its linked data, moving them into
+// q if one is given. If dryRun is true, returns without making changes.
+func CleanSession(result SessionResult, q Quarantine, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+
+	for _, path := range append([]string{result.Session.FilePath}, result.Linked...) {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := removePath(q, path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// BuildSessionPreview creates a preview of the sessions to be removed. Each
+// affected project is listed as kept with the number of sessions it retains.
+func BuildSessionPreview(results []SessionResult) *ui.Preview {
+	preview := &ui.Preview{
+		Title: "Session Retention Cleanup",
+	}
+
+	removed := make(map[string]int)
+	var order []claude.Project
+	for _, r := range results {
+		if removed[r.Project.EncodedName] == 0 {
+			order = append(order, r.Project)
+		}
+		removed[r.Project.EncodedName]++
+
+		description := fmt.Sprintf("%s, last active %s, %s", r.Project.ActualPath, r.Session.LastActive().Format("2006-01-02"), r.Reason)
+		if len(r.Linked) > 0 {
+			description += "; with " + formatLinked(r.Linked)
+		}
+
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      ui.ActionDelete,
+			Path:        r.Session.FilePath,
+			Description: description,
+			Size:        r.SizeSaved,
+		})
+	}
+
+	for _, p := range order {
+		preview.Kept = append(preview.Kept, ui.Change{
+			Path:        p.ActualPath,
+			Description: fmt.Sprintf("%d of %d sessions kept", len(p.Sessions)-removed[p.EncodedName], len(p.Sessions)),
+		})
+	}
+
+	return preview
+}
+
+// formatLinked describes linked data by its directory, e.g. "2 todos, file-history".
+func formatLinked(linked []string) string {
+	todos := 0
+	agents := false
+	var parts []string
+	for _, path := range linked {
+		switch dir := filepath.Base(filepath.Dir(path)); {
+		case dir == "todos":
+			todos++
+		case filepath.Base(filepath.Dir(filepath.Dir(path))) == "projects":
+			// Agent transcripts and session directories in a project
+			if !agents {
+				parts = append(parts, "agents")
+				agents = true
+			}
+		default:
+			parts = append(parts, dir)
+		}
+	}
+	if todos == 1 {
+		parts = append([]string{"1 todo"}, parts...)
+	} else if todos > 1 {
+		parts = append([]string{fmt.Sprintf("%d todos", todos)}, parts...)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatAge formats a retention age in days where possible, e.g. "90d".
+func formatAge(d time.Duration) string {
+	day := 24 * time.Hour
+	if d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
@@ -0,0 +1,204 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var retentionNow = time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+// retentionProject builds an existing project with one 100-byte session per
+// age in days.
+func retentionProject(t *testing.T, ages ...int) claude.Project {
+	p := claude.Project{EncodedName: "-p", ActualPath: t.TempDir()}
+	for i, days := range ages {
+		p.Sessions = append(p.Sessions, claude.SessionInfo{
+			ID:        string(rune('a' + i)),
+			FilePath:  filepath.Join("/sessions", string(rune('a'+i))+".jsonl"),
+			Timestamp: retentionNow.AddDate(0, 0, -days),
+			Size:      100,
+		})
+		p.TotalSize += 100
+	}
+	return p
+}
+
+func selectedIDs(results []SessionResult) []string {
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.Session.ID)
+	}
+	return ids
+}
+
+func TestFindExpiredSessions_OlderThan(t *testing.T) {
+	paths := &claude.Paths{}
+	p := retentionProject(t, 10, 100, 200, 50)
+
+	results := FindExpiredSessions(paths, []claude.Project{p}, RetentionPolicy{OlderThan: 90 * 24 * time.Hour}, retentionNow)
+
+	// Oldest first
+	assert.Equal(t, []string{"c", "b"}, selectedIDs(results))
+	assert.Equal(t, "older than 90d", results[0].Reason)
+}
+
+func TestFindExpiredSessions_OlderThanUsesLastActivity(t *testing.T) {
+	paths := &claude.Paths{}
+	p := retentionProject(t, 200, 200)
+	// Started 200 days ago, but resumed last week
+	p.Sessions[0].LastTimestamp = retentionNow.AddDate(0, 0, -7)
+
+	results := FindExpiredSessions(paths, []claude.Project{p}, RetentionPolicy{OlderThan: 90 * 24 * time.Hour}, retentionNow)
+
+	assert.Equal(t, []string{"b"}, selectedIDs(results))
+}
+
+func TestFindExpiredSessions_KeepLast(t *testing.T) {
+	paths := &claude.Paths{}
+	p := retentionProject(t, 300, 200, 100)
+
+	results := FindExpiredSessions(paths, []claude.Project{p}, RetentionPolicy{OlderThan: 24 * time.Hour, KeepLast: 2}, retentionNow)
+
+	assert.Equal(t, []string{"a"}, selectedIDs(results))
+
+	results = FindExpiredSessions(paths, []claude.Project{p}, RetentionPolicy{OlderThan: 24 * time.Hour, KeepLast: 3}, retentionNow)
+	assert.Empty(t, results)
+}
+
+func TestFindExpiredSessions_MaxProjectSize(t *testing.T) {
+	paths := &claude.Paths{}
+	p := retentionProject(t, 1, 2, 3, 4, 5)
+
+	results := FindExpiredSessions(paths, []claude.Project{p}, RetentionPolicy{MaxProjectSize: 250}, retentionNow)
+
+	// 500 bytes, so the three oldest sessions have to go
+	assert.Equal(t, []string{"e", "d", "c"}, selectedIDs(results))
+	assert.Equal(t, "project exceeds 250 B", results[0].Reason)
+}
+
+func TestFindExpiredSessions_CombinedRules(t *testing.T) {
+	paths := &claude.Paths{}
+	p := retentionProject(t, 1, 2, 3, 200)
+
+	policy := RetentionPolicy{OlderThan: 90 * 24 * time.Hour, MaxProjectSize: 200}
+	results := FindExpiredSessions(paths, []claude.Project{p}, policy, retentionNow)
+
+	// The age rule already frees 100 bytes; one more session has to go for size
+	assert.Equal(t, []string{"d", "c"}, selectedIDs(results))
+	assert.Equal(t, "older than 90d", results[0].Reason)
+	assert.Equal(t, "project exceeds 200 B", results[1].Reason)
+}
+
+func TestFindExpiredSessions_SkipsStaleProjectsAndUnknownAge(t *testing.T) {
+	paths := &claude.Paths{}
+	stale := retentionProject(t, 400)
+	stale.ActualPath = filepath.Join(t.TempDir(), "gone")
+
+	unknown := retentionProject(t, 400)
+	unknown.Sessions[0].Timestamp = time.Time{}
+
+	results := FindExpiredSessions(paths, []claude.Project{stale, unknown}, RetentionPolicy{OlderThan: time.Hour}, retentionNow)
+	assert.Empty(t, results)
+}
+
+func TestFindExpiredSessions_ZeroPolicy(t *testing.T) {
+	p := retentionProject(t, 400)
+	assert.Empty(t, FindExpiredSessions(&claude.Paths{}, []claude.Project{p}, RetentionPolicy{KeepLast: 1}, retentionNow))
+}
+
+func TestFindExpiredSessions_LinkedData(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths, err := claude.DiscoverPaths(tmpDir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(paths.Todos, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Todos, "a-agent-a.json"), []byte("[]"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Todos, "a-agent-x.json"), []byte("[1]"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Todos, "b-agent-b.json"), []byte("[]"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.FileHistory, "a"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.FileHistory, "a", "f@v1"), []byte("12345"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.SessionEnv, "a"), 0755))
+
+	p := retentionProject(t, 400)
+	results := FindExpiredSessions(paths, []claude.Project{p}, RetentionPolicy{OlderThan: time.Hour}, retentionNow)
+
+	require.Len(t, results, 1)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(paths.Todos, "a-agent-a.json"),
+		filepath.Join(paths.Todos, "a-agent-x.json"),
+		filepath.Join(paths.FileHistory, "a"),
+		filepath.Join(paths.SessionEnv, "a"),
+	}, results[0].Linked)
+	assert.Equal(t, int64(100+2+3+5), results[0].SizeSaved)
+}
+
+func TestLinkedData(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths, err := claude.DiscoverPaths(tmpDir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(paths.Todos, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Todos, "a-agent-a.json"), []byte("[]"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.Todos, "not-a-todo.txt"), nil, 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.FileHistory, "a"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.FileHistory, "a", "f@v1"), []byte("12345"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.SessionEnv, "b"), 0755))
+
+	linked := LinkedData(paths)
+
+	assert.Equal(t, map[string][]string{
+		"a": {filepath.Join(paths.Todos, "a-agent-a.json"), filepath.Join(paths.FileHistory, "a")},
+		"b": {filepath.Join(paths.SessionEnv, "b")},
+	}, linked)
+	assert.Equal(t, int64(2+5), LinkedSize(linked["a"]))
+}
+
+func TestCleanSession(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionPath := filepath.Join(tmpDir, "a.jsonl")
+	todoPath := filepath.Join(tmpDir, "todos", "a-agent-a.json")
+	require.NoError(t, os.WriteFile(sessionPath, []byte("{}"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Dir(todoPath), 0755))
+	require.NoError(t, os.WriteFile(todoPath, []byte("[]"), 0644))
+
+	result := SessionResult{
+		Session: claude.SessionInfo{ID: "a", FilePath: sessionPath},
+		Linked:  []string{todoPath, filepath.Join(tmpDir, "already-gone")},
+	}
+
+	require.NoError(t, CleanSession(result, nil, true))
+	assert.FileExists(t, sessionPath)
+
+	q := &fakeQuarantine{}
+	require.NoError(t, CleanSession(result, q, false))
+	assert.NoFileExists(t, sessionPath)
+	assert.NoFileExists(t, todoPath)
+	assert.Equal(t, []string{sessionPath, todoPath}, q.removed)
+}
+
+func TestBuildSessionPreview(t *testing.T) {
+	p := retentionProject(t, 400, 1)
+	results := []SessionResult{{
+		Project:   p,
+		Session:   p.Sessions[0],
+		Reason:    "older than 90d",
+		Linked:    []string{"/c/todos/a-agent-a.json", "/c/todos/a-agent-b.json", "/c/file-history/a"},
+		SizeSaved: 150,
+	}}
+
+	preview := BuildSessionPreview(results)
+
+	require.Len(t, preview.Changes, 1)
+	assert.Equal(t, ui.ActionDelete, preview.Changes[0].Action)
+	assert.Contains(t, preview.Changes[0].Description, "older than 90d; with 2 todos, file-history")
+	require.Len(t, preview.Kept, 1)
+	assert.Equal(t, "1 of 2 sessions kept", preview.Kept[0].Description)
+	assert.Equal(t, int64(150), preview.TotalSize())
+}
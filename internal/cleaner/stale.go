@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
 	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
 )
 
@@ -16,20 +17,89 @@ type StaleResult struct {
 	FilesRemoved int
 }
 
-// FindStaleProjects returns projects whose ActualPath no longer exists on disk.
+// Unavailability explains why a missing path is not treated as deleted.
+type Unavailability struct {
+	Status mounts.Status
+	Reason string // Explanation, e.g. "/mnt/usb is not mounted"
+}
+
+// Description returns the reason for keeping the path, as shown in previews.
+func (u Unavailability) Description() string {
+	return fmt.Sprintf("%s (%s)", u.Status, u.Reason)
+}
+
+// UnavailableProject is a project whose ActualPath is missing but which is
+// not stale, because its volume is not mounted or its path is not accessible,
+// or whose path is unknown.
+type UnavailableProject struct {
+	Project claude.Project
+	Unavailability
+
+	// UnknownPath is set instead of a mount status if the project is kept
+	// because its session files are corrupt and no valid line has a cwd, so
+	// it cannot be told whether it is stale.
+	UnknownPath bool
+}
+
+// Description returns the reason for keeping the project, as shown in previews.
+func (u UnavailableProject) Description() string {
+	if u.UnknownPath {
+		return "corrupt sessions (path unknown, no valid line has a cwd)"
+	}
+	return u.Unavailability.Description()
+}
+
+// FindStaleProjects returns projects whose ActualPath has been deleted,
+// checked against the mount table of the running system.
 func FindStaleProjects(projects []claude.Project) []claude.Project {
+	stale, _ := ClassifyProjects(projects, mounts.System())
+	return stale
+}
+
+// ClassifyProjects splits the projects whose ActualPath is missing into the
+// stale ones, whose path has been deleted, and the unavailable ones, whose
+// volume is not mounted, whose path cannot be accessed, or whose path is
+// unknown because their sessions are corrupt. Only stale projects may be
+// cleaned.
+func ClassifyProjects(projects []claude.Project, table *mounts.Table) ([]claude.Project, []UnavailableProject) {
 	var stale []claude.Project
+	var unavailable []UnavailableProject
 	for _, p := range projects {
-		if !p.Exists() {
+		if p.ActualPath == "" && len(p.CorruptSessions()) > 0 {
+			unavailable = append(unavailable, UnavailableProject{Project: p, UnknownPath: true})
+			continue
+		}
+		switch status, reason := table.Check(p.ActualPath); status {
+		case mounts.StatusPresent:
+		case mounts.StatusDeleted:
 			stale = append(stale, p)
+		default:
+			unavailable = append(unavailable, UnavailableProject{
+				Project:        p,
+				Unavailability: Unavailability{Status: status, Reason: reason},
+			})
 		}
 	}
-	return stale
+	return stale, unavailable
+}
+
+// KeepUnavailable returns the kept preview entries of unavailable projects.
+func KeepUnavailable(unavailable []UnavailableProject) []ui.Change {
+	var kept []ui.Change
+	for _, u := range unavailable {
+		kept = append(kept, ui.Change{
+			Path:        u.Project.ActualPath,
+			Description: u.Description(),
+			Size:        u.Project.TotalSize,
+		})
+	}
+	return kept
 }
 
-// CleanStaleProject removes the session data directory for a stale project.
+// CleanStaleProject removes the session data directory for a stale project,
+// moving it into q if one is given.
 // If dryRun is true, it returns what would be deleted without making changes.
-func CleanStaleProject(projectsDir string, project claude.Project, dryRun bool) (*StaleResult, error) {
+func CleanStaleProject(projectsDir string, project claude.Project, q Quarantine, dryRun bool) (*StaleResult, error) {
 	result := &StaleResult{
 		Project:      project,
 		SizeSaved:    project.TotalSize,
@@ -50,7 +120,7 @@ func CleanStaleProject(projectsDir string, project claude.Project, dryRun bool)
 	}
 
 	// Actually delete the directory
-	if err := os.RemoveAll(projectPath); err != nil {
+	if err := removePath(q, projectPath); err != nil {
 		return nil, fmt.Errorf("failed to remove project directory %s: %w", projectPath, err)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +63,53 @@ func TestFindStaleProjects_EmptyActualPath(t *testing.T) {
 	assert.Len(t, stale, 1)
 }
 
+func TestClassifyProjects_UnmountedVolume(t *testing.T) {
+	tmpDir := t.TempDir()
+	mnt := filepath.Join(tmpDir, "mnt")
+	existingPath := filepath.Join(tmpDir, "existing")
+	require.NoError(t, os.MkdirAll(existingPath, 0755))
+	require.NoError(t, os.MkdirAll(mnt, 0755))
+
+	table := &mounts.Table{Bases: []string{mnt}}
+	projects := []claude.Project{
+		{EncodedName: "existing", ActualPath: existingPath},
+		{EncodedName: "deleted", ActualPath: filepath.Join(tmpDir, "deleted")},
+		{EncodedName: "usb", ActualPath: filepath.Join(mnt, "usb", "project"), TotalSize: 42},
+	}
+
+	stale, unavailable := ClassifyProjects(projects, table)
+
+	require.Len(t, stale, 1)
+	assert.Equal(t, "deleted", stale[0].EncodedName)
+	require.Len(t, unavailable, 1)
+	assert.Equal(t, "usb", unavailable[0].Project.EncodedName)
+	assert.Equal(t, mounts.StatusMountAbsent, unavailable[0].Status)
+
+	kept := KeepUnavailable(unavailable)
+	require.Len(t, kept, 1)
+	assert.Equal(t, filepath.Join(mnt, "usb", "project"), kept[0].Path)
+	assert.Equal(t, "parent mount absent ("+filepath.Join(mnt, "usb")+" is not mounted)", kept[0].Description)
+	assert.Equal(t, int64(42), kept[0].Size)
+}
+
+func TestClassifyProjects_CorruptSessionsWithoutPath(t *testing.T) {
+	projects := []claude.Project{
+		{EncodedName: "empty"},
+		{EncodedName: "corrupt", Sessions: []claude.SessionInfo{{ID: "s", BadLines: 3}}},
+	}
+
+	stale, unavailable := ClassifyProjects(projects, &mounts.Table{})
+
+	// Without a single valid line the path is unknown, not deleted
+	require.Len(t, stale, 1)
+	assert.Equal(t, "empty", stale[0].EncodedName)
+	require.Len(t, unavailable, 1)
+	assert.Equal(t, "corrupt", unavailable[0].Project.EncodedName)
+	assert.True(t, unavailable[0].UnknownPath)
+	assert.Empty(t, unavailable[0].Status)
+	assert.Equal(t, "corrupt sessions (path unknown, no valid line has a cwd)", KeepUnavailable(unavailable)[0].Description)
+}
+
 func TestCleanStaleProject_DryRun(t *testing.T) {
 	tmpDir := t.TempDir()
 	projectsDir := filepath.Join(tmpDir, "projects")
@@ -79,7 +127,7 @@ func TestCleanStaleProject_DryRun(t *testing.T) {
 		FileCount:   1,
 	}
 
-	result, err := CleanStaleProject(projectsDir, project, true)
+	result, err := CleanStaleProject(projectsDir, project, nil, true)
 	require.NoError(t, err)
 
 	// Dry run should not delete
@@ -108,7 +156,7 @@ func TestCleanStaleProject_ActualDelete(t *testing.T) {
 		FileCount:   2,
 	}
 
-	result, err := CleanStaleProject(projectsDir, project, false)
+	result, err := CleanStaleProject(projectsDir, project, nil, false)
 	require.NoError(t, err)
 
 	// Should have deleted the directory
@@ -130,7 +178,7 @@ func TestCleanStaleProject_NonexistentProject(t *testing.T) {
 	}
 
 	// Should not error if project directory doesn't exist
-	result, err := CleanStaleProject(projectsDir, project, false)
+	result, err := CleanStaleProject(projectsDir, project, nil, false)
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), result.SizeSaved)
 }
@@ -0,0 +1,161 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// StateResult describes the changes to make to the global state file.
+type StateResult struct {
+	Path        string                // The state file, ~/.claude.json
+	Stale       []claude.StateProject // Entries whose project path no longer exists
+	Trim        []claude.StateProject // Entries whose history exceeds KeepHistory
+	Kept        []claude.StateProject // Entries left unchanged
+	KeepHistory int                   // History entries to keep per project; negative keeps all
+
+	// Unavailable maps the paths of kept entries whose project is missing
+	// but not deleted, e.g. on an unmounted volume, to the reason.
+	Unavailable map[string]Unavailability
+}
+
+// IsEmpty returns true if there is nothing to change.
+func (r *StateResult) IsEmpty() bool {
+	return len(r.Stale) == 0 && len(r.Trim) == 0
+}
+
+// FindStaleStateEntries selects the project entries of the state file whose
+// path has been deleted, and the entries of existing projects whose history
+// is longer than keepHistory. A negative keepHistory disables trimming.
+// Entries of projects on volumes that are not mounted are kept, like
+// unavailable projects in ClassifyProjects.
+func FindStaleStateEntries(state *claude.State, keepHistory int) *StateResult {
+	result := &StateResult{
+		Path:        state.Path,
+		KeepHistory: keepHistory,
+	}
+
+	table := mounts.System()
+	for _, p := range state.Projects {
+		status, reason := table.Check(p.Path)
+		switch {
+		case status == mounts.StatusDeleted:
+			result.Stale = append(result.Stale, p)
+		case status != mounts.StatusPresent:
+			if result.Unavailable == nil {
+				result.Unavailable = make(map[string]Unavailability)
+			}
+			result.Unavailable[p.Path] = Unavailability{Status: status, Reason: reason}
+			result.Kept = append(result.Kept, p)
+		case keepHistory >= 0 && p.History > keepHistory:
+			result.Trim = append(result.Trim, p)
+		default:
+			result.Kept = append(result.Kept, p)
+		}
+	}
+
+	return result
+}
+
+// ApplyStateClean removes the stale entries and trims the history of the
+// selected entries, which is stored newest first, to its first KeepHistory
+// elements in a single atomic rewrite of the state file. Everything
+// else in the file stays byte-identical. Claude Code rewrites the file while
+// it runs; if that happens concurrently, the edit is re-applied to the new
+// content. If q is given, the original file is kept in it so the change can
+// be undone. If dryRun is true, returns without making changes.
+func ApplyStateClean(result *StateResult, q Quarantine, dryRun bool) error {
+	if dryRun || result.IsEmpty() {
+		return nil
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(result.Path); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := preservePath(q, result.Path); err != nil {
+		return err
+	}
+
+	stale := make([]string, 0, len(result.Stale))
+	for _, p := range result.Stale {
+		stale = append(stale, p.Path)
+	}
+
+	_, err := claude.EditFile(result.Path, func(doc *claude.Document) error {
+		if _, err := doc.RemoveKeys([]string{"projects"}, stale); err != nil {
+			return err
+		}
+		for _, p := range result.Trim {
+			if _, err := doc.TruncateArray([]string{"projects", p.Path, "history"}, result.KeepHistory); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// BuildStatePreview creates a preview of the changes to the state file.
+func BuildStatePreview(result *StateResult) *ui.Preview {
+	preview := &ui.Preview{
+		Title: "State File Cleanup (" + result.Path + ")",
+	}
+
+	for _, p := range result.Stale {
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      ui.ActionDelete,
+			Path:        p.Path,
+			Description: "project entry: " + formatStateEntry(p),
+			Size:        p.Size,
+		})
+	}
+
+	for _, p := range result.Trim {
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      ui.ActionModify,
+			Path:        p.Path,
+			Description: fmt.Sprintf("trim history from %d to %d entries", p.History, result.KeepHistory),
+		})
+	}
+
+	for _, p := range result.Kept {
+		description := formatStateEntry(p)
+		if u, ok := result.Unavailable[p.Path]; ok {
+			description = u.Description()
+		}
+		preview.Kept = append(preview.Kept, ui.Change{
+			Path:        p.Path,
+			Description: description,
+			Size:        p.Size,
+		})
+	}
+
+	return preview
+}
+
+// FormatAuditDetails returns one description per change for the audit log.
+func (r *StateResult) FormatAuditDetails() []string {
+	var parts []string
+	for _, p := range r.Stale {
+		parts = append(parts, fmt.Sprintf("removed projects[%q] (%s)", p.Path, formatStateEntry(p)))
+	}
+	for _, p := range r.Trim {
+		parts = append(parts, fmt.Sprintf("trimmed history of projects[%q] from %d to %d entries", p.Path, p.History, r.KeepHistory))
+	}
+	return parts
+}
+
+// formatStateEntry summarizes what a state entry holds.
+func formatStateEntry(p claude.StateProject) string {
+	description := fmt.Sprintf("%d history entries, %d allowed tools", p.History, p.AllowedTools)
+	if len(p.MCPServers) > 0 {
+		description += fmt.Sprintf(", MCP servers: %s", strings.Join(p.MCPServers, ", "))
+	}
+	return description
+}
@@ -0,0 +1,183 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/mounts"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeStateFile writes a state file with one existing project, holding three
+// history entries, and one missing project.
+func writeStateFile(t *testing.T, tmpDir string) (statePath, existing string) {
+	existing = filepath.ToSlash(filepath.Join(tmpDir, "existing"))
+	require.NoError(t, os.MkdirAll(existing, 0755))
+
+	content := `{
+  "numStartups": 42,
+  "projects": {
+    "/gone/project": {
+      "allowedTools": [],
+      "history": [{"display": "old"}],
+      "mcpServers": {"db": {"command": "db-mcp"}}
+    },
+    "` + existing + `": {
+      "allowedTools": ["Bash(ls:*)"],
+      "history": [
+        {"display": "newest", "pastedContents": {}},
+        {"display": "middle", "pastedContents": {}},
+        {"display": "oldest", "pastedContents": {}}
+      ],
+      "hasTrustDialogAccepted": true
+    }
+  },
+  "userID": "abc"
+}
+`
+	statePath = filepath.Join(tmpDir, ".claude.json")
+	require.NoError(t, os.WriteFile(statePath, []byte(content), 0600))
+	return statePath, existing
+}
+
+func TestFindStaleStateEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath, existing := writeStateFile(t, tmpDir)
+
+	state, err := claude.LoadState(statePath)
+	require.NoError(t, err)
+
+	result := FindStaleStateEntries(state, -1)
+	require.Len(t, result.Stale, 1)
+	assert.Equal(t, "/gone/project", result.Stale[0].Path)
+	assert.Empty(t, result.Trim)
+	require.Len(t, result.Kept, 1)
+	assert.Equal(t, existing, result.Kept[0].Path)
+
+	result = FindStaleStateEntries(state, 1)
+	require.Len(t, result.Trim, 1)
+	assert.Equal(t, existing, result.Trim[0].Path)
+	assert.Empty(t, result.Kept)
+}
+
+func TestApplyStateClean(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath, existing := writeStateFile(t, tmpDir)
+
+	state, err := claude.LoadState(statePath)
+	require.NoError(t, err)
+	result := FindStaleStateEntries(state, 1)
+
+	require.NoError(t, ApplyStateClean(result, nil, false))
+
+	data, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	expected := `{
+  "numStartups": 42,
+  "projects": {
+    "` + existing + `": {
+      "allowedTools": ["Bash(ls:*)"],
+      "history": [
+        {"display": "newest", "pastedContents": {}}
+      ],
+      "hasTrustDialogAccepted": true
+    }
+  },
+  "userID": "abc"
+}
+`
+	assert.Equal(t, expected, string(data))
+}
+
+func TestApplyStateClean_KeepsNewestHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	project := filepath.ToSlash(tmpDir)
+
+	// Claude Code adds prompts to the front of the history
+	content := `{"projects":{"` + project + `":{"history":[
+    {"display": "third", "timestamp": "2025-03-03T00:00:00Z"},
+    {"display": "second", "timestamp": "2025-02-02T00:00:00Z"},
+    {"display": "first", "timestamp": "2025-01-01T00:00:00Z"}
+  ]}}}`
+	statePath := filepath.Join(tmpDir, ".claude.json")
+	require.NoError(t, os.WriteFile(statePath, []byte(content), 0600))
+
+	state, err := claude.LoadState(statePath)
+	require.NoError(t, err)
+	require.NoError(t, ApplyStateClean(FindStaleStateEntries(state, 2), nil, false))
+
+	data, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	var kept struct {
+		Projects map[string]struct {
+			History []struct {
+				Timestamp string `json:"timestamp"`
+			} `json:"history"`
+		} `json:"projects"`
+	}
+	require.NoError(t, json.Unmarshal(data, &kept))
+	history := kept.Projects[project].History
+	require.Len(t, history, 2)
+	assert.Equal(t, "2025-03-03T00:00:00Z", history[0].Timestamp)
+	assert.Equal(t, "2025-02-02T00:00:00Z", history[1].Timestamp)
+}
+
+func TestApplyStateClean_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath, _ := writeStateFile(t, tmpDir)
+	before, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+
+	state, err := claude.LoadState(statePath)
+	require.NoError(t, err)
+	require.NoError(t, ApplyStateClean(FindStaleStateEntries(state, 0), nil, true))
+
+	after, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestApplyStateClean_Quarantine(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath, _ := writeStateFile(t, tmpDir)
+
+	state, err := claude.LoadState(statePath)
+	require.NoError(t, err)
+
+	q := &fakeQuarantine{}
+	require.NoError(t, ApplyStateClean(FindStaleStateEntries(state, -1), q, false))
+	assert.Equal(t, []string{statePath}, q.preserved)
+}
+
+func TestBuildStatePreview(t *testing.T) {
+	result := &StateResult{
+		Path:        "/home/u/.claude.json",
+		Stale:       []claude.StateProject{{Path: "/gone", History: 3, MCPServers: []string{"db"}, Size: 100}},
+		Trim:        []claude.StateProject{{Path: "/here", History: 250}},
+		Kept:        []claude.StateProject{{Path: "/kept", History: 2}, {Path: "/mnt/usb/p"}},
+		KeepHistory: 50,
+		Unavailable: map[string]Unavailability{"/mnt/usb/p": {Status: mounts.StatusMountAbsent, Reason: "/mnt/usb is not mounted"}},
+	}
+
+	preview := BuildStatePreview(result)
+
+	require.Len(t, preview.Changes, 2)
+	assert.Equal(t, ui.ActionDelete, preview.Changes[0].Action)
+	assert.Contains(t, preview.Changes[0].Description, "MCP servers: db")
+	assert.Equal(t, ui.ActionModify, preview.Changes[1].Action)
+	assert.Equal(t, "trim history from 250 to 50 entries", preview.Changes[1].Description)
+	require.Len(t, preview.Kept, 2)
+	assert.Equal(t, "parent mount absent (/mnt/usb is not mounted)", preview.Kept[1].Description)
+	assert.Equal(t, int64(100), preview.TotalSize())
+
+	details := result.FormatAuditDetails()
+	assert.Equal(t, []string{
+		`removed projects["/gone"] (3 history entries, 0 allowed tools, MCP servers: db)`,
+		`trimmed history of projects["/here"] from 250 to 50 entries`,
+	}, details)
+}
@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+)
+
+// trivialGracePeriod is how long after its last activity a session is left
+// alone, since it may still be running.
+const trivialGracePeriod = 24 * time.Hour
+
+// TrivialRules classify sessions that hold nothing worth keeping, such as
+// ones with only a summary line or a single prompt that got no response. A
+// session is trivial if it meets every rule.
+type TrivialRules struct {
+	MaxMessages int           // At most this many user and assistant messages
+	NoToolUse   bool          // No tool calls
+	ShorterThan time.Duration // Lasted less than this; zero for any duration
+}
+
+// Classify reports whether s is trivial, and why.
+func (r TrivialRules) Classify(s *claude.SessionInfo) (string, bool) {
+	if s.Messages() > r.MaxMessages {
+		return "", false
+	}
+	if r.NoToolUse && s.ToolUses > 0 {
+		return "", false
+	}
+	duration := s.LastActive().Sub(s.Timestamp)
+	if r.ShorterThan > 0 && duration >= r.ShorterThan {
+		return "", false
+	}
+
+	var reasons []string
+	switch {
+	case s.Messages() == 0 && s.Summary != "":
+		reasons = append(reasons, "only a summary")
+	case s.Messages() == 0:
+		reasons = append(reasons, "no messages")
+	case s.AssistantMessages == 0:
+		reasons = append(reasons, plural(s.UserMessages, "prompt")+" without response")
+	default:
+		reasons = append(reasons, plural(s.Messages(), "message"))
+	}
+	if r.NoToolUse {
+		reasons = append(reasons, "no tool use")
+	}
+	if r.ShorterThan > 0 {
+		reasons = append(reasons, "lasted "+duration.Round(time.Second).String())
+	}
+	return strings.Join(reasons, ", "), true
+}
+
+// findTrivialSessions finds the trivial sessions of all projects, with their
+// todos, file-history, session-env and agent transcripts as linked data.
+// Sessions active within the grace period before now, and corrupt ones,
+// which "repair sessions" handles, are left alone.
+func findTrivialSessions(paths *claude.Paths, inv *claude.Inventory, r TrivialRules, now time.Time) []OrphanResult {
+	var orphans []OrphanResult
+
+	for i := range inv.Projects {
+		p := &inv.Projects[i]
+		for j := range p.Sessions {
+			s := &p.Sessions[j]
+			if s.IsCorrupt() || s.ID == "" || now.Sub(s.LastActive()) < trivialGracePeriod {
+				continue
+			}
+			reason, trivial := r.Classify(s)
+			if !trivial {
+				continue
+			}
+
+			o := OrphanResult{
+				Type:      OrphanTypeTrivialSession,
+				Path:      s.FilePath,
+				SizeSaved: s.Size,
+				SessionID: s.ID,
+				Reason:    reason,
+			}
+			for _, linked := range append(findLinkedData(paths, s.ID), p.AgentData(s.ID)...) {
+				size, err := dirSize(linked)
+				if err != nil {
+					continue
+				}
+				o.Linked = append(o.Linked, linked)
+				o.SizeSaved += size
+			}
+			orphans = append(orphans, o)
+		}
+	}
+
+	return orphans
+}
+
+// plural formats a count of things, e.g. "1 prompt" or "2 prompts".
+func plural(n int, thing string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, thing)
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
@@ -0,0 +1,123 @@
+package cleaner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrivialRules_Classify(t *testing.T) {
+	start := time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		rules   TrivialRules
+		session claude.SessionInfo
+		reason  string
+		trivial bool
+	}{
+		{
+			name:    "aborted prompt",
+			rules:   TrivialRules{MaxMessages: 1},
+			session: claude.SessionInfo{UserMessages: 1, Timestamp: start},
+			reason:  "1 prompt without response",
+			trivial: true,
+		},
+		{
+			name:    "too many messages",
+			rules:   TrivialRules{MaxMessages: 1},
+			session: claude.SessionInfo{UserMessages: 1, AssistantMessages: 1},
+		},
+		{
+			name:    "short exchange",
+			rules:   TrivialRules{MaxMessages: 2, NoToolUse: true, ShorterThan: time.Minute},
+			session: claude.SessionInfo{UserMessages: 1, AssistantMessages: 1, Timestamp: start, LastTimestamp: start.Add(12 * time.Second)},
+			reason:  "2 messages, no tool use, lasted 12s",
+			trivial: true,
+		},
+		{
+			name:    "used tools",
+			rules:   TrivialRules{MaxMessages: 2, NoToolUse: true},
+			session: claude.SessionInfo{UserMessages: 1, AssistantMessages: 1, ToolUses: 1},
+		},
+		{
+			name:    "too long",
+			rules:   TrivialRules{MaxMessages: 2, ShorterThan: time.Minute},
+			session: claude.SessionInfo{UserMessages: 1, AssistantMessages: 1, Timestamp: start, LastTimestamp: start.Add(time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, trivial := tt.rules.Classify(&tt.session)
+			assert.Equal(t, tt.trivial, trivial)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestFindInventoryOrphans_TrivialSessions(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+	now := time.Date(2025, 12, 10, 0, 0, 0, 0, time.UTC)
+
+	projectDir := filepath.Join(paths.Projects, "-test-project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	write := func(id, content string) string {
+		path := filepath.Join(projectDir, id+".jsonl")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	prompt := func(id, ts string) string {
+		return `{"type":"user","sessionId":"` + id + `","cwd":"/test","timestamp":"` + ts + `","message":{"role":"user","content":"hi"}}` + "\n"
+	}
+	reply := `{"type":"assistant","timestamp":"2025-12-01T09:05:00Z","message":{"id":"m1","content":[{"type":"text","text":"hello"}]}}` + "\n"
+
+	aborted := write("aborted", prompt("aborted", "2025-12-01T09:00:00Z"))
+	summary := write("summary", `{"type":"summary","summary":"Fix bug","leafUuid":"x"}`+"\n")
+	write("answered", prompt("answered", "2025-12-01T09:00:00Z")+reply)
+	write("running", prompt("running", "2025-12-09T23:00:00Z"))
+	write("corrupt", prompt("corrupt", "2025-12-01T09:00:00Z")+"{\n")
+
+	require.NoError(t, os.MkdirAll(paths.Todos, 0755))
+	todo := filepath.Join(paths.Todos, "aborted-agent-aborted.json")
+	require.NoError(t, os.WriteFile(todo, []byte("[]"), 0644))
+
+	inv, err := claude.ScanInventory(context.Background(), paths.Projects, 0, nil)
+	require.NoError(t, err)
+
+	// Without rules, sessions with content are no orphans
+	orphans, err := FindInventoryOrphans(paths, inv, OrphanRules{Now: now})
+	require.NoError(t, err)
+	assert.Empty(t, orphanPaths(orphans, OrphanTypeTrivialSession))
+
+	orphans, err = FindInventoryOrphans(paths, inv, OrphanRules{Now: now, Trivial: &TrivialRules{MaxMessages: 1}})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{aborted, summary}, orphanPaths(orphans, OrphanTypeTrivialSession))
+	assert.Empty(t, orphanPaths(orphans, OrphanTypeTodo), "the todos of a trivial session are linked to it")
+
+	var o OrphanResult
+	for _, found := range orphans {
+		switch found.Path {
+		case aborted:
+			o = found
+		case summary:
+			assert.Equal(t, "only a summary", found.Reason)
+		}
+	}
+	assert.Equal(t, "aborted", o.SessionID)
+	assert.Equal(t, "1 prompt without response", o.Reason)
+	assert.Equal(t, []string{todo}, o.Linked)
+	assert.Contains(t, BuildOrphanPreview([]OrphanResult{o}).Changes[0].Description, "Trivial session: 1 prompt without response; with 1 todo")
+
+	// The session is removed together with its linked data
+	_, err = CleanOrphans([]OrphanResult{o}, nil, false)
+	require.NoError(t, err)
+	assert.NoFileExists(t, aborted)
+	assert.NoFileExists(t, todo)
+}
@@ -0,0 +1,284 @@
+// Package mounts tells a deleted directory apart from one that is only
+// unreachable because the volume or network share holding it is not mounted.
+package mounts
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Status classifies a project path.
+type Status string
+
+const (
+	// StatusPresent means the path exists.
+	StatusPresent Status = "present"
+	// StatusDeleted means the path is gone from a file system that is mounted.
+	StatusDeleted Status = "deleted"
+	// StatusMountAbsent means the path is missing because the file system it
+	// lives on is not mounted or not reachable.
+	StatusMountAbsent Status = "parent mount absent"
+	// StatusPermissionDenied means the path cannot be checked because a
+	// parent directory is not accessible.
+	StatusPermissionDenied Status = "permission denied"
+)
+
+// DefaultBases lists the directories below which removable and network
+// volumes are conventionally mounted.
+var DefaultBases = []string{"/mnt", "/media", "/run/media", "/Volumes", "/net"}
+
+// Mount is a mounted file system.
+type Mount struct {
+	Point  string // Mount point
+	FSType string
+	Source string
+}
+
+// Table holds the mounted file systems, the mount points that are expected
+// to exist, e.g. from /etc/fstab, and the conventional mount bases.
+type Table struct {
+	Mounts   []Mount
+	Expected []string
+	Bases    []string
+}
+
+var (
+	systemOnce  sync.Once
+	systemTable *Table
+)
+
+// System returns the mount table of the running system, read once from
+// /proc/self/mountinfo and /etc/fstab. Files that cannot be read, e.g. on
+// systems other than Linux, leave the table with only the default bases.
+func System() *Table {
+	systemOnce.Do(func() {
+		systemTable = &Table{Bases: DefaultBases}
+		if f, err := os.Open("/proc/self/mountinfo"); err == nil {
+			systemTable.Mounts, _ = ParseMountInfo(f)
+			_ = f.Close()
+		}
+		if f, err := os.Open("/etc/fstab"); err == nil {
+			systemTable.Expected, _ = ParseFstab(f)
+			_ = f.Close()
+		}
+	})
+	return systemTable
+}
+
+// ParseMountInfo parses the format of /proc/<pid>/mountinfo.
+func ParseMountInfo(r io.Reader) ([]Mount, error) {
+	var mounts []Mount
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// ID, parent ID, major:minor, root, mount point, options, optional
+		// fields terminated by "-", file system type, source, super options
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 5 || sep < 0 || sep+2 >= len(fields) {
+			return nil, fmt.Errorf("malformed mountinfo line: %q", scanner.Text())
+		}
+		mounts = append(mounts, Mount{
+			Point:  unescape(fields[4]),
+			FSType: fields[sep+1],
+			Source: unescape(fields[sep+2]),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// ParseFstab returns the mount points listed in an fstab file.
+func ParseFstab(r io.Reader) ([]string, error) {
+	var points []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !filepath.IsAbs(fields[1]) {
+			continue // swap and malformed entries
+		}
+		points = append(points, filepath.Clean(unescape(fields[1])))
+	}
+	return points, scanner.Err()
+}
+
+// unescape decodes the octal escapes (e.g. "\040" for a space) used in
+// mountinfo and fstab.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// IsMounted reports whether a file system is mounted at dir.
+func (t *Table) IsMounted(dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, m := range t.Mounts {
+		if m.Point == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// containing returns the mount holding path, i.e. the one with the longest
+// mount point that is path or one of its parents.
+func (t *Table) containing(path string) (Mount, bool) {
+	var best Mount
+	found := false
+	for _, m := range t.Mounts {
+		if within(path, m.Point) && (!found || len(m.Point) > len(best.Point)) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// Check classifies path. Besides the status it returns an explanation for
+// paths that are missing but not deleted. An empty path counts as deleted.
+func (t *Table) Check(path string) (Status, string) {
+	if path == "" {
+		return StatusDeleted, ""
+	}
+	path = filepath.Clean(path)
+
+	_, err := os.Stat(path)
+	switch {
+	case err == nil:
+		return StatusPresent, ""
+	case errors.Is(err, fs.ErrPermission):
+		return StatusPermissionDenied, "cannot access " + path
+	case !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR):
+		// I/O errors, stale NFS handles and disconnected FUSE mounts
+		return StatusMountAbsent, "file system not reachable: " + errText(err)
+	}
+
+	// A mount point from fstab that is not mounted right now
+	for _, point := range t.Expected {
+		if point != "/" && within(path, point) && !t.IsMounted(point) {
+			return StatusMountAbsent, point + " is not mounted"
+		}
+	}
+
+	// Automounted directories only appear once they are accessed
+	if m, ok := t.containing(path); ok && m.FSType == "autofs" {
+		return StatusMountAbsent, m.Point + " is an automount that is not mounted"
+	}
+
+	// Find the nearest existing parent
+	parent := filepath.Dir(path)
+	for {
+		_, err := os.Stat(parent)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, fs.ErrPermission) {
+			return StatusPermissionDenied, "cannot access " + parent
+		}
+		next := filepath.Dir(parent)
+		if next == parent {
+			return StatusDeleted, ""
+		}
+		parent = next
+	}
+
+	// Below a mount base such as /mnt or /media/<user>, a missing directory
+	// or an empty, unmounted one is a volume that is not plugged in
+	if !t.IsMounted(parent) {
+		if volume := t.missingVolume(path, parent); volume != "" {
+			return StatusMountAbsent, volume + " is not mounted"
+		}
+	}
+
+	return StatusDeleted, ""
+}
+
+// missingVolume returns the likely mount point of the volume holding path if
+// parent, the nearest existing parent of path, is where volumes are mounted.
+func (t *Table) missingVolume(path, parent string) string {
+	for _, base := range t.Bases {
+		base = filepath.Clean(base)
+		if !within(parent, base) {
+			continue
+		}
+		if parent == base || (perUser(base) && filepath.Dir(parent) == base) {
+			// The mount point itself is missing
+			rel, err := filepath.Rel(parent, path)
+			if err != nil {
+				continue
+			}
+			first, _, _ := strings.Cut(rel, string(filepath.Separator))
+			return filepath.Join(parent, first)
+		}
+		if depth(parent, base) <= 2 && isEmptyDir(parent) {
+			// An empty mount point
+			return parent
+		}
+	}
+	return ""
+}
+
+// perUser reports whether base holds per-user directories, like /media/<user>.
+func perUser(base string) bool {
+	return base == "/media" || base == "/run/media"
+}
+
+// depth returns the number of path segments of dir below base.
+func depth(dir, base string) int {
+	rel, err := filepath.Rel(base, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	dir = filepath.Clean(dir)
+	if dir == string(filepath.Separator) {
+		return filepath.IsAbs(path)
+	}
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func isEmptyDir(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) == 0
+}
+
+func errText(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
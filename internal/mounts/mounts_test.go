@@ -0,0 +1,115 @@
+package mounts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+36 22 0:32 / /mnt/my\040usb rw,nosuid master:7 - vfat /dev/sdb1 rw
+41 22 0:40 / /net rw,relatime shared:20 - autofs systemd-1 rw,fd=45
+48 22 0:45 / /home/u/remote rw,nosuid,nodev - fuse.sshfs u@host:/srv rw,user_id=1000
+`
+
+func TestParseMountInfo(t *testing.T) {
+	mounts, err := ParseMountInfo(strings.NewReader(sampleMountInfo))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Mount{
+		{Point: "/", FSType: "ext4", Source: "/dev/sda1"},
+		{Point: "/mnt/my usb", FSType: "vfat", Source: "/dev/sdb1"},
+		{Point: "/net", FSType: "autofs", Source: "systemd-1"},
+		{Point: "/home/u/remote", FSType: "fuse.sshfs", Source: "u@host:/srv"},
+	}, mounts)
+
+	_, err = ParseMountInfo(strings.NewReader("22 1 8:1 / /\n"))
+	assert.Error(t, err)
+}
+
+func TestParseFstab(t *testing.T) {
+	fstab := `# /etc/fstab
+UUID=abc  /               ext4  defaults  0 1
+UUID=def  none            swap  sw        0 0
+nas:/data /mnt/nas\040data nfs  noauto    0 0
+`
+	points, err := ParseFstab(strings.NewReader(fstab))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/mnt/nas data"}, points)
+}
+
+func TestTable_IsMounted(t *testing.T) {
+	table := &Table{Mounts: []Mount{{Point: "/"}, {Point: "/mnt/usb"}}}
+	assert.True(t, table.IsMounted("/mnt/usb/"))
+	assert.False(t, table.IsMounted("/mnt"))
+}
+
+func TestTable_Check(t *testing.T) {
+	tmp := t.TempDir()
+	mnt := filepath.Join(tmp, "mnt")
+	media := filepath.Join(tmp, "media")
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "home", "Code"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(mnt, "stub"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(mnt, "mounted"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(mnt, "full", "other"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "nas"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "auto"), 0755))
+	require.NoError(t, os.MkdirAll(media, 0755))
+
+	table := &Table{
+		Mounts: []Mount{
+			{Point: "/", FSType: "ext4"},
+			{Point: filepath.Join(mnt, "mounted"), FSType: "vfat"},
+			{Point: filepath.Join(tmp, "auto"), FSType: "autofs"},
+		},
+		Expected: []string{"/", filepath.Join(tmp, "nas")},
+		Bases:    []string{mnt, media},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		status  Status
+		explain string
+	}{
+		{"existing", filepath.Join(tmp, "home", "Code"), StatusPresent, ""},
+		{"deleted", filepath.Join(tmp, "home", "Code", "gone"), StatusDeleted, ""},
+		{"deleted below missing parents", filepath.Join(tmp, "home", "x", "y"), StatusDeleted, ""},
+		{"empty path", "", StatusDeleted, ""},
+		{"missing volume below base", filepath.Join(mnt, "usb", "proj"), StatusMountAbsent, filepath.Join(mnt, "usb") + " is not mounted"},
+		{"empty mount point", filepath.Join(mnt, "stub", "proj"), StatusMountAbsent, filepath.Join(mnt, "stub") + " is not mounted"},
+		{"deleted on mounted volume", filepath.Join(mnt, "mounted", "proj"), StatusDeleted, ""},
+		{"deleted in non-empty dir below base", filepath.Join(mnt, "full", "proj"), StatusDeleted, ""},
+		{"per-user media dir", filepath.Join(media, "u", "usb", "proj"), StatusMountAbsent, filepath.Join(media, "u") + " is not mounted"},
+		{"fstab entry not mounted", filepath.Join(tmp, "nas", "proj"), StatusMountAbsent, filepath.Join(tmp, "nas") + " is not mounted"},
+		{"automount", filepath.Join(tmp, "auto", "proj"), StatusMountAbsent, filepath.Join(tmp, "auto") + " is an automount that is not mounted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, explain := table.Check(tt.path)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.explain, explain)
+		})
+	}
+}
+
+func TestTable_CheckPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks do not apply to root")
+	}
+
+	tmp := t.TempDir()
+	locked := filepath.Join(tmp, "locked")
+	require.NoError(t, os.MkdirAll(filepath.Join(locked, "proj"), 0755))
+	require.NoError(t, os.Chmod(locked, 0))
+	defer func() { _ = os.Chmod(locked, 0755) }()
+
+	status, explain := (&Table{}).Check(filepath.Join(locked, "proj"))
+	assert.Equal(t, StatusPermissionDenied, status)
+	assert.Contains(t, explain, "cannot access")
+}
@@ -0,0 +1,113 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SchemaVersion is the version of the machine-readable output schema.
+// It is incremented whenever a field is removed or changes meaning;
+// adding fields or record kinds does not change it.
+const SchemaVersion = 1
+
+// Format selects how command output is rendered.
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat validates an --output value.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatText, FormatJSON, FormatNDJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format: %s (expected text, json or ndjson)", s)
+	}
+}
+
+// Header identifies the schema and command of a machine-readable document.
+// In NDJSON output it is the first line.
+type Header struct {
+	Kind          string `json:"kind"`
+	SchemaVersion int    `json:"schemaVersion"`
+	Command       string `json:"command"`
+}
+
+// document is the top-level object of JSON output.
+type document struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Command       string `json:"command"`
+	Records       []any  `json:"records"`
+}
+
+// Encoder writes records as a single JSON document or as NDJSON lines.
+type Encoder struct {
+	w       io.Writer
+	format  Format
+	command string
+	records []any
+	started bool
+}
+
+// NewEncoder creates an encoder for the given command, e.g. "list projects".
+func NewEncoder(w io.Writer, format Format, command string) *Encoder {
+	return &Encoder{
+		w:       w,
+		format:  format,
+		command: command,
+		records: []any{},
+	}
+}
+
+// Add emits a record. NDJSON records are written immediately; JSON records
+// are buffered until Close.
+func (e *Encoder) Add(record any) error {
+	if e.format != FormatNDJSON {
+		e.records = append(e.records, record)
+		return nil
+	}
+
+	if !e.started {
+		e.started = true
+		if err := e.writeLine(Header{Kind: "header", SchemaVersion: SchemaVersion, Command: e.command}); err != nil {
+			return err
+		}
+	}
+	return e.writeLine(record)
+}
+
+// Close finishes the output. For JSON it writes the buffered document.
+func (e *Encoder) Close() error {
+	if e.format == FormatNDJSON {
+		if !e.started {
+			e.started = true
+			return e.writeLine(Header{Kind: "header", SchemaVersion: SchemaVersion, Command: e.command})
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(document{
+		SchemaVersion: SchemaVersion,
+		Command:       e.command,
+		Records:       e.records,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "%s\n", data)
+	return err
+}
+
+func (e *Encoder) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "%s\n", data)
+	return err
+}
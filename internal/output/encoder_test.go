@@ -0,0 +1,87 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"text", "json", "ndjson"} {
+		f, err := ParseFormat(s)
+		require.NoError(t, err)
+		assert.Equal(t, Format(s), f)
+	}
+
+	_, err := ParseFormat("yaml")
+	assert.Error(t, err)
+}
+
+func TestEncoder_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, FormatJSON, "list orphans")
+
+	require.NoError(t, enc.Add(Orphan{Kind: "orphan", Type: "todo", Path: "/a", Size: 10}))
+	assert.Empty(t, buf.String(), "JSON output should be buffered until Close")
+	require.NoError(t, enc.Close())
+
+	var doc struct {
+		SchemaVersion int              `json:"schemaVersion"`
+		Command       string           `json:"command"`
+		Records       []map[string]any `json:"records"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, SchemaVersion, doc.SchemaVersion)
+	assert.Equal(t, "list orphans", doc.Command)
+	require.Len(t, doc.Records, 1)
+	assert.Equal(t, "orphan", doc.Records[0]["kind"])
+	assert.Equal(t, "/a", doc.Records[0]["path"])
+}
+
+func TestEncoder_JSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf, FormatJSON, "list projects").Close())
+
+	assert.Contains(t, buf.String(), `"records": []`)
+}
+
+func TestEncoder_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, FormatNDJSON, "clean projects")
+
+	require.NoError(t, enc.Add(NewResult("projects", "DELETE", "/a", 10, nil)))
+	require.NoError(t, enc.Add(NewSummary("projects")))
+	require.NoError(t, enc.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var header Header
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, Header{Kind: "header", SchemaVersion: SchemaVersion, Command: "clean projects"}, header)
+
+	var result Result
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &result))
+	assert.Equal(t, ResultDone, result.Status)
+
+	assert.Contains(t, lines[2], `"kind":"summary"`)
+}
+
+func TestEncoder_NDJSONEmptyWritesHeader(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf, FormatNDJSON, "list orphans").Close())
+
+	assert.Equal(t, `{"kind":"header","schemaVersion":1,"command":"list orphans"}`+"\n", buf.String())
+}
+
+func TestNewResult_Error(t *testing.T) {
+	r := NewResult("config", "MODIFY", "/a/settings.local.json", 0, errors.New("permission denied"))
+
+	assert.Equal(t, ResultError, r.Status)
+	assert.Equal(t, "permission denied", r.Error)
+}
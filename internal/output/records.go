@@ -0,0 +1,568 @@
+package output
+
+import (
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/archive"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pins"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/secrets"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/trash"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/usage"
+)
+
+// Project status values.
+const (
+	StatusOK    = "ok"
+	StatusStale = "stale"
+	StatusTrim  = "trim" // State entry whose history will be trimmed
+
+	StatusProtected = "protected"
+	StatusPinned    = "pinned"
+	StatusUnmounted = "unmounted" // Missing because its volume is not mounted
+	StatusDenied    = "denied"    // Missing because a parent is not accessible
+	StatusCorrupt   = "corrupt"   // Has session files with invalid lines
+)
+
+// Result status values.
+const (
+	ResultDone    = "done"
+	ResultError   = "error"
+	ResultSkipped = "skipped"
+)
+
+// Project describes a project registered in ~/.claude/projects.
+type Project struct {
+	Kind        string    `json:"kind"` // "project"
+	EncodedName string    `json:"encodedName"`
+	Path        string    `json:"path"`
+	Status      string    `json:"status"`
+	SessionIDs  []string  `json:"sessionIds"`
+	Files       int       `json:"files"`
+	Size        int64     `json:"size"`
+	LastUsed    time.Time `json:"lastUsed,omitzero"`
+	Reason      string    `json:"reason,omitempty"`          // Why a protected or unavailable project is kept
+	Corrupt     []string  `json:"corruptSessions,omitempty"` // Session files with invalid lines
+
+	UserMessages      int               `json:"userMessages"`
+	AssistantMessages int               `json:"assistantMessages"`
+	Tokens            claude.TokenUsage `json:"tokens"`
+	Models            []string          `json:"models"`
+	Branches          []string          `json:"branches"`
+}
+
+// NewProject converts a scanned project.
+func NewProject(p claude.Project, status string) Project {
+	ids := p.SessionIDs
+	if ids == nil {
+		ids = []string{}
+	}
+	var corrupt []string
+	for _, s := range p.CorruptSessions() {
+		corrupt = append(corrupt, s.FilePath)
+	}
+	activity := p.Activity()
+	return Project{
+		Kind:        "project",
+		EncodedName: p.EncodedName,
+		Path:        p.ActualPath,
+		Status:      status,
+		SessionIDs:  ids,
+		Files:       p.FileCount,
+		Size:        p.TotalSize,
+		LastUsed:    p.LastUsed,
+		Corrupt:     corrupt,
+
+		UserMessages:      activity.UserMessages,
+		AssistantMessages: activity.AssistantMessages,
+		Tokens:            activity.Usage,
+		Models:            nonNil(activity.Models),
+		Branches:          nonNil(activity.Branches),
+	}
+}
+
+// Orphan describes orphaned data found by the orphan scanner.
+type Orphan struct {
+	Kind   string   `json:"kind"` // "orphan"
+	Type   string   `json:"type"`
+	Path   string   `json:"path"`
+	Size   int64    `json:"size"`
+	Reason string   `json:"reason,omitempty"` // Why a session is trivial
+	Linked []string `json:"linked,omitempty"` // Linked data of a trivial session
+}
+
+// NewOrphan converts an orphan scan result.
+func NewOrphan(o cleaner.OrphanResult) Orphan {
+	return Orphan{
+		Kind:   "orphan",
+		Type:   string(o.Type),
+		Path:   o.Path,
+		Size:   o.SizeSaved,
+		Reason: o.Reason,
+		Linked: o.Linked,
+	}
+}
+
+// Config describes the duplicate entries of a local settings file.
+type Config struct {
+	Kind   string   `json:"kind"` // "config"
+	Path   string   `json:"path"`
+	Allow  []string `json:"allow"`
+	Deny   []string `json:"deny"`
+	Ask    []string `json:"ask"`
+	Delete bool     `json:"delete"`
+	// Covered explains the entries that a broader rule covers.
+	Covered []Coverage `json:"covered,omitempty"`
+}
+
+// Coverage describes a config entry covered by a broader rule.
+type Coverage struct {
+	List  string `json:"list"`
+	Rule  string `json:"rule"`
+	By    string `json:"by"`
+	Layer string `json:"layer"`
+	Path  string `json:"path,omitempty"`
+}
+
+// NewConfig converts a deduplication result.
+func NewConfig(r cleaner.DedupResult) Config {
+	record := Config{
+		Kind:   "config",
+		Path:   r.LocalPath,
+		Allow:  nonNil(r.DuplicateAllow),
+		Deny:   nonNil(r.DuplicateDeny),
+		Ask:    nonNil(r.DuplicateAsk),
+		Delete: r.SuggestDelete,
+	}
+	for _, s := range r.Subsumed {
+		record.Covered = append(record.Covered, Coverage{List: s.List, Rule: s.Rule, By: s.By, Layer: s.Layer, Path: s.Path})
+	}
+	return record
+}
+
+// Promotion describes a rule that config promote moves into the user settings.
+type Promotion struct {
+	Kind    string   `json:"kind"` // "promotion"
+	List    string   `json:"list"`
+	Rule    string   `json:"rule"`
+	Configs []string `json:"configs"`
+}
+
+// NewPromotion converts a rule found in enough local configs.
+func NewPromotion(p cleaner.Promotion) Promotion {
+	return Promotion{
+		Kind:    "promotion",
+		List:    p.List,
+		Rule:    p.Rule,
+		Configs: nonNil(p.Configs),
+	}
+}
+
+// Lint describes a problem with a permission rule of a settings file.
+type Lint struct {
+	Kind    string `json:"kind"` // "lint"
+	Problem string `json:"problem"`
+	Path    string `json:"path"`
+	Layer   string `json:"layer"`
+	List    string `json:"list"`
+	Rule    string `json:"rule"`
+	By      string `json:"by,omitempty"`
+	ByList  string `json:"byList,omitempty"`
+	ByLayer string `json:"byLayer,omitempty"`
+	ByPath  string `json:"byPath,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewLint converts a finding of the settings lint.
+func NewLint(f cleaner.LintFinding) Lint {
+	return Lint{
+		Kind:    "lint",
+		Problem: f.Problem,
+		Path:    f.Path,
+		Layer:   f.Layer,
+		List:    f.List,
+		Rule:    f.Rule,
+		By:      f.By,
+		ByList:  f.ByList,
+		ByLayer: f.ByLayer,
+		ByPath:  f.ByPath,
+		Message: f.Description(),
+	}
+}
+
+// Session describes a session transcript, either listed or selected by a
+// retention policy.
+type Session struct {
+	Kind       string    `json:"kind"` // "session"
+	ID         string    `json:"id"`
+	Project    string    `json:"project"`
+	Path       string    `json:"path"`
+	Started    time.Time `json:"started,omitzero"`
+	LastActive time.Time `json:"lastActive,omitzero"`
+	Size       int64     `json:"size"`             // Including linked data
+	Reason     string    `json:"reason,omitempty"` // Why a retention policy selected the session
+	Linked     []string  `json:"linked"`
+
+	UserMessages      int               `json:"userMessages"`
+	AssistantMessages int               `json:"assistantMessages"`
+	Version           string            `json:"version,omitempty"`
+	GitBranch         string            `json:"gitBranch,omitempty"`
+	Models            []string          `json:"models"`
+	Tokens            claude.TokenUsage `json:"tokens"`
+	Summary           string            `json:"summary,omitempty"`
+	BadLines          int               `json:"badLines,omitempty"`
+}
+
+// NewSession converts a retention result.
+func NewSession(r cleaner.SessionResult) Session {
+	record := NewSessionInfo(r.Project, r.Session, r.Linked, r.SizeSaved)
+	record.Reason = r.Reason
+	return record
+}
+
+// NewSessionInfo converts a scanned session with its linked data, whose
+// total size including the session file is size.
+func NewSessionInfo(p claude.Project, s claude.SessionInfo, linked []string, size int64) Session {
+	return Session{
+		Kind:       "session",
+		ID:         s.ID,
+		Project:    p.ActualPath,
+		Path:       s.FilePath,
+		Started:    s.Timestamp,
+		LastActive: s.LastActive(),
+		Size:       size,
+		Linked:     nonNil(linked),
+
+		UserMessages:      s.UserMessages,
+		AssistantMessages: s.AssistantMessages,
+		Version:           s.Version,
+		GitBranch:         s.GitBranch,
+		Models:            nonNil(s.Models),
+		Tokens:            s.Usage,
+		Summary:           s.Summary,
+		BadLines:          s.BadLines,
+	}
+}
+
+// Message describes a prompt or reply of a session transcript.
+type Message struct {
+	Kind      string    `json:"kind"` // "message"
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	Role      string    `json:"role"`
+	Text      string    `json:"text"`
+	Tools     []string  `json:"tools"`
+}
+
+// NewMessage converts a transcript message.
+func NewMessage(m claude.Message) Message {
+	return Message{
+		Kind:      "message",
+		Timestamp: m.Timestamp,
+		Role:      m.Role,
+		Text:      m.Text,
+		Tools:     nonNil(m.Tools),
+	}
+}
+
+// Todo describes an item of a session's todo list.
+type Todo struct {
+	Kind    string `json:"kind"` // "todo"
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+// NewTodo converts a todo item read from the file at path.
+func NewTodo(path string, t claude.Todo) Todo {
+	return Todo{
+		Kind:    "todo",
+		Path:    path,
+		Content: t.Content,
+		Status:  t.Status,
+	}
+}
+
+// Match describes a block of transcript text found by grep.
+type Match struct {
+	Kind      string    `json:"kind"` // "match"
+	Session   string    `json:"session"`
+	Project   string    `json:"project"`
+	Path      string    `json:"path"`
+	Line      int       `json:"line"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	Role      string    `json:"role"`
+	Snippet   string    `json:"snippet"`
+}
+
+// NewMatch converts a search match found in a session of the project at
+// projectPath.
+func NewMatch(projectPath string, m claude.SearchMatch) Match {
+	return Match{
+		Kind:      "match",
+		Session:   m.SessionID,
+		Project:   projectPath,
+		Path:      m.Path,
+		Line:      m.Line,
+		Timestamp: m.Timestamp,
+		Role:      m.Role,
+		Snippet:   m.Snippet,
+	}
+}
+
+// Secret describes a secret found in a session transcript or file-history
+// snapshot. Match holds only the first characters of the secret.
+type Secret struct {
+	Kind    string `json:"kind"` // "secret"
+	Path    string `json:"path"`
+	Session string `json:"session"`
+	Project string `json:"project"`
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Match   string `json:"match"`
+}
+
+// NewSecret converts a secret found in the file at path, which belongs to a
+// session of the project at projectPath.
+func NewSecret(path, session, projectPath string, h secrets.Hit) Secret {
+	return Secret{
+		Kind:    "secret",
+		Path:    path,
+		Session: session,
+		Project: projectPath,
+		Line:    h.Line,
+		Rule:    h.Rule,
+		Match:   h.Masked,
+	}
+}
+
+// StateEntry describes a project entry of the global state file, ~/.claude.json.
+type StateEntry struct {
+	Kind         string   `json:"kind"` // "stateEntry"
+	Path         string   `json:"path"`
+	Status       string   `json:"status"`
+	History      int      `json:"history"`
+	AllowedTools int      `json:"allowedTools"`
+	MCPServers   []string `json:"mcpServers"`
+	Size         int64    `json:"size"`
+}
+
+// NewStateEntry converts a state file entry.
+func NewStateEntry(p claude.StateProject, status string) StateEntry {
+	return StateEntry{
+		Kind:         "stateEntry",
+		Path:         p.Path,
+		Status:       status,
+		History:      p.History,
+		AllowedTools: p.AllowedTools,
+		MCPServers:   nonNil(p.MCPServers),
+		Size:         p.Size,
+	}
+}
+
+// TrashRun describes a quarantine run.
+type TrashRun struct {
+	Kind    string      `json:"kind"` // "trashRun"
+	RunID   string      `json:"runId"`
+	Command string      `json:"command"`
+	Created time.Time   `json:"created"`
+	Size    int64       `json:"size"`
+	Items   []TrashItem `json:"items"`
+}
+
+// TrashItem describes a single item held in a quarantine run.
+type TrashItem struct {
+	ID    int    `json:"id"`
+	Type  string `json:"type"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"isDir"`
+}
+
+// NewTrashRun converts a trash manifest, listing only pending items.
+func NewTrashRun(m trash.Manifest) TrashRun {
+	run := TrashRun{
+		Kind:    "trashRun",
+		RunID:   m.RunID,
+		Command: m.Command,
+		Created: m.Created,
+		Size:    m.TotalSize(),
+		Items:   []TrashItem{},
+	}
+	for _, item := range m.Pending() {
+		run.Items = append(run.Items, TrashItem{
+			ID:    item.ID,
+			Type:  string(item.Kind),
+			Path:  item.Path,
+			Size:  item.Size,
+			IsDir: item.IsDir,
+		})
+	}
+	return run
+}
+
+// TrackedFile describes a file with snapshots in the file-history of a session.
+type TrackedFile struct {
+	Kind      string     `json:"kind"` // "trackedFile"
+	Session   string     `json:"session"`
+	Project   string     `json:"project"`
+	Source    string     `json:"source"`
+	Size      int64      `json:"size"`
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+// Snapshot describes a file-history snapshot of a tracked file.
+type Snapshot struct {
+	Path    string `json:"path"`
+	Version int    `json:"version"`
+	Size    int64  `json:"size"`
+	Reason  string `json:"reason,omitempty"` // Why it can be removed
+}
+
+// NewTrackedFile converts a tracked file of the file-history analysis.
+func NewTrackedFile(f cleaner.TrackedFile) TrackedFile {
+	record := TrackedFile{
+		Kind:      "trackedFile",
+		Session:   f.SessionID,
+		Project:   f.Project.ActualPath,
+		Source:    f.Source,
+		Size:      f.Size(),
+		Snapshots: []Snapshot{},
+	}
+	for _, s := range f.Snapshots {
+		record.Snapshots = append(record.Snapshots, Snapshot{
+			Path:    s.Path,
+			Version: s.Version,
+			Size:    s.Size,
+			Reason:  s.Reason,
+		})
+	}
+	return record
+}
+
+// Archive describes a session bundle.
+type Archive struct {
+	Kind    string    `json:"kind"` // "archive"
+	Path    string    `json:"path"`
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+	Files   int       `json:"files"`
+	Size    int64     `json:"size"`
+}
+
+// NewArchive converts the manifest of the bundle at path.
+func NewArchive(path string, m archive.Manifest) Archive {
+	return Archive{
+		Kind:    "archive",
+		Path:    path,
+		Command: m.Command,
+		Created: m.Created,
+		Files:   len(m.Files),
+		Size:    m.TotalSize(),
+	}
+}
+
+// Usage groups, see Usage.
+const (
+	UsageTotal    = "total"
+	UsageCategory = "category"
+	UsageProject  = "project"
+	UsageSession  = "session"
+	UsageMonth    = "month"
+)
+
+// Usage describes the disk space used by a category, project, session or
+// month of last modification, or by everything below ~/.claude.
+type Usage struct {
+	Kind    string `json:"kind"` // "usage"
+	Group   string `json:"group"`
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Project string `json:"project,omitempty"` // Project path of a session
+	Files   int    `json:"files"`
+	Size    int64  `json:"size"`
+}
+
+// NewUsage converts an entry of a disk usage report.
+func NewUsage(group string, e usage.Entry) Usage {
+	return Usage{
+		Kind:    "usage",
+		Group:   group,
+		Name:    e.Name,
+		Path:    e.Path,
+		Project: e.Project,
+		Files:   e.Files,
+		Size:    e.Size,
+	}
+}
+
+// Pin describes a pinned path or glob pattern.
+type Pin struct {
+	Kind     string    `json:"kind"` // "pin"
+	Pattern  string    `json:"pattern"`
+	Created  time.Time `json:"created"`
+	Projects []string  `json:"projects"` // Known projects the pin matches
+}
+
+// NewPin converts a pin and the paths of the projects it matches.
+func NewPin(p pins.Pin, projects []string) Pin {
+	return Pin{
+		Kind:     "pin",
+		Pattern:  p.Pattern,
+		Created:  p.Created,
+		Projects: nonNil(projects),
+	}
+}
+
+// Result reports the outcome of a single change made by a clean, restore or
+// purge command.
+type Result struct {
+	Kind     string `json:"kind"` // "result"
+	Category string `json:"category"`
+	Type     string `json:"type,omitempty"` // Orphan type for orphan results
+	Action   string `json:"action"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewResult creates a result record. A non-nil err marks the result as failed.
+func NewResult(category, action, path string, size int64, err error) Result {
+	r := Result{
+		Kind:     "result",
+		Category: category,
+		Action:   action,
+		Path:     path,
+		Size:     size,
+		Status:   ResultDone,
+	}
+	if err != nil {
+		r.Status = ResultError
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// Summary closes the output of a single cleaner.
+type Summary struct {
+	Kind     string `json:"kind"` // "summary"
+	Category string `json:"category"`
+	DryRun   bool   `json:"dryRun"`
+	Aborted  bool   `json:"aborted"`
+	Items    int    `json:"items"`
+	Errors   int    `json:"errors"`
+	Size     int64  `json:"size"`
+}
+
+// NewSummary creates a summary record.
+func NewSummary(category string) Summary {
+	return Summary{Kind: "summary", Category: category}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+// Package pathglob matches file system paths against glob patterns that
+// protect whole directory trees.
+package pathglob
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Validate checks the syntax of a glob pattern.
+func Validate(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	for _, seg := range split(pattern) {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// IsPattern reports whether s contains glob meta characters.
+func IsPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// MatchTree reports whether name or one of its parent directories matches
+// the glob pattern. Patterns are matched segment by segment with path.Match;
+// a "**" segment matches any number of segments.
+func MatchTree(pattern, name string) bool {
+	if name == "" {
+		return false
+	}
+	pat := split(pattern)
+	segs := split(name)
+	for n := len(segs); n > 0; n-- {
+		if matchSegments(pat, segs[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// split splits a slash- or OS-separated path into its segments.
+func split(p string) []string {
+	p = filepath.ToSlash(p)
+	return strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+}
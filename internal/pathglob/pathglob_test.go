@@ -0,0 +1,45 @@
+package pathglob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchTree(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"/home/u/Code/keep", "/home/u/Code/keep", true},
+		{"/home/u/Code/keep", "/home/u/Code/keep/sub/dir", true},
+		{"/home/u/Code/keep", "/home/u/Code/keeper", false},
+		{"/home/u/Code/keep", "/home/u/Code", false},
+		{"/work/**/secret", "/work/secret", true},
+		{"/work/**/secret", "/work/a/b/secret/src", true},
+		{"/work/**/secret", "/work/a/public", false},
+		{"/tmp/*-important", "/tmp/very-important", true},
+		{"/tmp/*-important", "/tmp/other", false},
+		{"/mnt/**", "/mnt/usb/project", true},
+		{"/mnt/**", "/home/u", false},
+		{"/a", "", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, MatchTree(tt.pattern, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("/work/**/*.go"))
+	assert.Error(t, Validate(""))
+	assert.Error(t, Validate("/work/[x"))
+}
+
+func TestIsPattern(t *testing.T) {
+	assert.True(t, IsPattern("/work/*"))
+	assert.True(t, IsPattern("/work/?"))
+	assert.True(t, IsPattern("/work/[ab]"))
+	assert.False(t, IsPattern("/work/plain"))
+}
@@ -0,0 +1,252 @@
+// Package permissions parses Claude Code permission rules, e.g. "Bash(git:*)",
+// "Read(~/secrets/**)" or "mcp__github", and decides whether one rule covers
+// another, that is whether everything the narrower rule matches is also
+// matched by the broader one.
+//
+// Coverage is decided conservatively: when the semantics of two rules cannot
+// be compared with certainty, neither covers the other, so that a rule is
+// never reported as redundant when it is not.
+package permissions
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// mcpPrefix starts the names of MCP tools, "mcp__<server>__<tool>".
+const mcpPrefix = "mcp__"
+
+// pathTools are the tools whose specifier is a gitignore-style path pattern.
+var pathTools = map[string]bool{
+	"Read":         true,
+	"Edit":         true,
+	"Write":        true,
+	"MultiEdit":    true,
+	"NotebookEdit": true,
+}
+
+// Rule is a parsed permission rule, "Tool" or "Tool(specifier)".
+type Rule struct {
+	Raw       string
+	Tool      string
+	Specifier string // Empty if the rule applies to every use of the tool
+}
+
+// Parse parses a permission rule.
+func Parse(s string) (Rule, error) {
+	r := Rule{Raw: s}
+	s = strings.TrimSpace(s)
+	tool, spec, hasSpec := strings.Cut(s, "(")
+	if hasSpec {
+		if !strings.HasSuffix(spec, ")") {
+			return r, fmt.Errorf("invalid rule %q: missing closing parenthesis", r.Raw)
+		}
+		spec = strings.TrimSuffix(spec, ")")
+		if spec == "" {
+			return r, fmt.Errorf("invalid rule %q: empty specifier", r.Raw)
+		}
+	}
+	if tool == "" || strings.ContainsAny(tool, " )") {
+		return r, fmt.Errorf("invalid rule %q: invalid tool name", r.Raw)
+	}
+	r.Tool = tool
+	r.Specifier = spec
+	return r, nil
+}
+
+// String returns the rule as written.
+func (r Rule) String() string {
+	return r.Raw
+}
+
+// IsPath reports whether the rule's specifier is a path pattern.
+func (r Rule) IsPath() bool {
+	return pathTools[r.Tool] && r.Specifier != ""
+}
+
+// IsRelative reports whether the rule is a path pattern relative to the
+// settings file or the working directory rather than an absolute or
+// home-relative one. Such rules can only be compared between settings files
+// of the same directory.
+func (r Rule) IsRelative() bool {
+	return r.IsPath() && !strings.HasPrefix(r.Specifier, "//") && !strings.HasPrefix(r.Specifier, "~/")
+}
+
+// Covers reports whether r matches everything that o matches.
+func (r Rule) Covers(o Rule) bool {
+	if r.Raw == o.Raw {
+		return true
+	}
+	if strings.HasPrefix(r.Tool, mcpPrefix) || strings.HasPrefix(o.Tool, mcpPrefix) {
+		return r.coversMCP(o)
+	}
+	if r.Tool != o.Tool {
+		return false
+	}
+	if r.Specifier == "" {
+		return true
+	}
+	if o.Specifier == "" {
+		return false
+	}
+
+	switch {
+	case r.Tool == "Bash":
+		return coversCommand(r.Specifier, o.Specifier)
+	case r.IsPath():
+		return coversPath(r.Specifier, o.Specifier)
+	case r.Tool == "WebFetch":
+		return coversDomain(r.Specifier, o.Specifier)
+	default:
+		return r.Specifier == o.Specifier
+	}
+}
+
+// coversMCP reports whether the MCP rule r covers o: "mcp__server" and
+// "mcp__server__*" cover every tool of the server.
+func (r Rule) coversMCP(o Rule) bool {
+	name, ok := strings.CutPrefix(r.Tool, mcpPrefix)
+	if !ok || r.Specifier != "" || o.Specifier != "" {
+		return false
+	}
+	server, tool, _ := strings.Cut(name, "__")
+	if server == "" || tool != "" && tool != "*" {
+		return r.Tool == o.Tool
+	}
+	return strings.HasPrefix(o.Tool, mcpPrefix+server+"__")
+}
+
+// coversCommand reports whether the Bash specifier a covers b. A specifier is
+// an exact command, a prefix ending in ":*" or a pattern in which "*" matches
+// anything. A prefix only covers commands that continue after a space, which
+// holds whether or not Claude Code requires a word boundary.
+func coversCommand(a, b string) bool {
+	bPrefix, bIsPrefix := strings.CutSuffix(b, ":*")
+
+	if aPrefix, ok := strings.CutSuffix(a, ":*"); ok {
+		if strings.Contains(aPrefix, "*") {
+			return false
+		}
+		switch {
+		case bIsPrefix:
+			return bPrefix == aPrefix || strings.HasPrefix(bPrefix, aPrefix+" ")
+		case strings.Contains(b, "*"):
+			// Every command b matches starts with the text before its "*"
+			return strings.HasPrefix(b[:strings.Index(b, "*")], aPrefix+" ")
+		default:
+			return b == aPrefix || strings.HasPrefix(b, aPrefix+" ")
+		}
+	}
+
+	if !strings.Contains(a, "*") {
+		return false
+	}
+	if bIsPrefix {
+		return matchWildcard(a, bPrefix) && matchWildcard(a, bPrefix+"*")
+	}
+	// A "*" of b is matched by a "*" of a, as a has no literal "*"
+	return matchWildcard(a, b)
+}
+
+// matchWildcard reports whether text matches pattern, in which "*" matches
+// any sequence of characters.
+func matchWildcard(pattern, text string) bool {
+	head, rest, found := strings.Cut(pattern, "*")
+	if !found {
+		return pattern == text
+	}
+	if !strings.HasPrefix(text, head) {
+		return false
+	}
+	text = text[len(head):]
+	for i := 0; i <= len(text); i++ {
+		if matchWildcard(rest, text[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversPath reports whether the path pattern a covers b. Both must be
+// anchored alike: "//" for absolute paths, "~/" for the home directory, "/"
+// for the directory of the settings file, or relative.
+func coversPath(a, b string) bool {
+	if anchor(a) != anchor(b) {
+		return false
+	}
+	// A pattern ending in "/" only matches directories
+	if strings.HasSuffix(a, "/") && !strings.HasSuffix(b, "/") {
+		return false
+	}
+	return matchSegments(segments(a), segments(b))
+}
+
+// anchor returns the part of a path pattern that says what it is relative to.
+func anchor(p string) string {
+	for _, prefix := range []string{"//", "~/", "/", "./"} {
+		if strings.HasPrefix(p, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+func segments(p string) []string {
+	p = strings.TrimPrefix(p, anchor(p))
+	return strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+}
+
+// matchSegments matches the segments of pattern b against those of pattern
+// a, where a "**" segment of a matches any number of segments.
+func matchSegments(a, b []string) bool {
+	for len(a) > 0 {
+		if a[0] == "**" {
+			for i := 0; i <= len(b); i++ {
+				if matchSegments(a[1:], b[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(b) == 0 || !coversSegment(a[0], b[0]) {
+			return false
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(b) == 0
+}
+
+// coversSegment reports whether the segment pattern a covers b.
+func coversSegment(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if b == "**" {
+		return false
+	}
+	// A "?" or class of a cannot stand for a wildcard of b
+	if strings.ContainsAny(b, "*?[") && strings.ContainsAny(a, "?[\\") {
+		return false
+	}
+	ok, _ := path.Match(a, b)
+	return ok
+}
+
+// coversDomain reports whether the WebFetch specifier a covers b, as in
+// "domain:*.example.com" covering "domain:docs.example.com".
+func coversDomain(a, b string) bool {
+	da, ok := strings.CutPrefix(a, "domain:")
+	if !ok {
+		return false
+	}
+	db, ok := strings.CutPrefix(b, "domain:")
+	if !ok {
+		return false
+	}
+	if da == db {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(da, "*")
+	return ok && strings.HasPrefix(suffix, ".") && strings.HasSuffix(db, suffix)
+}
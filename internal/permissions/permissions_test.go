@@ -0,0 +1,120 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule      string
+		tool      string
+		specifier string
+	}{
+		{"Bash", "Bash", ""},
+		{"Bash(git:*)", "Bash", "git:*"},
+		{"Bash(echo (hi))", "Bash", "echo (hi)"},
+		{"Read(~/.ssh/**)", "Read", "~/.ssh/**"},
+		{"WebFetch(domain:example.com)", "WebFetch", "domain:example.com"},
+		{"mcp__github__create_issue", "mcp__github__create_issue", ""},
+	}
+
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		require.NoError(t, err, tt.rule)
+		assert.Equal(t, tt.rule, r.Raw)
+		assert.Equal(t, tt.tool, r.Tool, tt.rule)
+		assert.Equal(t, tt.specifier, r.Specifier, tt.rule)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, rule := range []string{"", "Bash(git:*", "Bash()", "(git)", "Bad Tool"} {
+		_, err := Parse(rule)
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		broad  string
+		narrow string
+		covers bool
+	}{
+		// Whole tools
+		{"Bash", "Bash(git:*)", true},
+		{"Bash(git:*)", "Bash", false},
+		{"Read", "Read(./src/**)", true},
+		{"Bash", "Read", false},
+
+		// Command prefixes and wildcards
+		{"Bash(git:*)", "Bash(git status:*)", true},
+		{"Bash(git:*)", "Bash(git status)", true},
+		{"Bash(git:*)", "Bash(git)", true},
+		{"Bash(git:*)", "Bash(git log *)", true},
+		{"Bash(git:*)", "Bash(gitk:*)", false},
+		{"Bash(git status:*)", "Bash(git:*)", false},
+		{"Bash(git status)", "Bash(git status --short)", false},
+		{"Bash(npm *)", "Bash(npm run test)", true},
+		{"Bash(npm *)", "Bash(npm run *)", true},
+		{"Bash(npm *)", "Bash(npm run:*)", true},
+		{"Bash(npm run *)", "Bash(npm *)", false},
+		{"Bash(*)", "Bash(rm -rf:*)", true},
+
+		// Paths
+		{"Read(~/Code/**)", "Read(~/Code/app/main.go)", true},
+		{"Read(~/Code/**)", "Read(~/Code/app/**)", true},
+		{"Read(~/Code/*)", "Read(~/Code/app/main.go)", false},
+		{"Read(~/Code/*)", "Read(~/Code/**)", false},
+		{"Read(./src/*.go)", "Read(./src/main.go)", true},
+		{"Read(./src/*.go)", "Read(./src/*)", false},
+		{"Read(./src/?.go)", "Read(./src/*.go)", false},
+		{"Edit(//etc/**)", "Edit(/etc/hosts)", false},
+		{"Read(/docs/**)", "Read(/docs/api/index.md)", true},
+		{"Read(src/)", "Read(src)", false},
+		{"Read(~/Code/**)", "Edit(~/Code/app)", false},
+
+		// Domains
+		{"WebFetch(domain:example.com)", "WebFetch(domain:example.com)", true},
+		{"WebFetch(domain:*.example.com)", "WebFetch(domain:docs.example.com)", true},
+		{"WebFetch(domain:*.example.com)", "WebFetch(domain:example.com)", false},
+		{"WebFetch(domain:example.com)", "WebFetch(domain:docs.example.com)", false},
+
+		// MCP tools
+		{"mcp__github", "mcp__github__create_issue", true},
+		{"mcp__github__*", "mcp__github__create_issue", true},
+		{"mcp__github__create_issue", "mcp__github", false},
+		{"mcp__github", "mcp__gitlab__create_issue", false},
+		{"Bash", "mcp__Bash__run", false},
+
+		// Other tools
+		{"WebSearch", "WebSearch", true},
+		{"Task(explore)", "Task(plan)", false},
+	}
+
+	for _, tt := range tests {
+		broad, err := Parse(tt.broad)
+		require.NoError(t, err)
+		narrow, err := Parse(tt.narrow)
+		require.NoError(t, err)
+		assert.Equal(t, tt.covers, broad.Covers(narrow), "%s covers %s", tt.broad, tt.narrow)
+	}
+}
+
+func TestRule_IsRelative(t *testing.T) {
+	for rule, relative := range map[string]bool{
+		"Read(./src/**)":  true,
+		"Read(/docs/**)":  true,
+		"Read(*.env)":     true,
+		"Read(//etc/**)":  false,
+		"Read(~/Code/**)": false,
+		"Read":            false,
+		"Bash(ls:*)":      false,
+	} {
+		r, err := Parse(rule)
+		require.NoError(t, err)
+		assert.Equal(t, relative, r.IsRelative(), rule)
+	}
+}
@@ -0,0 +1,149 @@
+// Package pins keeps the list of projects that must never be treated as
+// stale, e.g. because they live on a drive that is not always mounted.
+package pins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pathglob"
+)
+
+// Pin is a single pinned path or glob pattern.
+type Pin struct {
+	Pattern string    `json:"pattern"`
+	Created time.Time `json:"created"`
+}
+
+// List is the set of pins stored in a file.
+type List struct {
+	path string
+	Pins []Pin
+}
+
+// file is the on-disk format of a List.
+type file struct {
+	Pins []Pin `json:"pins"`
+}
+
+// DefaultPath returns the default pin file for a given Claude home directory.
+func DefaultPath(claudeHome string) string {
+	return filepath.Join(claudeHome, "cccc-pins.json")
+}
+
+// Load reads the pins stored at path. A missing file yields an empty list.
+func Load(path string) (*List, error) {
+	l := &List{path: path}
+
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("corrupt pin file %s: %w", path, err)
+	}
+	l.Pins = f.Pins
+
+	return l, nil
+}
+
+// Path returns the file the list is stored in.
+func (l *List) Path() string {
+	return l.path
+}
+
+// Add pins pattern. It reports false if the pattern was already pinned.
+func (l *List) Add(pattern string, now time.Time) bool {
+	if l.Has(pattern) {
+		return false
+	}
+	l.Pins = append(l.Pins, Pin{Pattern: pattern, Created: now.UTC()})
+	sort.Slice(l.Pins, func(i, j int) bool {
+		return l.Pins[i].Pattern < l.Pins[j].Pattern
+	})
+	return true
+}
+
+// Remove unpins pattern. It reports false if the pattern was not pinned.
+func (l *List) Remove(pattern string) bool {
+	for i, pin := range l.Pins {
+		if pin.Pattern == pattern {
+			l.Pins = append(l.Pins[:i], l.Pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Has reports whether pattern is pinned.
+func (l *List) Has(pattern string) bool {
+	for _, pin := range l.Pins {
+		if pin.Pattern == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Protects returns the reason why path must be kept, or "" if no pin
+// matches it. A pin also protects everything below the path it matches.
+func (l *List) Protects(path string) string {
+	for _, pin := range l.Pins {
+		if pathglob.MatchTree(pin.Pattern, path) {
+			return "pinned (" + pin.Pattern + ")"
+		}
+	}
+	return ""
+}
+
+// Save atomically writes the list to its file.
+func (l *List) Save() error {
+	if l.Pins == nil {
+		l.Pins = []Pin{}
+	}
+	data, err := json.MarshalIndent(file{Pins: l.Pins}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Normalize turns a path or glob given on the command line into an absolute,
+// cleaned pattern. A leading "~/" is expanded to the home directory.
+func Normalize(arg string) (string, error) {
+	if arg == "~" || strings.HasPrefix(arg, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		arg = filepath.Join(home, strings.TrimPrefix(arg, "~"))
+	}
+
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", err
+	}
+	if err := pathglob.Validate(abs); err != nil {
+		return "", err
+	}
+	return abs, nil
+}
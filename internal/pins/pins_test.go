@@ -0,0 +1,82 @@
+package pins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	l, err := Load(filepath.Join(t.TempDir(), "cccc-pins.json"))
+	require.NoError(t, err)
+	assert.Empty(t, l.Pins)
+}
+
+func TestLoad_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cccc-pins.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "corrupt pin file")
+}
+
+func TestList_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "cccc-pins.json")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	l, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, l.Add("/mnt/usb/**", now))
+	assert.True(t, l.Add("/home/u/Code/worktree", now))
+	assert.False(t, l.Add("/mnt/usb/**", now), "adding twice is a no-op")
+	require.NoError(t, l.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Pin{
+		{Pattern: "/home/u/Code/worktree", Created: now},
+		{Pattern: "/mnt/usb/**", Created: now},
+	}, loaded.Pins)
+
+	assert.True(t, loaded.Remove("/mnt/usb/**"))
+	assert.False(t, loaded.Remove("/mnt/usb/**"))
+	require.NoError(t, loaded.Save())
+
+	loaded, err = Load(path)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Pins, 1)
+	assert.True(t, loaded.Has("/home/u/Code/worktree"))
+}
+
+func TestList_Protects(t *testing.T) {
+	l := &List{}
+	l.Add("/mnt/usb/**", time.Now())
+	l.Add("/home/u/Code/worktree", time.Now())
+
+	assert.Equal(t, "pinned (/mnt/usb/**)", l.Protects("/mnt/usb/project"))
+	assert.Equal(t, "pinned (/home/u/Code/worktree)", l.Protects("/home/u/Code/worktree"))
+	assert.Equal(t, "pinned (/home/u/Code/worktree)", l.Protects("/home/u/Code/worktree/.claude/settings.local.json"))
+	assert.Empty(t, l.Protects("/home/u/Code/other"))
+}
+
+func TestNormalize(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	got, err := Normalize("~/Code/x/")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "Code", "x"), got)
+
+	got, err = Normalize("rel/*")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(wd, "rel", "*"), got)
+
+	_, err = Normalize("/mnt/[x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,292 @@
+// Package policy loads the user's policy file, ~/.config/cccc/config.toml,
+// which sets the default behavior of cccc. Command-line flags override it.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/output"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/pathglob"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/secrets"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// Confirmation modes.
+const (
+	ConfirmPrompt = "prompt"  // Ask before every change (default)
+	ConfirmYes    = "yes"     // Apply changes without asking, like --yes
+	ConfirmDryRun = "dry-run" // Only show what would change, like --dry-run
+)
+
+// CleanSubcommands lists the subcommands that a plain "cccc clean" can run.
+var CleanSubcommands = []string{"projects", "orphans", "config", "state", "sessions", "file-history"}
+
+// ListSubcommands lists the subcommands that a plain "cccc list" can run.
+var ListSubcommands = []string{"projects", "orphans", "config", "state", "file-history"}
+
+// Policy is the effective configuration of a cccc invocation.
+type Policy struct {
+	// Clean lists the subcommands run by a plain "cccc clean", in order.
+	Clean []string `toml:"clean" json:"clean"`
+	// List is the subcommand run by a plain "cccc list".
+	List string `toml:"list" json:"list"`
+	// Output is the output format: text, json or ndjson.
+	Output string `toml:"output" json:"output"`
+	// Confirm is the confirmation mode: prompt, yes or dry-run.
+	Confirm string `toml:"confirm" json:"confirm"`
+	// AuditLog is the audit log location; empty means ~/.claude/cccc-audit.log.
+	AuditLog string `toml:"audit_log,omitempty" json:"auditLog,omitempty"`
+	// ManagedSettings is the location of Claude Code's managed settings;
+	// empty means the system default, e.g. /etc/claude-code on Linux.
+	ManagedSettings string `toml:"managed_settings,omitempty" json:"managedSettings,omitempty"`
+	// Exclude lists globs of project paths that must never be touched.
+	// "**" matches any number of directories, and a pattern matching a
+	// directory also protects everything below it.
+	Exclude []string `toml:"exclude" json:"exclude"`
+
+	Retention Retention       `toml:"retention" json:"retention"`
+	Trivial   TrivialSessions `toml:"trivial_sessions" json:"trivialSessions"`
+	Secrets   Secrets         `toml:"secrets" json:"secrets"`
+}
+
+// Retention holds the default retention rules for "clean sessions" and "clean
+// state", and the age of the logs and caches that "clean orphans" removes.
+type Retention struct {
+	OlderThan      string `toml:"older_than,omitempty" json:"olderThan,omitempty"`
+	KeepLast       int    `toml:"keep_last,omitzero" json:"keepLast,omitempty"`
+	MaxProjectSize string `toml:"max_project_size,omitempty" json:"maxProjectSize,omitempty"`
+	KeepHistory    *int   `toml:"keep_history,omitempty" json:"keepHistory,omitempty"`
+	// LogsOlderThan is the age after which shell snapshots, debug logs and
+	// statsig caches are orphans; empty means 30 days.
+	LogsOlderThan string `toml:"logs_older_than,omitempty" json:"logsOlderThan,omitempty"`
+	// KeepFileVersions is the number of file-history versions kept of each
+	// file by "clean file-history"; zero keeps all.
+	KeepFileVersions int `toml:"keep_file_versions,omitzero" json:"keepFileVersions,omitempty"`
+}
+
+// TrivialSessions holds the rules by which "clean orphans" removes sessions
+// that hold nothing worth keeping. Without MaxMessages, no session is trivial.
+type TrivialSessions struct {
+	MaxMessages *int   `toml:"max_messages,omitempty" json:"maxMessages,omitempty"`
+	NoToolUse   bool   `toml:"no_tool_use,omitzero" json:"noToolUse,omitempty"`
+	ShorterThan string `toml:"shorter_than,omitempty" json:"shorterThan,omitempty"`
+}
+
+// Secrets holds the rules of "scan secrets" and "redact" in addition to the
+// built-in ones.
+type Secrets struct {
+	Rules []SecretRule `toml:"rules,omitempty" json:"rules,omitempty"`
+	// Disable lists built-in rules that are not used.
+	Disable []string `toml:"disable,omitempty" json:"disable,omitempty"`
+}
+
+// SecretRule is a custom secret rule. If its pattern has a capture group
+// named "secret", only that group is reported and redacted.
+type SecretRule struct {
+	Name    string `toml:"name" json:"name"`
+	Pattern string `toml:"pattern" json:"pattern"`
+}
+
+// Compile returns the built-in secret rules that are not disabled, followed by
+// the custom ones.
+func (s Secrets) Compile() ([]secrets.Rule, error) {
+	var custom []secrets.Rule
+	for _, r := range s.Rules {
+		rule, err := secrets.NewRule(r.Name, r.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		custom = append(custom, rule)
+	}
+	return secrets.Rules(custom, s.Disable), nil
+}
+
+// Default returns the policy used when there is no policy file.
+func Default() *Policy {
+	return &Policy{
+		Clean:   []string{"projects", "orphans", "config"},
+		List:    "projects",
+		Output:  string(output.FormatText),
+		Confirm: ConfirmPrompt,
+		Exclude: []string{},
+	}
+}
+
+// DefaultPath returns the default location of the policy file.
+func DefaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "cccc", "config.toml"), nil
+}
+
+// Load reads the policy file at path on top of the defaults.
+// Returns the default policy if the file doesn't exist.
+func Load(path string) (*Policy, error) {
+	p := Default()
+
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := toml.Decode(string(data), p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("%s: unknown key %q", path, undecoded[0].String())
+	}
+
+	if err := p.expandHome(); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// Validate checks that all values of the policy are well-formed.
+func (p *Policy) Validate() error {
+	for _, sub := range p.Clean {
+		if !contains(CleanSubcommands, sub) {
+			return fmt.Errorf("clean: unknown subcommand %q", sub)
+		}
+	}
+	if !contains(ListSubcommands, p.List) {
+		return fmt.Errorf("list: unknown subcommand %q", p.List)
+	}
+	if _, err := output.ParseFormat(p.Output); err != nil {
+		return fmt.Errorf("output: %w", err)
+	}
+	switch p.Confirm {
+	case ConfirmPrompt, ConfirmYes, ConfirmDryRun:
+	default:
+		return fmt.Errorf("confirm: unknown mode %q (expected prompt, yes or dry-run)", p.Confirm)
+	}
+	for _, pattern := range p.Exclude {
+		if err := pathglob.Validate(pattern); err != nil {
+			return fmt.Errorf("exclude: %w", err)
+		}
+	}
+
+	r := p.Retention
+	if r.OlderThan != "" {
+		if _, err := ui.ParseAge(r.OlderThan); err != nil {
+			return fmt.Errorf("retention.older_than: %w", err)
+		}
+	}
+	if r.KeepLast < 0 {
+		return errors.New("retention.keep_last: must not be negative")
+	}
+	if r.MaxProjectSize != "" {
+		if _, err := ui.ParseSize(r.MaxProjectSize); err != nil {
+			return fmt.Errorf("retention.max_project_size: %w", err)
+		}
+	}
+	if r.KeepHistory != nil && *r.KeepHistory < 0 {
+		return errors.New("retention.keep_history: must not be negative")
+	}
+	if r.KeepFileVersions < 0 {
+		return errors.New("retention.keep_file_versions: must not be negative")
+	}
+	if r.LogsOlderThan != "" {
+		if _, err := ui.ParseAge(r.LogsOlderThan); err != nil {
+			return fmt.Errorf("retention.logs_older_than: %w", err)
+		}
+	}
+	t := p.Trivial
+	if t.MaxMessages != nil && *t.MaxMessages < 0 {
+		return errors.New("trivial_sessions.max_messages: must not be negative")
+	}
+	if t.ShorterThan != "" {
+		if _, err := ui.ParseAge(t.ShorterThan); err != nil {
+			return fmt.Errorf("trivial_sessions.shorter_than: %w", err)
+		}
+	}
+	if t.MaxMessages == nil && (t.NoToolUse || t.ShorterThan != "") {
+		return errors.New("trivial_sessions: max_messages is required")
+	}
+	for _, name := range p.Secrets.Disable {
+		if !contains(secrets.BuiltinNames(), name) {
+			return fmt.Errorf("secrets.disable: unknown rule %q", name)
+		}
+	}
+	if _, err := p.Secrets.Compile(); err != nil {
+		return fmt.Errorf("secrets.rules: %w", err)
+	}
+	if contains(p.Clean, "sessions") && r.OlderThan == "" && r.MaxProjectSize == "" {
+		return errors.New("clean: \"sessions\" requires retention.older_than or retention.max_project_size")
+	}
+
+	return nil
+}
+
+// Protects returns the reason why path must be left untouched, or "" if
+// it may be cleaned.
+func (p *Policy) Protects(path string) string {
+	for _, pattern := range p.Exclude {
+		if pathglob.MatchTree(pattern, path) {
+			return "excluded by policy (" + pattern + ")"
+		}
+	}
+	return ""
+}
+
+// Encode writes the policy as TOML.
+func (p *Policy) Encode(w io.Writer) error {
+	enc := toml.NewEncoder(w)
+	enc.Indent = ""
+	return enc.Encode(p)
+}
+
+// expandHome replaces a leading "~/" in paths with the user's home directory.
+func (p *Policy) expandHome() error {
+	if p.AuditLog == "" && p.ManagedSettings == "" && len(p.Exclude) == 0 {
+		return nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	expand := func(s string) string {
+		if s == "~" {
+			return home
+		}
+		if strings.HasPrefix(s, "~/") {
+			return filepath.Join(home, s[2:])
+		}
+		return s
+	}
+
+	p.AuditLog = expand(p.AuditLog)
+	p.ManagedSettings = expand(p.ManagedSettings)
+	for i, pattern := range p.Exclude {
+		p.Exclude[i] = expand(pattern)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
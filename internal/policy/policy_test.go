@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad_Missing(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), "config.toml"))
+	require.NoError(t, err)
+	assert.Equal(t, Default(), p)
+}
+
+func TestLoad(t *testing.T) {
+	path := writePolicy(t, `
+clean = ["projects", "sessions"]
+list = "orphans"
+output = "ndjson"
+confirm = "dry-run"
+audit_log = "/var/log/cccc.log"
+exclude = ["/work/**"]
+
+[retention]
+older_than = "90d"
+keep_last = 20
+max_project_size = "200MB"
+keep_history = 50
+
+[trivial_sessions]
+max_messages = 1
+no_tool_use = true
+shorter_than = "60s"
+`)
+
+	p, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"projects", "sessions"}, p.Clean)
+	assert.Equal(t, "orphans", p.List)
+	assert.Equal(t, "ndjson", p.Output)
+	assert.Equal(t, ConfirmDryRun, p.Confirm)
+	assert.Equal(t, "/var/log/cccc.log", p.AuditLog)
+	assert.Equal(t, []string{"/work/**"}, p.Exclude)
+	assert.Equal(t, "90d", p.Retention.OlderThan)
+	assert.Equal(t, 20, p.Retention.KeepLast)
+	assert.Equal(t, "200MB", p.Retention.MaxProjectSize)
+	require.NotNil(t, p.Retention.KeepHistory)
+	assert.Equal(t, 50, *p.Retention.KeepHistory)
+	require.NotNil(t, p.Trivial.MaxMessages)
+	assert.Equal(t, 1, *p.Trivial.MaxMessages)
+	assert.True(t, p.Trivial.NoToolUse)
+	assert.Equal(t, "60s", p.Trivial.ShorterThan)
+}
+
+func TestLoad_KeepsDefaultsForMissingKeys(t *testing.T) {
+	p, err := Load(writePolicy(t, `output = "json"`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "json", p.Output)
+	assert.Equal(t, Default().Clean, p.Clean)
+	assert.Equal(t, ConfirmPrompt, p.Confirm)
+}
+
+func TestLoad_ExpandsHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	p, err := Load(writePolicy(t, `
+audit_log = "~/logs/cccc.log"
+managed_settings = "~/managed-settings.json"
+exclude = ["~/Code/keep"]
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(home, "logs", "cccc.log"), p.AuditLog)
+	assert.Equal(t, filepath.Join(home, "managed-settings.json"), p.ManagedSettings)
+	assert.Equal(t, []string{filepath.Join(home, "Code", "keep")}, p.Exclude)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"syntax":               `clean = [`,
+		"unknown key":          `colour = "red"`,
+		"unknown subcommand":   `clean = ["everything"]`,
+		"unknown list":         `list = "trash"`,
+		"unknown output":       `output = "xml"`,
+		"unknown confirm":      `confirm = "maybe"`,
+		"bad glob":             `exclude = ["/work/[x"]`,
+		"bad age":              "[retention]\nolder_than = \"soon\"",
+		"bad size":             "[retention]\nmax_project_size = \"big\"",
+		"bad logs age":         "[retention]\nlogs_older_than = \"later\"",
+		"negative keep_last":   "[retention]\nkeep_last = -1",
+		"negative versions":    "[retention]\nkeep_file_versions = -1",
+		"bad trivial duration": "[trivial_sessions]\nmax_messages = 1\nshorter_than = \"quick\"",
+		"negative messages":    "[trivial_sessions]\nmax_messages = -1",
+		"trivial needs max":    "[trivial_sessions]\nno_tool_use = true",
+		"sessions needs rules": `clean = ["sessions"]`,
+		"bad secret pattern":   "[[secrets.rules]]\nname = \"x\"\npattern = \"(\"",
+		"unnamed secret rule":  "[[secrets.rules]]\npattern = \"x\"",
+		"unknown secret rule":  "[secrets]\ndisable = [\"aws\"]",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writePolicy(t, content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPolicy_Protects(t *testing.T) {
+	p := Default()
+	p.Exclude = []string{"/work/**/secret", "/home/u/Code/keep", "/tmp/*-important"}
+
+	tests := []struct {
+		path      string
+		protected bool
+	}{
+		{"/home/u/Code/keep", true},
+		{"/home/u/Code/keep/sub/dir", true},
+		{"/home/u/Code/keeper", false},
+		{"/work/secret", true},
+		{"/work/a/b/secret/src", true},
+		{"/work/a/public", false},
+		{"/tmp/very-important", true},
+		{"/tmp/other", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		reason := p.Protects(tt.path)
+		assert.Equal(t, tt.protected, reason != "", tt.path)
+	}
+
+	assert.Equal(t, "excluded by policy (/home/u/Code/keep)", p.Protects("/home/u/Code/keep"))
+}
+
+func TestPolicy_EncodeRoundTrips(t *testing.T) {
+	keep := 5
+	p := Default()
+	p.Exclude = []string{"/work/**"}
+	p.Retention = Retention{OlderThan: "30d", KeepLast: 2, KeepHistory: &keep}
+	p.Secrets = Secrets{Rules: []SecretRule{{Name: "ticket", Pattern: `TICKET-\d+`}}, Disable: []string{"high-entropy"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Encode(&buf))
+
+	loaded, err := Load(writePolicy(t, buf.String()))
+	require.NoError(t, err)
+	assert.Equal(t, p, loaded)
+}
+
+func TestSecrets_Compile(t *testing.T) {
+	p, err := Load(writePolicy(t, "[secrets]\ndisable = [\"high-entropy\"]\n\n[[secrets.rules]]\nname = \"ticket\"\npattern = 'TICKET-(?P<secret>\\d+)'\n"))
+	require.NoError(t, err)
+
+	rules, err := p.Secrets.Compile()
+	require.NoError(t, err)
+	var names []string
+	for _, r := range rules {
+		names = append(names, r.Name)
+	}
+	assert.NotContains(t, names, "high-entropy")
+	assert.Equal(t, "ticket", names[len(names)-1])
+}
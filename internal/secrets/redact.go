@@ -0,0 +1,174 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+)
+
+// Hit is a secret found in a file.
+type Hit struct {
+	Line   int // 1-based line number
+	Rule   string
+	Masked string // The secret, shortened with Mask
+}
+
+// ScanFile returns the secrets in the file at path. If jsonl is true, the
+// file is read as JSON Lines, see Redact.
+func ScanFile(path string, rules []Rule, jsonl bool) ([]Hit, error) {
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304 -- path is sanitized with filepath.Clean
+	if err != nil {
+		return nil, err
+	}
+	_, hits := Redact(data, rules, jsonl)
+	return hits, nil
+}
+
+// RedactFile replaces the secrets in the file at path and atomically
+// replaces the file, returning the secrets that were replaced.
+func RedactFile(path string, rules []Rule, jsonl bool) ([]Hit, error) {
+	var hits []Hit
+	_, err := claude.RewriteFile(path, func(original []byte) ([]byte, error) {
+		var data []byte
+		data, hits = Redact(original, rules, jsonl)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// Redact returns data with every secret replaced by "[REDACTED:<rule>]",
+// along with the secrets found. If jsonl is true, each line that is valid
+// JSON has the secrets in its string values replaced, and only the strings
+// that changed are encoded again, so that the line stays valid JSON and is
+// otherwise unchanged. Other lines, and data that is not JSON Lines, are
+// handled as plain text, in which private keys may span several lines.
+func Redact(data []byte, rules []Rule, jsonl bool) ([]byte, []Hit) {
+	if !jsonl {
+		return redactText(data, rules, 1)
+	}
+
+	var out []byte
+	var hits []Hit
+	for n, line := range bytes.SplitAfter(data, []byte("\n")) {
+		var lineHits []Hit
+		if json.Valid(line) {
+			line, lineHits = redactJSON(line, rules, n+1)
+		} else {
+			line, lineHits = redactText(line, rules, n+1)
+		}
+		out = append(out, line...)
+		hits = append(hits, lineHits...)
+	}
+	return out, hits
+}
+
+// redactText redacts plain text that starts on line first.
+func redactText(data []byte, rules []Rule, first int) ([]byte, []Hit) {
+	text := string(data)
+	matches := find(rules, text)
+	if len(matches) == 0 {
+		return data, nil
+	}
+
+	var out strings.Builder
+	var hits []Hit
+	last, line := 0, first
+	for _, m := range matches {
+		line += strings.Count(text[last:m.start], "\n")
+		hits = append(hits, Hit{Line: line, Rule: m.rule, Masked: Mask(text[m.start:m.end])})
+		out.WriteString(text[last:m.start])
+		out.WriteString(replacement(m.rule))
+		line += strings.Count(text[m.start:m.end], "\n")
+		last = m.end
+	}
+	out.WriteString(text[last:])
+	return []byte(out.String()), hits
+}
+
+// structuralKeys lists the keys whose string values are identifiers, paths,
+// signatures or binary data written by Claude Code rather than text. They are
+// neither reported nor redacted, as changing them could break the session.
+var structuralKeys = map[string]bool{
+	"id": true, "uuid": true, "parentUuid": true, "leafUuid": true,
+	"sessionId": true, "requestId": true, "tool_use_id": true,
+	"type": true, "role": true, "model": true, "version": true,
+	"cwd": true, "gitBranch": true, "timestamp": true,
+	"signature": true, "data": true,
+}
+
+// redactJSON redacts the string values of a line holding a valid JSON value,
+// except those of structural keys. Keys are left alone.
+func redactJSON(line []byte, rules []Rule, n int) ([]byte, []Hit) {
+	var out []byte
+	var hits []Hit
+	last := 0
+	key := ""
+	for i := 0; i < len(line); i++ {
+		if line[i] != '"' {
+			continue
+		}
+		end := stringEnd(line, i)
+		var s string
+		if err := json.Unmarshal(line[i:end], &s); err != nil {
+			i = end - 1
+			continue
+		}
+		if rest := bytes.TrimLeft(line[end:], " \t\r\n"); len(rest) > 0 && rest[0] == ':' {
+			key = s
+			i = end - 1
+			continue
+		}
+		if matches := find(rules, s); len(matches) > 0 && !structuralKeys[key] {
+			var redacted strings.Builder
+			prev := 0
+			for _, m := range matches {
+				hits = append(hits, Hit{Line: n, Rule: m.rule, Masked: Mask(s[m.start:m.end])})
+				redacted.WriteString(s[prev:m.start])
+				redacted.WriteString(replacement(m.rule))
+				prev = m.end
+			}
+			redacted.WriteString(s[prev:])
+
+			out = append(out, line[last:i]...)
+			out = append(out, encodeString(redacted.String())...)
+			last = end
+		}
+		i = end - 1
+	}
+	if out == nil {
+		return line, nil
+	}
+	return append(out, line[last:]...), hits
+}
+
+// stringEnd returns the offset just past the closing quote of the JSON
+// string that starts at line[start], or the length of line if it is not
+// closed.
+func stringEnd(line []byte, start int) int {
+	for j := start + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(line)
+}
+
+// encodeString encodes s as a JSON string, leaving <, > and & as they are.
+func encodeString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
@@ -0,0 +1,163 @@
+// Package secrets finds credentials such as API keys, tokens and private
+// keys in session transcripts and file-history snapshots, and redacts them.
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// secretGroup is the name of the capture group that holds the secret in
+// patterns that also match its surroundings, such as the name of a variable.
+const secretGroup = "secret"
+
+// Rule detects one kind of secret.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// MinEntropy, if non-zero, is the Shannon entropy in bits per character
+	// that a match must reach. Such matches must also mix digits, lower and
+	// upper case letters, which rules out words and hex hashes, and must not
+	// start with a slash or contain a dot, which rules out paths and names.
+	MinEntropy float64
+}
+
+// NewRule compiles a rule. If pattern has a capture group named "secret",
+// only that group is reported and redacted.
+func NewRule(name, pattern string) (Rule, error) {
+	if name == "" {
+		return Rule{}, fmt.Errorf("rule without a name")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule %s: %w", name, err)
+	}
+	return Rule{Name: name, Pattern: re}, nil
+}
+
+// Builtin returns the built-in rules, most specific first.
+func Builtin() []Rule {
+	return []Rule{
+		{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN[ A-Z0-9]*PRIVATE KEY-----[\s\S]*?(?:-----END[ A-Z0-9]*PRIVATE KEY-----|$)`)},
+		{Name: "aws-access-key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+		{Name: "aws-secret-key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+]{40})\b`)},
+		{Name: "github-token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+		{Name: "anthropic-api-key", Pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+		{Name: "slack-token", Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+		{Name: "env-secret", Pattern: regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?[A-Za-z0-9_]*(?i:secret|token|password|passwd|api_?key|private_?key)[A-Za-z0-9_]*[ \t]*=[ \t]*["']?(?P<secret>[^\s"'#]{8,})`)},
+		{Name: "high-entropy", Pattern: regexp.MustCompile(`[A-Za-z0-9+/_=.-]{32,}`), MinEntropy: 4.5},
+	}
+}
+
+// BuiltinNames returns the names of the built-in rules.
+func BuiltinNames() []string {
+	var names []string
+	for _, r := range Builtin() {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// Rules returns the built-in rules except those named in disable, followed
+// by custom.
+func Rules(custom []Rule, disable []string) []Rule {
+	var rules []Rule
+	for _, r := range Builtin() {
+		if !slices.Contains(disable, r.Name) {
+			rules = append(rules, r)
+		}
+	}
+	return append(rules, custom...)
+}
+
+// match is a secret found in a text, at text[start:end].
+type match struct {
+	rule       string
+	start, end int
+}
+
+// find returns the secrets in text, ordered by position. Where matches of
+// several rules overlap, the one that starts first wins, and for equal
+// starts the rule that comes first.
+func find(rules []Rule, text string) []match {
+	var all []match
+	for i, r := range rules {
+		group := r.Pattern.SubexpIndex(secretGroup)
+		for _, loc := range r.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if group > 0 {
+				start, end = loc[2*group], loc[2*group+1]
+				if start < 0 {
+					continue
+				}
+			}
+			if start == end || strings.HasPrefix(text[start:], redactedPrefix) || (r.MinEntropy > 0 && !random(text[start:end], r.MinEntropy)) {
+				continue
+			}
+			all = append(all, match{rule: rules[i].Name, start: start, end: end})
+		}
+	}
+
+	// The sort is stable, so matches that start together stay in rule order
+	slices.SortStableFunc(all, func(a, b match) int { return a.start - b.start })
+	var found []match
+	for _, m := range all {
+		if len(found) > 0 && m.start < found[len(found)-1].end {
+			continue
+		}
+		found = append(found, m)
+	}
+	return found
+}
+
+// random reports whether s looks like a randomly generated credential.
+func random(s string, minEntropy float64) bool {
+	if strings.HasPrefix(s, "/") || strings.Contains(s, ".") {
+		return false
+	}
+	var digit, lower, upper bool
+	for _, r := range s {
+		digit = digit || unicode.IsDigit(r)
+		lower = lower || unicode.IsLower(r)
+		upper = upper || unicode.IsUpper(r)
+	}
+	return digit && lower && upper && entropy(s) >= minEntropy
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// Mask shortens a secret for display, keeping only its first characters.
+func Mask(secret string) string {
+	runes := []rune(secret)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + strings.Repeat("*", 8)
+}
+
+// redactedPrefix starts the text that replaces a secret. Matches that start
+// with it are skipped, so that redacting again changes nothing.
+const redactedPrefix = "[REDACTED:"
+
+// replacement returns the text a secret found by rule is replaced with.
+func replacement(rule string) string {
+	return redactedPrefix + rule + "]"
+}
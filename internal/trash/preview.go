@@ -0,0 +1,62 @@
+package trash
+
+import (
+	"fmt"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
+)
+
+// BuildRestorePreview creates a preview of the items of a run to be restored.
+// If ids is empty, all pending items are included.
+func BuildRestorePreview(m *Manifest, ids []int) *ui.Preview {
+	preview := &ui.Preview{
+		Title: "Restore " + m.RunID,
+	}
+
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	for _, item := range m.Pending() {
+		if len(ids) > 0 && !selected[item.ID] {
+			continue
+		}
+
+		description := fmt.Sprintf("#%d, removed by %s", item.ID, m.Command)
+		switch item.Kind {
+		case KindModified:
+			description = fmt.Sprintf("#%d, original before %s (overwrites current file)", item.ID, m.Command)
+		case KindCreated:
+			description = fmt.Sprintf("#%d, created by %s (removes current file)", item.ID, m.Command)
+		}
+
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      ui.ActionRestore,
+			Path:        item.Path,
+			Description: description,
+			Size:        item.Size,
+		})
+	}
+
+	return preview
+}
+
+// BuildPurgePreview creates a preview of trash runs to be permanently deleted.
+func BuildPurgePreview(store *Store, manifests []Manifest) *ui.Preview {
+	preview := &ui.Preview{
+		Title: "Trash Purge",
+	}
+
+	for _, m := range manifests {
+		dir, _ := store.runDir(m.RunID)
+		preview.Changes = append(preview.Changes, ui.Change{
+			Action:      ui.ActionDelete,
+			Path:        dir,
+			Description: fmt.Sprintf("%s, %d items, created: %s", m.Command, len(m.Pending()), m.Created.Local().Format("2006-01-02 15:04")),
+			Size:        m.TotalSize(),
+		})
+	}
+
+	return preview
+}
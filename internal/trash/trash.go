@@ -0,0 +1,448 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ItemKind records how a quarantined item relates to its original path.
+type ItemKind string
+
+const (
+	// KindRemoved means the original was moved into the trash.
+	KindRemoved ItemKind = "removed"
+	// KindModified means a copy was taken before the original was rewritten in place.
+	KindModified ItemKind = "modified"
+	// KindCreated means the run created the path, so nothing is stored and
+	// restoring removes it.
+	KindCreated ItemKind = "created"
+)
+
+// manifestName is the file name of the manifest inside a run directory.
+const manifestName = "manifest.json"
+
+// Item is a single file or directory held in a quarantine run.
+type Item struct {
+	ID       int       `json:"id"`
+	Kind     ItemKind  `json:"kind"`
+	Path     string    `json:"path"`   // Original location
+	Stored   string    `json:"stored"` // Location relative to the run directory
+	Size     int64     `json:"size"`
+	IsDir    bool      `json:"isDir"`
+	Time     time.Time `json:"time"`
+	Restored bool      `json:"restored,omitempty"`
+}
+
+// Manifest describes the contents of a quarantine run.
+type Manifest struct {
+	RunID   string    `json:"runId"`
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+	Items   []Item    `json:"items"`
+}
+
+// TotalSize returns the total size of all items still held in the run.
+func (m *Manifest) TotalSize() int64 {
+	var total int64
+	for _, item := range m.Items {
+		if !item.Restored {
+			total += item.Size
+		}
+	}
+	return total
+}
+
+// Pending returns the items that have not been restored yet.
+func (m *Manifest) Pending() []Item {
+	var pending []Item
+	for _, item := range m.Items {
+		if !item.Restored {
+			pending = append(pending, item)
+		}
+	}
+	return pending
+}
+
+// ErrRunNotFound is returned when a run ID does not exist in the store.
+var ErrRunNotFound = errors.New("trash run not found")
+
+// Store manages quarantine runs below a single directory.
+type Store struct {
+	Dir string
+	now func() time.Time
+}
+
+// NewStore returns a store rooted at dir. The directory is created on first use.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir, now: time.Now}
+}
+
+// DefaultDir returns the default trash directory for a given Claude home directory.
+func DefaultDir(claudeHome string) string {
+	return filepath.Join(claudeHome, "cccc-trash")
+}
+
+// Begin starts a new run for the given command. Nothing is written to disk
+// until the first item is quarantined, so a run that ends up empty leaves no trace.
+func (s *Store) Begin(command string) *Run {
+	return &Run{
+		store: s,
+		manifest: Manifest{
+			Command: command,
+		},
+	}
+}
+
+// List returns the manifests of all runs, oldest first.
+func (s *Store) List() ([]Manifest, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []Manifest
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		m, err := s.Load(entry.Name())
+		if err != nil {
+			continue
+		}
+		manifests = append(manifests, *m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Created.Before(manifests[j].Created)
+	})
+
+	return manifests, nil
+}
+
+// Load reads the manifest of a single run.
+func (s *Store) Load(runID string) (*Manifest, error) {
+	dir, err := s.runDir(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestName)) // #nosec G304 -- run ID is validated by runDir
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrRunNotFound, runID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("corrupt manifest for run %s: %w", runID, err)
+	}
+
+	return &m, nil
+}
+
+// Restore puts the selected items of a run back in their original locations.
+// If ids is empty, all pending items are restored. Removed items are never
+// restored over an existing path; modified items overwrite the current file;
+// created items are removed.
+// Restoring stops at the first error and returns the items restored so far.
+func (s *Store) Restore(runID string, ids []int) ([]Item, error) {
+	m, err := s.Load(runID)
+	if err != nil {
+		return nil, err
+	}
+	dir, _ := s.runDir(runID)
+
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	for _, id := range ids {
+		if !hasItem(m, id) {
+			return nil, fmt.Errorf("run %s has no item %d", runID, id)
+		}
+	}
+
+	var restored []Item
+	for i := range m.Items {
+		item := &m.Items[i]
+		if item.Restored || (len(ids) > 0 && !selected[item.ID]) {
+			continue
+		}
+
+		stored := filepath.Join(dir, item.Stored)
+		if err := restoreItem(item, stored); err != nil {
+			_ = writeManifest(dir, m)
+			return restored, fmt.Errorf("failed to restore %s: %w", item.Path, err)
+		}
+
+		item.Restored = true
+		restored = append(restored, *item)
+	}
+
+	if err := writeManifest(dir, m); err != nil {
+		return restored, err
+	}
+
+	// Drop the run entirely once nothing is left in it
+	if len(m.Pending()) == 0 {
+		if err := os.RemoveAll(dir); err != nil {
+			return restored, err
+		}
+	}
+
+	return restored, nil
+}
+
+// Purge permanently deletes runs created before the given time and returns
+// their manifests.
+func (s *Store) Purge(before time.Time) ([]Manifest, error) {
+	manifests, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []Manifest
+	for _, m := range manifests {
+		if !m.Created.Before(before) {
+			continue
+		}
+		dir, err := s.runDir(m.RunID)
+		if err != nil {
+			return purged, err
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return purged, err
+		}
+		purged = append(purged, m)
+	}
+
+	return purged, nil
+}
+
+// runDir returns the directory of a run, rejecting IDs that would escape the store.
+func (s *Store) runDir(runID string) (string, error) {
+	if runID == "" || runID == "." || runID == ".." || strings.ContainsAny(runID, `/\`) {
+		return "", fmt.Errorf("invalid run ID: %q", runID)
+	}
+	return filepath.Join(s.Dir, runID), nil
+}
+
+// newRunID allocates a unique, timestamp-based run ID.
+func (s *Store) newRunID(created time.Time) (string, error) {
+	base := created.UTC().Format("20060102T150405Z")
+	id := base
+	for n := 2; ; n++ {
+		_, err := os.Stat(filepath.Join(s.Dir, id))
+		if os.IsNotExist(err) {
+			return id, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		id = base + "-" + strconv.Itoa(n)
+	}
+}
+
+// Run collects the items quarantined by a single invocation of the tool.
+type Run struct {
+	store    *Store
+	dir      string
+	manifest Manifest
+}
+
+// ID returns the run ID, or "" if nothing has been quarantined yet.
+func (r *Run) ID() string {
+	return r.manifest.RunID
+}
+
+// Items returns the items quarantined so far.
+func (r *Run) Items() []Item {
+	return r.manifest.Items
+}
+
+// Manifest returns a copy of the run's manifest.
+func (r *Run) Manifest() Manifest {
+	m := r.manifest
+	m.Items = append([]Item(nil), r.manifest.Items...)
+	return m
+}
+
+// Remove moves path into the trash.
+func (r *Run) Remove(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	item, stored, err := r.add(KindRemoved, path, info)
+	if err != nil {
+		return err
+	}
+
+	if err := move(path, stored); err != nil {
+		return err
+	}
+
+	return r.commit(item)
+}
+
+// Preserve stores a copy of path so that a subsequent in-place rewrite can be undone.
+func (r *Run) Preserve(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	item, stored, err := r.add(KindModified, path, info)
+	if err != nil {
+		return err
+	}
+
+	if err := copyTree(path, stored); err != nil {
+		return err
+	}
+
+	return r.commit(item)
+}
+
+// Created records that path did not exist before the run created it, so that
+// restoring the run removes it.
+func (r *Run) Created(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	item, _, err := r.add(KindCreated, path, info)
+	if err != nil {
+		return err
+	}
+
+	return r.commit(item)
+}
+
+// add allocates an item and its storage location, creating the run directory
+// on first use.
+func (r *Run) add(kind ItemKind, path string, info os.FileInfo) (Item, string, error) {
+	if r.dir == "" {
+		now := r.store.now()
+		id, err := r.store.newRunID(now)
+		if err != nil {
+			return Item{}, "", err
+		}
+		dir := filepath.Join(r.store.Dir, id)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return Item{}, "", err
+		}
+		r.dir = dir
+		r.manifest.RunID = id
+		r.manifest.Created = now.UTC()
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, "", err
+	}
+
+	size, err := treeSize(path)
+	if err != nil {
+		return Item{}, "", err
+	}
+
+	id := len(r.manifest.Items) + 1
+	item := Item{
+		ID:     id,
+		Kind:   kind,
+		Path:   abs,
+		Stored: filepath.Join("items", strconv.Itoa(id), filepath.Base(abs)),
+		Size:   size,
+		IsDir:  info.IsDir(),
+		Time:   r.store.now().UTC(),
+	}
+
+	// The trash holds nothing of a created item
+	if kind == KindCreated {
+		item.Stored, item.Size = "", 0
+		return item, "", nil
+	}
+
+	stored := filepath.Join(r.dir, item.Stored)
+	if err := os.MkdirAll(filepath.Dir(stored), 0700); err != nil {
+		return Item{}, "", err
+	}
+
+	return item, stored, nil
+}
+
+// commit records an item in the manifest once its data is safely stored.
+func (r *Run) commit(item Item) error {
+	r.manifest.Items = append(r.manifest.Items, item)
+	return writeManifest(r.dir, &r.manifest)
+}
+
+// restoreItem moves or copies a stored item back to its original location.
+func restoreItem(item *Item, stored string) error {
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0700); err != nil {
+		return err
+	}
+
+	switch item.Kind {
+	case KindCreated:
+		err := os.Remove(item.Path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	case KindModified:
+		tmp := item.Path + ".cccc-restore"
+		if err := copyTree(stored, tmp); err != nil {
+			_ = os.RemoveAll(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, item.Path); err != nil {
+			_ = os.RemoveAll(tmp)
+			return err
+		}
+		return os.RemoveAll(stored)
+	default:
+		if _, err := os.Lstat(item.Path); err == nil {
+			return fmt.Errorf("%s already exists", item.Path)
+		}
+		return move(stored, item.Path)
+	}
+}
+
+// writeManifest atomically writes the manifest of a run.
+func writeManifest(dir string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, manifestName+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, manifestName))
+}
+
+func hasItem(m *Manifest, id int) bool {
+	for _, item := range m.Items {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
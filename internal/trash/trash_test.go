@@ -0,0 +1,256 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, now time.Time) *Store {
+	store := NewStore(filepath.Join(t.TempDir(), "cccc-trash"))
+	store.now = func() time.Time { return now }
+	return store
+}
+
+func TestDefaultDir(t *testing.T) {
+	assert.Equal(t, "/home/user/.claude/cccc-trash", DefaultDir("/home/user/.claude"))
+}
+
+func TestRun_EmptyRunLeavesNoTrace(t *testing.T) {
+	store := newTestStore(t, time.Now())
+
+	run := store.Begin("clean projects")
+
+	assert.Equal(t, "", run.ID())
+	assert.NoDirExists(t, store.Dir)
+}
+
+func TestRun_RemoveMovesIntoTrash(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Date(2025, 12, 6, 16, 0, 0, 0, time.UTC))
+
+	dir := filepath.Join(tmpDir, "project")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jsonl"), []byte("12345"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.json"), []byte("123"), 0644))
+
+	run := store.Begin("clean projects")
+	require.NoError(t, run.Remove(dir))
+
+	assert.NoDirExists(t, dir)
+	assert.Equal(t, "20251206T160000Z", run.ID())
+	require.Len(t, run.Items(), 1)
+	assert.Equal(t, KindRemoved, run.Items()[0].Kind)
+	assert.Equal(t, int64(8), run.Items()[0].Size)
+	assert.True(t, run.Items()[0].IsDir)
+
+	m, err := store.Load(run.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "clean projects", m.Command)
+	assert.Len(t, m.Items, 1)
+	assert.FileExists(t, filepath.Join(store.Dir, run.ID(), m.Items[0].Stored, "a.jsonl"))
+}
+
+func TestRun_UniqueRunIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Date(2025, 12, 6, 16, 0, 0, 0, time.UTC))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(tmpDir, "file"+string(rune('a'+i)))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+
+		run := store.Begin("clean orphans")
+		require.NoError(t, run.Remove(path))
+		ids = append(ids, run.ID())
+	}
+
+	assert.Equal(t, []string{"20251206T160000Z", "20251206T160000Z-2", "20251206T160000Z-3"}, ids)
+}
+
+func TestStore_RestoreRemoved(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Now())
+
+	file := filepath.Join(tmpDir, "todo.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"todos":[]}`), 0640))
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(file, mtime, mtime))
+
+	run := store.Begin("clean orphans")
+	require.NoError(t, run.Remove(file))
+	assert.NoFileExists(t, file)
+
+	restored, err := store.Restore(run.ID(), nil)
+	require.NoError(t, err)
+	assert.Len(t, restored, 1)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, `{"todos":[]}`, string(data))
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	assert.True(t, info.ModTime().Equal(mtime))
+
+	// A fully restored run is removed from the store
+	_, err = store.Load(run.ID())
+	assert.ErrorIs(t, err, ErrRunNotFound)
+}
+
+func TestStore_RestoreModified(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Now())
+
+	file := filepath.Join(tmpDir, "settings.local.json")
+	require.NoError(t, os.WriteFile(file, []byte("original"), 0600))
+
+	run := store.Begin("clean config")
+	require.NoError(t, run.Preserve(file))
+	require.NoError(t, os.WriteFile(file, []byte("rewritten"), 0600))
+
+	_, err := store.Restore(run.ID(), nil)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(data))
+}
+
+func TestStore_RestoreCreated(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Now())
+
+	file := filepath.Join(tmpDir, "settings.json")
+	run := store.Begin("config promote")
+	require.NoError(t, os.WriteFile(file, []byte("{}"), 0600))
+	require.NoError(t, run.Created(file))
+
+	m, err := store.Load(run.ID())
+	require.NoError(t, err)
+	require.Len(t, m.Items, 1)
+	assert.Equal(t, KindCreated, m.Items[0].Kind)
+	assert.Zero(t, m.TotalSize())
+	assert.Contains(t, BuildRestorePreview(m, nil).Changes[0].Description, "created by config promote")
+
+	_, err = store.Restore(run.ID(), nil)
+	require.NoError(t, err)
+	assert.NoFileExists(t, file)
+}
+
+func TestStore_RestoreSelectedItems(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Now())
+
+	a := filepath.Join(tmpDir, "a")
+	b := filepath.Join(tmpDir, "b")
+	require.NoError(t, os.WriteFile(a, []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(b, []byte("b"), 0644))
+
+	run := store.Begin("clean orphans")
+	require.NoError(t, run.Remove(a))
+	require.NoError(t, run.Remove(b))
+
+	restored, err := store.Restore(run.ID(), []int{2})
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+	assert.Equal(t, b, restored[0].Path)
+
+	assert.NoFileExists(t, a)
+	assert.FileExists(t, b)
+
+	// The run keeps the remaining item
+	m, err := store.Load(run.ID())
+	require.NoError(t, err)
+	assert.Len(t, m.Pending(), 1)
+	assert.Equal(t, a, m.Pending()[0].Path)
+}
+
+func TestStore_RestoreRefusesToOverwrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Now())
+
+	file := filepath.Join(tmpDir, "a")
+	require.NoError(t, os.WriteFile(file, []byte("old"), 0644))
+
+	run := store.Begin("clean orphans")
+	require.NoError(t, run.Remove(file))
+	require.NoError(t, os.WriteFile(file, []byte("new"), 0644))
+
+	_, err := store.Restore(run.ID(), nil)
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+}
+
+func TestStore_RestoreUnknownItem(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := newTestStore(t, time.Now())
+
+	file := filepath.Join(tmpDir, "a")
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0644))
+
+	run := store.Begin("clean orphans")
+	require.NoError(t, run.Remove(file))
+
+	_, err := store.Restore(run.ID(), []int{7})
+	assert.Error(t, err)
+	assert.NoFileExists(t, file)
+}
+
+func TestStore_RejectsInvalidRunID(t *testing.T) {
+	store := newTestStore(t, time.Now())
+
+	for _, id := range []string{"", "..", "../etc", "a/b"} {
+		_, err := store.Load(id)
+		assert.Error(t, err, id)
+	}
+}
+
+func TestStore_ListAndPurge(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Date(2025, 12, 6, 16, 0, 0, 0, time.UTC)
+	store := newTestStore(t, now.Add(-40*24*time.Hour))
+
+	old := filepath.Join(tmpDir, "old")
+	require.NoError(t, os.WriteFile(old, []byte("old"), 0644))
+	oldRun := store.Begin("clean orphans")
+	require.NoError(t, oldRun.Remove(old))
+
+	store.now = func() time.Time { return now }
+	recent := filepath.Join(tmpDir, "recent")
+	require.NoError(t, os.WriteFile(recent, []byte("recent"), 0644))
+	recentRun := store.Begin("clean projects")
+	require.NoError(t, recentRun.Remove(recent))
+
+	manifests, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	assert.Equal(t, oldRun.ID(), manifests[0].RunID)
+	assert.Equal(t, recentRun.ID(), manifests[1].RunID)
+
+	purged, err := store.Purge(now.Add(-30 * 24 * time.Hour))
+	require.NoError(t, err)
+	require.Len(t, purged, 1)
+	assert.Equal(t, oldRun.ID(), purged[0].RunID)
+
+	manifests, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, recentRun.ID(), manifests[0].RunID)
+}
+
+func TestStore_ListEmpty(t *testing.T) {
+	store := newTestStore(t, time.Now())
+
+	manifests, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, manifests)
+}
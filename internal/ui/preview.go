@@ -9,9 +9,10 @@ import (
 type Action string
 
 const (
-	ActionDelete Action = "DELETE"
-	ActionModify Action = "MODIFY"
-	ActionCreate Action = "CREATE"
+	ActionDelete  Action = "DELETE"
+	ActionModify  Action = "MODIFY"
+	ActionCreate  Action = "CREATE"
+	ActionRestore Action = "RESTORE"
 )
 
 // Change represents a single change to be made.
@@ -88,3 +89,18 @@ func FormatSize(bytes int64) string {
 		return fmt.Sprintf("%d B", bytes)
 	}
 }
+
+// FormatCount formats a count such as a number of tokens as a short
+// human-readable string (e.g., "1.2M").
+func FormatCount(n int64) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.1fG", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
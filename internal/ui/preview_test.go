@@ -131,3 +131,11 @@ func TestFormatSize_Zero(t *testing.T) {
 
 	assert.Equal(t, "0 B", result)
 }
+
+func TestFormatCount(t *testing.T) {
+	assert.Equal(t, "0", FormatCount(0))
+	assert.Equal(t, "999", FormatCount(999))
+	assert.Equal(t, "1.2k", FormatCount(1234))
+	assert.Equal(t, "3.5M", FormatCount(3_450_000))
+	assert.Equal(t, "2.0G", FormatCount(2_000_000_000))
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAge(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"0d", 0},
+		{"1.5d", 36 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"90m", 90 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		d, err := ParseAge(tc.input)
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, d, tc.input)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2025, 12, 10, 12, 0, 0, 0, time.UTC)
+
+	start, err := ParseTime("2025-12-02", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 2, 0, 0, 0, 0, time.Local), start)
+
+	end, err := ParseTime("2025-12-02", now, true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 3, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond), end)
+
+	exact, err := ParseTime("2025-12-02T10:00:00Z", now, true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 2, 10, 0, 0, 0, time.UTC), exact)
+
+	ago, err := ParseTime("7d", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -7), ago)
+
+	_, err = ParseTime("last tuesday", now, false)
+	assert.ErrorContains(t, err, "invalid time")
+}
+
+func TestParseAge_Invalid(t *testing.T) {
+	for _, input := range []string{"", "d", "abc", "-3d", "10x", "-1h"} {
+		_, err := ParseAge(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"4096", 4096},
+		{"100B", 100},
+		{"512K", 512 * 1024},
+		{"2KB", 2048},
+		{"200MB", 200 * 1024 * 1024},
+		{"200mb", 200 * 1024 * 1024},
+		{"1.5 GB", 3 * 512 * 1024 * 1024},
+		{"1G", 1024 * 1024 * 1024},
+	}
+
+	for _, tc := range testCases {
+		n, err := ParseSize(tc.input)
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, n, tc.input)
+	}
+}
+
+func TestParseSize_Invalid(t *testing.T) {
+	for _, input := range []string{"", "MB", "abc", "-1MB", "10XB"} {
+		_, err := ParseSize(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,167 @@
+// Package usage reports where the disk space below ~/.claude goes, by
+// top-level category, project, session and month of last modification.
+package usage
+
+import (
+	"cmp"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+)
+
+// Entry is the space used by a group of files.
+type Entry struct {
+	Name    string // Category, project path, session ID or month ("2006-01")
+	Path    string // Directory or file the entry stands for, if any
+	Project string // Project path of a session
+	Files   int
+	Size    int64
+}
+
+// Report totals the files below the Claude directory.
+type Report struct {
+	Root  string
+	Total Entry
+	// Categories holds an entry for each file and directory directly below
+	// Root, largest first.
+	Categories []Entry
+	// Projects holds the projects with the sessions, todos, file-history and
+	// session-env of their sessions, largest first. Projects without a known
+	// path are named by their directory.
+	Projects []Entry
+	// Sessions holds the sessions with their linked data, largest first.
+	Sessions []Entry
+	// Months holds the files by the month they were last modified, in local
+	// time, oldest first.
+	Months []Entry
+}
+
+// Measure walks paths.Root once and totals every regular file below it.
+// Symbolic links are counted but not followed, and entries that cannot be
+// read are skipped. A missing root gives an empty report.
+//
+// The files of the projects directory and the linked session data are
+// attributed to the scanned projects.
+func Measure(paths *claude.Paths, projects []claude.Project) (*Report, error) {
+	r := &Report{Root: paths.Root, Total: Entry{Name: "total", Path: paths.Root}}
+
+	// Linked data is attributed by its path directly below todos,
+	// file-history or session-env
+	owner := make(map[string]string) // Linked path to session ID
+	for id, linked := range cleaner.LinkedData(paths) {
+		for _, path := range linked {
+			owner[path] = id
+		}
+	}
+
+	categories := make(map[string]*Entry)
+	projectDirs := make(map[string]*Entry) // Encoded name to entry
+	sessions := make(map[string]*Entry)    // Session ID to entry
+	sessionProjects := make(map[string]*Entry)
+	sessionFiles := make(map[string]string) // Session file path to session ID
+	months := make(map[string]*Entry)
+	for _, p := range projects {
+		project := &Entry{Name: cmp.Or(p.ActualPath, p.EncodedName), Path: filepath.Join(paths.Projects, p.EncodedName)}
+		projectDirs[p.EncodedName] = project
+		for _, s := range p.Sessions {
+			sessions[s.ID] = &Entry{Name: s.ID, Path: s.FilePath, Project: p.ActualPath}
+			sessionProjects[s.ID] = project
+			sessionFiles[s.FilePath] = s.ID
+		}
+		for _, a := range p.Agents {
+			if sessions[a.ID] != nil {
+				sessionFiles[a.FilePath] = a.ID // Agent transcripts count for their session
+			}
+		}
+	}
+
+	err := filepath.WalkDir(paths.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == paths.Root {
+				return err
+			}
+			return nil // Skip what cannot be read
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(paths.Root, path)
+		if err != nil {
+			return nil
+		}
+		size := info.Size()
+		parts := strings.SplitN(rel, string(filepath.Separator), 3)
+		top := filepath.Join(paths.Root, parts[0])
+
+		r.Total.add(size)
+		entry(categories, parts[0], top).add(size)
+		entry(months, info.ModTime().Local().Format("2006-01"), "").add(size)
+		if len(parts) == 1 {
+			return nil
+		}
+
+		if top == paths.Projects {
+			if project := projectDirs[parts[1]]; project != nil {
+				project.add(size)
+			}
+			if id, ok := sessionFiles[path]; ok {
+				sessions[id].add(size)
+			}
+		} else if id, ok := owner[filepath.Join(top, parts[1])]; ok && sessions[id] != nil {
+			sessions[id].add(size)
+			sessionProjects[id].add(size)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	r.Categories = sorted(categories, bySize)
+	r.Projects = sorted(projectDirs, bySize)
+	r.Sessions = sorted(sessions, bySize)
+	r.Months = sorted(months, func(a, b Entry) int { return strings.Compare(a.Name, b.Name) })
+	return r, nil
+}
+
+// add counts a file of the given size.
+func (e *Entry) add(size int64) {
+	e.Files++
+	e.Size += size
+}
+
+// entry returns the entry named name in m, adding it if necessary.
+func entry(m map[string]*Entry, name, path string) *Entry {
+	e, ok := m[name]
+	if !ok {
+		e = &Entry{Name: name, Path: path}
+		m[name] = e
+	}
+	return e
+}
+
+// bySize orders entries largest first, then by name.
+func bySize(a, b Entry) int {
+	return cmp.Or(cmp.Compare(b.Size, a.Size), strings.Compare(a.Name, b.Name))
+}
+
+// sorted returns the entries of m with at least one file, sorted by order.
+func sorted(m map[string]*Entry, order func(a, b Entry) int) []Entry {
+	entries := make([]Entry, 0, len(m))
+	for _, e := range m {
+		if e.Files > 0 {
+			entries = append(entries, *e)
+		}
+	}
+	slices.SortFunc(entries, order)
+	return entries
+}
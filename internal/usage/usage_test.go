@@ -0,0 +1,96 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
+)
+
+func writeFile(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestMeasure(t *testing.T) {
+	paths, err := claude.DiscoverPaths(t.TempDir())
+	require.NoError(t, err)
+
+	october := time.Date(2025, 10, 15, 12, 0, 0, 0, time.Local)
+	november := time.Date(2025, 11, 15, 12, 0, 0, 0, time.Local)
+
+	// A project with two sessions, one with linked data, and one of
+	// unknown path
+	session1 := filepath.Join(paths.Projects, "-app", "s1.jsonl")
+	session2 := filepath.Join(paths.Projects, "-app", "s2.jsonl")
+	writeFile(t, session1, 100, october)
+	writeFile(t, session2, 10, november)
+	writeFile(t, filepath.Join(paths.Projects, "-app", "s1", "subagents", "agent-1.jsonl"), 5, november)
+	writeFile(t, filepath.Join(paths.Todos, "s1-agent-s1.json"), 20, november)
+	writeFile(t, filepath.Join(paths.FileHistory, "s1", "abc@v1"), 300, october)
+	writeFile(t, filepath.Join(paths.SessionEnv, "s1", "env"), 1, november)
+	writeFile(t, filepath.Join(paths.Projects, "-unknown", "s3.jsonl"), 7, november)
+
+	// Data of other categories and of a session that no longer exists
+	writeFile(t, filepath.Join(paths.FileHistory, "gone", "def@v1"), 50, november)
+	writeFile(t, filepath.Join(paths.Root, "debug", "s1.txt"), 1000, november)
+	writeFile(t, filepath.Join(paths.Root, "shell-snapshots", "snapshot-zsh-1.sh"), 40, november)
+	writeFile(t, filepath.Join(paths.Root, "history.jsonl"), 3, november)
+
+	projects := []claude.Project{
+		{EncodedName: "-app", ActualPath: "/work/app", Sessions: []claude.SessionInfo{
+			{ID: "s1", FilePath: session1, Size: 100},
+			{ID: "s2", FilePath: session2, Size: 10},
+		}},
+		{EncodedName: "-unknown", Sessions: []claude.SessionInfo{
+			{ID: "s3", FilePath: filepath.Join(paths.Projects, "-unknown", "s3.jsonl"), Size: 7},
+		}},
+	}
+
+	r, err := Measure(paths, projects)
+	require.NoError(t, err)
+
+	assert.Equal(t, Entry{Name: "total", Path: paths.Root, Files: 11, Size: 1536}, r.Total)
+	assert.Equal(t, []Entry{
+		{Name: "debug", Path: filepath.Join(paths.Root, "debug"), Files: 1, Size: 1000},
+		{Name: "file-history", Path: paths.FileHistory, Files: 2, Size: 350},
+		{Name: "projects", Path: paths.Projects, Files: 4, Size: 122},
+		{Name: "shell-snapshots", Path: filepath.Join(paths.Root, "shell-snapshots"), Files: 1, Size: 40},
+		{Name: "todos", Path: paths.Todos, Files: 1, Size: 20},
+		{Name: "history.jsonl", Path: filepath.Join(paths.Root, "history.jsonl"), Files: 1, Size: 3},
+		{Name: "session-env", Path: paths.SessionEnv, Files: 1, Size: 1},
+	}, r.Categories)
+
+	assert.Equal(t, []Entry{
+		{Name: "/work/app", Path: filepath.Join(paths.Projects, "-app"), Files: 6, Size: 436},
+		{Name: "-unknown", Path: filepath.Join(paths.Projects, "-unknown"), Files: 1, Size: 7},
+	}, r.Projects, "projects include the linked data of their sessions")
+
+	assert.Equal(t, []Entry{
+		{Name: "s1", Path: session1, Project: "/work/app", Files: 4, Size: 421},
+		{Name: "s2", Path: session2, Project: "/work/app", Files: 1, Size: 10},
+		{Name: "s3", Path: filepath.Join(paths.Projects, "-unknown", "s3.jsonl"), Files: 1, Size: 7},
+	}, r.Sessions)
+
+	assert.Equal(t, []Entry{
+		{Name: "2025-10", Files: 2, Size: 400},
+		{Name: "2025-11", Files: 9, Size: 1136},
+	}, r.Months)
+}
+
+func TestMeasure_MissingRoot(t *testing.T) {
+	paths, err := claude.DiscoverPaths(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+
+	r, err := Measure(paths, nil)
+	require.NoError(t, err)
+	assert.Zero(t, r.Total.Files)
+	assert.Empty(t, r.Categories)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/mkoepf/claude-code-config-cleaner/internal/claude"
 	"github.com/mkoepf/claude-code-config-cleaner/internal/cleaner"
+	"github.com/mkoepf/claude-code-config-cleaner/internal/trash"
 	"github.com/mkoepf/claude-code-config-cleaner/internal/ui"
 )
 
@@ -226,7 +227,7 @@ func TestSafety_PartialFailureStops(t *testing.T) {
 	}
 
 	// This should fail
-	_, err := cleaner.CleanOrphans(protectedOrphans, false)
+	_, err := cleaner.CleanOrphans(protectedOrphans, nil, false)
 	if err == nil {
 		// On some systems this might succeed, so we check if file still exists
 		if _, statErr := os.Stat(readonlyFile); statErr != nil {
@@ -323,7 +324,7 @@ func TestSafety_PreviewMatchesAction(t *testing.T) {
 
 	// Now actually clean (non-dry-run)
 	for _, p := range stale {
-		_, err := cleaner.CleanStaleProject(projectsDir, p, false)
+		_, err := cleaner.CleanStaleProject(projectsDir, p, nil, false)
 		if err != nil {
 			t.Errorf("failed to clean stale project: %v", err)
 		}
@@ -377,7 +378,7 @@ func TestSafety_DryRunNeverModifies(t *testing.T) {
 
 	// Run with dryRun=true
 	for _, p := range stale {
-		result, err := cleaner.CleanStaleProject(projectsDir, p, true)
+		result, err := cleaner.CleanStaleProject(projectsDir, p, nil, true)
 		if err != nil {
 			t.Errorf("dry run failed: %v", err)
 		}
@@ -438,3 +439,162 @@ func TestSafety_EmptyPathNeverDeletes(t *testing.T) {
 		t.Errorf("preview should indicate 'no cwd found' for empty path projects")
 	}
 }
+
+// snapshotTree records the contents and permissions of every file below root.
+func snapshotTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	snapshot := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if info.IsDir() {
+			snapshot[rel+"/"] = info.Mode().Perm().String()
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		snapshot[rel] = info.Mode().Perm().String() + " " + string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to snapshot %s: %v", root, err)
+	}
+	return snapshot
+}
+
+// TestSafety_RestoreRoundTrips verifies that restoring a trash run brings back
+// every removed or rewritten file byte for byte.
+func TestSafety_RestoreRoundTrips(t *testing.T) {
+	tmpHome := t.TempDir()
+	claudeDir := filepath.Join(tmpHome, ".claude")
+	paths, err := claude.DiscoverPaths(claudeDir)
+	if err != nil {
+		t.Fatalf("failed to discover paths: %v", err)
+	}
+
+	// Stale project with two sessions
+	staleProjectPath := filepath.Join(tmpHome, "deleted-project")
+	staleDir := filepath.Join(paths.Projects, strings.ReplaceAll(staleProjectPath, "/", "-"))
+	if err := os.MkdirAll(staleDir, 0755); err != nil {
+		t.Fatalf("failed to create stale project dir: %v", err)
+	}
+	for _, name := range []string{"a.jsonl", "b.jsonl"} {
+		content := `{"sessionId":"` + name + `","cwd":"` + filepath.ToSlash(staleProjectPath) + `","timestamp":"2025-01-01T00:00:00Z"}` + "\n"
+		if err := os.WriteFile(filepath.Join(staleDir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write session: %v", err)
+		}
+	}
+
+	// Orphan todo and file-history
+	if err := os.MkdirAll(paths.Todos, 0755); err != nil {
+		t.Fatalf("failed to create todos dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(paths.Todos, "gone-agent-gone.json"), []byte(`[{"content":"x"}]`), 0644); err != nil {
+		t.Fatalf("failed to write todo: %v", err)
+	}
+	historyDir := filepath.Join(paths.FileHistory, "gone", "nested")
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		t.Fatalf("failed to create file-history dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(historyDir, "abc@v1"), []byte("snapshot\x00bytes"), 0644); err != nil {
+		t.Fatalf("failed to write file-history: %v", err)
+	}
+
+	// Local config with a duplicate and a file that is entirely duplicate
+	if err := os.WriteFile(paths.Settings, []byte(`{"permissions":{"allow":["Bash(git:*)"]}}`), 0644); err != nil {
+		t.Fatalf("failed to write global settings: %v", err)
+	}
+	projectA := filepath.Join(tmpHome, "project-a", ".claude")
+	projectB := filepath.Join(tmpHome, "project-b", ".claude")
+	for _, dir := range []string{projectA, projectB} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create project config dir: %v", err)
+		}
+	}
+	localA := filepath.Join(projectA, "settings.local.json")
+	localB := filepath.Join(projectB, "settings.local.json")
+	if err := os.WriteFile(localA, []byte("{\n  \"permissions\": {\n    \"allow\": [\"Bash(git:*)\", \"Bash(make:*)\"]\n  },\n  \"env\": {\"A\": \"1\"}\n}\n"), 0640); err != nil {
+		t.Fatalf("failed to write local settings: %v", err)
+	}
+	if err := os.WriteFile(localB, []byte(`{"permissions":{"allow":["Bash(git:*)"]}}`), 0600); err != nil {
+		t.Fatalf("failed to write local settings: %v", err)
+	}
+
+	before := snapshotTree(t, tmpHome)
+
+	// Clean everything into a single trash run
+	store := trash.NewStore(trash.DefaultDir(paths.Root))
+	run := store.Begin("clean")
+
+	projects, err := claude.ScanProjects(paths.Projects)
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+	for _, p := range cleaner.FindStaleProjects(projects) {
+		if _, err := cleaner.CleanStaleProject(paths.Projects, p, run, false); err != nil {
+			t.Fatalf("failed to clean stale project: %v", err)
+		}
+	}
+
+	orphans, err := cleaner.FindOrphans(paths, nil, nil, cleaner.OrphanRules{})
+	if err != nil {
+		t.Fatalf("failed to find orphans: %v", err)
+	}
+	if _, err := cleaner.CleanOrphans(orphans, run, false); err != nil {
+		t.Fatalf("failed to clean orphans: %v", err)
+	}
+
+	global, err := claude.LoadSettings(paths.Settings)
+	if err != nil {
+		t.Fatalf("failed to load global settings: %v", err)
+	}
+	for _, localPath := range []string{localA, localB} {
+		local, err := claude.LoadSettings(localPath)
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", localPath, err)
+		}
+		result := cleaner.DeduplicateConfig(localPath, global, local)
+		if err := cleaner.ApplyDedup(result, run, false); err != nil {
+			t.Fatalf("failed to dedup %s: %v", localPath, err)
+		}
+	}
+
+	if _, err := os.Stat(staleDir); !os.IsNotExist(err) {
+		t.Fatalf("stale project was not removed")
+	}
+	if _, err := os.Stat(localB); !os.IsNotExist(err) {
+		t.Fatalf("fully duplicate local config was not removed")
+	}
+	if run.ID() == "" {
+		t.Fatalf("nothing was quarantined")
+	}
+
+	if _, err := store.Restore(run.ID(), nil); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	after := snapshotTree(t, tmpHome)
+
+	// A fully restored run is dropped, leaving only the empty trash root
+	delete(after, filepath.Join(".claude", "cccc-trash")+"/")
+
+	for path, want := range before {
+		got, ok := after[path]
+		if !ok {
+			t.Errorf("%s was not restored", path)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s differs after restore:\nbefore: %q\nafter:  %q", path, want, got)
+		}
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			t.Errorf("unexpected file after restore: %s", path)
+		}
+	}
+}